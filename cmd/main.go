@@ -2,14 +2,31 @@ package main
 
 import (
 	"context"
+	"flag"
+	"fmt"
 	"log"
+	"os"
+	"strconv"
 
 	_ "github.com/joho/godotenv/autoload"
 
+	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/raihaninkam/finalPhase3/internals/configs"
+	"github.com/raihaninkam/finalPhase3/internals/jobs"
+	"github.com/raihaninkam/finalPhase3/internals/repositories"
 	"github.com/raihaninkam/finalPhase3/internals/routers"
+	"github.com/raihaninkam/finalPhase3/pkg"
 )
 
+const usage = `usage:
+  server [--auto-migrate]      menjalankan HTTP server
+  server migrate up            menjalankan semua migration yang belum dijalankan
+  server migrate down [n]      rollback n migration terakhir (default 1)
+  server migrate status        menampilkan versi database dan migration yang pending
+  server migrate force <v>     menandai database di versi v (membersihkan status dirty)
+  server seed                  mengisi database dengan data contoh (db/seeds)
+`
+
 // @title 					Social Media
 // @version 				1.0
 // @host						localhost:3009
@@ -19,11 +36,15 @@ import (
 // @name Authorization
 // @description Masukkan format: Bearer <token>
 func main() {
+	autoMigrate := flag.Bool("auto-migrate", os.Getenv("AUTO_MIGRATE") == "true", "jalankan migration sebelum server start")
+	flag.Usage = func() { fmt.Fprint(os.Stderr, usage) }
+	flag.Parse()
+
 	// Inisialization databae for this project
 	db, err := configs.InitPg()
 	if err != nil {
 		log.Println("FAILED TO CONNECT DB")
-		return
+		os.Exit(1)
 	}
 
 	defer db.Close()
@@ -31,11 +52,56 @@ func main() {
 	err = configs.PingDB(db)
 	if err != nil {
 		log.Println("PING TO DB FAILED", err.Error())
-		return
+		os.Exit(1)
 	}
 
 	log.Println("DB CONNECTED")
 
+	switch flag.Arg(0) {
+	case "":
+	case "migrate":
+		if err := runMigrate(db, flag.Args()[1:]); err != nil {
+			log.Println("MIGRATION FAILED\nCause:", err.Error())
+			db.Close()
+			os.Exit(1)
+		}
+		return
+	case "seed":
+		if err := configs.Seed(context.Background(), db); err != nil {
+			log.Println("SEED FAILED\nCause:", err.Error())
+			db.Close()
+			os.Exit(1)
+		}
+		return
+	default:
+		flag.Usage()
+		db.Close()
+		os.Exit(2)
+	}
+
+	if *autoMigrate {
+		if _, err := configs.MigrateUp(context.Background(), db); err != nil {
+			log.Println("MIGRATION FAILED\nCause:", err.Error())
+			return
+		}
+	}
+
+	// pastikan skema database sesuai dengan versi kode
+	if err := configs.CheckSchema(db); err != nil {
+		log.Println("INCOMPATIBLE DB SCHEMA\nCause:", err.Error())
+		return
+	}
+
+	// load key jwt dan secret cursor lebih awal agar konfigurasi yang salah langsung ketahuan
+	if err := pkg.LoadSigningKeys(); err != nil {
+		log.Println("FAILED TO LOAD JWT KEYS\nCause:", err.Error())
+		return
+	}
+	if err := pkg.LoadCursorSecret(); err != nil {
+		log.Println("FAILED TO LOAD CURSOR SECRET\nCause:", err.Error())
+		return
+	}
+
 	// inisialization redis
 	rdb := configs.InitRedis()
 	cmd := rdb.Ping(context.Background())
@@ -46,7 +112,79 @@ func main() {
 	log.Println("Redis Connected")
 	defer rdb.Close()
 
+	// background job penghapusan akun yang sudah melewati masa tenggang
+	go jobs.NewAccountPurger(repositories.NewAccountRepository(db, rdb)).Start(context.Background())
+
+	// background job pembuatan arsip export data user
+	go jobs.NewDataExporter(repositories.NewExportRepository(db, rdb)).Start(context.Background())
+
+	// background job perhitungan rekomendasi akun (who to follow)
+	go jobs.NewSuggestionBuilder(repositories.NewSuggestionRepository(db, rdb)).Start(context.Background())
+
+	// inisialization mailer (smtp / log)
+	mailer := configs.InitMailer()
+
 	// Inisialization engine gin, HTTP framework
-	router := routers.InitRouter(db, rdb)
+	router := routers.InitRouter(db, rdb, mailer)
 	router.Run(":3009")
 }
+
+// runMigrate menjalankan subcommand migrate up|down|status|force
+func runMigrate(db *pgxpool.Pool, args []string) error {
+	ctx := context.Background()
+	if len(args) == 0 {
+		flag.Usage()
+		return fmt.Errorf("missing migrate command")
+	}
+
+	switch args[0] {
+	case "up":
+		applied, err := configs.MigrateUp(ctx, db)
+		if err != nil {
+			return err
+		}
+		if len(applied) == 0 {
+			log.Println("no change")
+		}
+		return nil
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			n, err := strconv.Atoi(args[1])
+			if err != nil || n < 1 {
+				return fmt.Errorf("invalid number of steps %q", args[1])
+			}
+			steps = n
+		}
+		applied, err := configs.MigrateDown(ctx, db, steps)
+		if err != nil {
+			return err
+		}
+		if len(applied) == 0 {
+			log.Println("no change")
+		}
+		return nil
+	case "status":
+		status, err := configs.GetMigrationStatus(ctx, db)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("version: %d (dirty: %t)\nlatest:  %d\n", status.Version, status.Dirty, status.Latest)
+		for _, m := range status.Pending {
+			fmt.Printf("pending: %d_%s\n", m.Version, m.Name)
+		}
+		return nil
+	case "force":
+		if len(args) < 2 {
+			return fmt.Errorf("missing version")
+		}
+		version, err := strconv.ParseUint(args[1], 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid version %q", args[1])
+		}
+		return configs.ForceVersion(ctx, db, version)
+	default:
+		flag.Usage()
+		return fmt.Errorf("unknown migrate command %q", args[0])
+	}
+}
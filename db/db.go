@@ -0,0 +1,20 @@
+// Package db menyimpan file migration dan seed SQL di dalam binary server,
+// sehingga image Docker tidak membutuhkan CLI migrate maupun folder db
+package db
+
+import "embed"
+
+//go:embed migrations/*.sql
+var Migrations embed.FS
+
+//go:embed seeds/*.sql
+var Seeds embed.FS
+
+// SeedOrder adalah urutan file seed, mengikuti foreign key antar tabel
+var SeedOrder = []string{
+	"seed_users.sql",
+	"seed_post.sql",
+	"seed_follows.sql",
+	"seeds_likes.sql",
+	"seed_comment.sql",
+}
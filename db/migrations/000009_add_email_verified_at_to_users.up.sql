@@ -0,0 +1,4 @@
+ALTER TABLE users ADD COLUMN email_verified_at TIMESTAMP WITH TIME ZONE;
+
+-- akun yang sudah ada sebelum fitur verifikasi dianggap sudah terverifikasi
+UPDATE users SET email_verified_at = COALESCE(created_at, CURRENT_TIMESTAMP);
@@ -0,0 +1,5 @@
+DROP TABLE mfa_recovery_codes;
+
+ALTER TABLE users
+    DROP COLUMN mfa_secret,
+    DROP COLUMN mfa_enabled;
@@ -0,0 +1,2 @@
+-- tidak ada yang perlu di-rollback, skema setelah migration ini sama dengan 000001
+SELECT 1;
@@ -0,0 +1,13 @@
+-- database lama yang dibuat di luar migration ini memakai kolom "password",
+-- samakan dengan skema 000001 (password_hash) agar aplikasi bisa jalan di keduanya
+DO $$
+BEGIN
+    IF EXISTS (SELECT 1 FROM information_schema.columns WHERE table_name = 'users' AND column_name = 'password')
+        AND NOT EXISTS (SELECT 1 FROM information_schema.columns WHERE table_name = 'users' AND column_name = 'password_hash') THEN
+        ALTER TABLE users RENAME COLUMN password TO password_hash;
+    END IF;
+END $$;
+
+-- name wajib diisi, user lama tanpa nama memakai username
+UPDATE users SET name = username WHERE name IS NULL OR name = '';
+ALTER TABLE users ALTER COLUMN name SET NOT NULL;
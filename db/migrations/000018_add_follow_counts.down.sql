@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_follows_following_id;
+ALTER TABLE users DROP COLUMN follower_count, DROP COLUMN following_count;
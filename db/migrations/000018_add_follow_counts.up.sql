@@ -0,0 +1,9 @@
+ALTER TABLE users
+    ADD COLUMN follower_count INTEGER NOT NULL DEFAULT 0 CHECK (follower_count >= 0),
+    ADD COLUMN following_count INTEGER NOT NULL DEFAULT 0 CHECK (following_count >= 0);
+
+UPDATE users u SET
+    follower_count = (SELECT COUNT(*) FROM follows WHERE following_id = u.id),
+    following_count = (SELECT COUNT(*) FROM follows WHERE follower_id = u.id);
+
+CREATE INDEX idx_follows_following_id ON follows(following_id, created_at DESC);
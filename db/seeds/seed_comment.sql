@@ -7,4 +7,4 @@ INSERT INTO comments (id, user_id, post_id, content, created_at) VALUES
 -- Comments on Sarah's posts
 ('22222222-2222-2222-2222-222222222221', '11111111-1111-1111-1111-111111111111', '22222222-2222-2222-2222-222222222221', 'Amazing insights! Just shared with my team.', '2024-03-03 11:45:00+07'),
 ('22222222-2222-2222-2222-222222222222', '55555555-5555-5555-5555-555555555555', '22222222-2222-2222-2222-222222222221', 'This is exactly what we needed for our strategy!', '2024-03-03 12:30:00+07'),
-('22222222-2222-2222-2222-222222222223', '33333333-3333-3333-3333-333333333333', '22222222-2222-2222-2222-222222222222', 'Living the dream! Bali looks amazing 🌴', '2024-03-04 09:15:00+07'),
\ No newline at end of file
+('22222222-2222-2222-2222-222222222223', '33333333-3333-3333-3333-333333333333', '22222222-2222-2222-2222-222222222222', 'Living the dream! Bali looks amazing 🌴', '2024-03-04 09:15:00+07');
\ No newline at end of file
@@ -6,4 +6,9 @@ INSERT INTO follows (follower_id, following_id, created_at) VALUES
 
 -- Sarah follows several people
 ('22222222-2222-2222-2222-222222222222', '11111111-1111-1111-1111-111111111111', '2024-02-04 13:00:00+07'),
-('22222222-2222-2222-2222-222222222222', '55555555-5555-5555-5555-555555555555', '2024-02-05 14:00:00+07'),
\ No newline at end of file
+('22222222-2222-2222-2222-222222222222', '55555555-5555-5555-5555-555555555555', '2024-02-05 14:00:00+07');
+
+-- follower_count / following_count dihitung ulang dari data follows
+UPDATE users u SET
+    follower_count = (SELECT COUNT(*) FROM follows WHERE following_id = u.id),
+    following_count = (SELECT COUNT(*) FROM follows WHERE follower_id = u.id);
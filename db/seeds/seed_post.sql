@@ -5,4 +5,4 @@ INSERT INTO posts (id, user_id, content_text, image_url, created_at) VALUES
 
 -- Sarah Connor's posts
 ('22222222-2222-2222-2222-222222222221', '22222222-2222-2222-2222-222222222222', 'Excited to share my new blog post about digital marketing trends 2024! Check it out!', 'https://images.unsplash.com/photo-1486312338219-ce68d2c6f44d?w=500&h=300&fit=crop', '2024-03-03 10:15:00+07'),
-('22222222-2222-2222-2222-222222222222', '22222222-2222-2222-2222-222222222222', 'Bali vibes 🏝️ Working from paradise this week! #digitalnomad', NULL, '2024-03-04 08:45:00+07'),
\ No newline at end of file
+('22222222-2222-2222-2222-222222222222', '22222222-2222-2222-2222-222222222222', 'Bali vibes 🏝️ Working from paradise this week! #digitalnomad', NULL, '2024-03-04 08:45:00+07');
\ No newline at end of file
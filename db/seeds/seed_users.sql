@@ -1,6 +1,8 @@
--- Password: password123 (hash bcrypt: $2a$10$92IXUNpkjO0rOQ5byMi.Ye4oKoEa3Ro9llC/.og/at2.uheWG/igi)
-INSERT INTO users (id, username, email, password_hash, name, avatar_url, bio, created_at) VALUES
-('raihaninsan@gmail.com', '$argon2id$v=19$m=65536,t=2,p=1$qZBbdgEMlxVZXIeoevqcTw$1JZZegb6sqzzSGvZzpFZgH9365MjSwUmRJKld7BtktI', 'Sarah Connor', 'https://images.unsplash.com/photo-1494790108755-2616b612b786?w=150&h=150&fit=crop&crop=face', 'Digital marketer and travel blogger', '2024-01-16 11:30:00+07'),
-('inkam@mail.com', '$argon2id$v=19$m=65536,t=2,p=1$qZBbdgEMlxVZXIeoevqcTw$1JZZegb6sqzzSGvZzpFZgH9365MjSwUmRJKld7BtktI', 'Mike Chen', 'https://images.unsplash.com/photo-1507003211169-0a1dd7228f2d?w=150&h=150&fit=crop&crop=face', 'Food lover and fitness coach', '2024-01-17 14:20:00+07')
-('11111111-1111-1111-1111-111111111111', 'john_doe', 'john@example.com', '$2a$10$92IXUNpkjO0rOQ5byMi.Ye4oKoEa3Ro9llC/.og/at2.uheWG/igi', 'John Doe', 'https://images.unsplash.com/photo-1472099645785-5658abf4ff4e?w=150&h=150&fit=crop&crop=face', 'Software engineer and photography enthusiast', '2024-01-15 10:00:00+07'),
-('22222222-2222-2222-2222-222222222222', 'sarah_connor', 'sarah@example.com', '$2a$10$92IXUNpkjO0rOQ5byMi.Ye4oKoEa3Ro9llC/.og/at2.uheWG/igi', 'Sarah Connor', 'https://images.unsplash.com/photo-1494790108755-2616b612b786?w=150&h=150&fit=crop&crop=face', 'Digital marketer and travel blogger', '2024-01-16 11:30:00+07');
\ No newline at end of file
+-- Password john, sarah, mike: password (hash bcrypt, otomatis di-upgrade ke argon2 saat login)
+-- Password raihan, inkam: hash argon2
+INSERT INTO users (id, username, email, password_hash, name, avatar_url, bio, email_verified_at, created_at) VALUES
+('11111111-1111-1111-1111-111111111111', 'john_doe', 'john@example.com', '$2a$10$92IXUNpkjO0rOQ5byMi.Ye4oKoEa3Ro9llC/.og/at2.uheWG/igi', 'John Doe', 'https://images.unsplash.com/photo-1472099645785-5658abf4ff4e?w=150&h=150&fit=crop&crop=face', 'Software engineer and photography enthusiast', '2024-01-15 10:00:00+07', '2024-01-15 10:00:00+07'),
+('22222222-2222-2222-2222-222222222222', 'sarah_connor', 'sarah@example.com', '$2a$10$92IXUNpkjO0rOQ5byMi.Ye4oKoEa3Ro9llC/.og/at2.uheWG/igi', 'Sarah Connor', 'https://images.unsplash.com/photo-1494790108755-2616b612b786?w=150&h=150&fit=crop&crop=face', 'Digital marketer and travel blogger', '2024-01-16 11:30:00+07', '2024-01-16 11:30:00+07'),
+('33333333-3333-3333-3333-333333333333', 'raihaninsan', 'raihaninsan@gmail.com', '$argon2id$v=19$m=65536,t=2,p=1$qZBbdgEMlxVZXIeoevqcTw$1JZZegb6sqzzSGvZzpFZgH9365MjSwUmRJKld7BtktI', 'Raihan Insan', NULL, 'Backend developer', '2024-01-16 12:00:00+07', '2024-01-16 12:00:00+07'),
+('44444444-4444-4444-4444-444444444444', 'inkam', 'inkam@mail.com', '$argon2id$v=19$m=65536,t=2,p=1$qZBbdgEMlxVZXIeoevqcTw$1JZZegb6sqzzSGvZzpFZgH9365MjSwUmRJKld7BtktI', 'Inkam', NULL, NULL, '2024-01-17 09:00:00+07', '2024-01-17 09:00:00+07'),
+('55555555-5555-5555-5555-555555555555', 'mike_chen', 'mike@example.com', '$2a$10$92IXUNpkjO0rOQ5byMi.Ye4oKoEa3Ro9llC/.og/at2.uheWG/igi', 'Mike Chen', 'https://images.unsplash.com/photo-1507003211169-0a1dd7228f2d?w=150&h=150&fit=crop&crop=face', 'Food lover and fitness coach', '2024-01-17 14:20:00+07', '2024-01-17 14:20:00+07');
@@ -8,4 +8,4 @@ INSERT INTO likes (user_id, post_id, created_at) VALUES
 -- Likes on Sarah's posts
 ('11111111-1111-1111-1111-111111111111', '22222222-2222-2222-2222-222222222221', '2024-03-03 11:30:00+07'),
 ('55555555-5555-5555-5555-555555555555', '22222222-2222-2222-2222-222222222221', '2024-03-03 12:00:00+07'),
-('33333333-3333-3333-3333-333333333333', '22222222-2222-2222-2222-222222222222', '2024-03-04 09:30:00+07'),
\ No newline at end of file
+('33333333-3333-3333-3333-333333333333', '22222222-2222-2222-2222-222222222222', '2024-03-04 09:30:00+07');
\ No newline at end of file
@@ -0,0 +1,35 @@
+package configs
+
+import (
+	"os"
+	"time"
+)
+
+const (
+	EmailVerificationOff   = "off"
+	EmailVerificationLogin = "login"
+	EmailVerificationPost  = "post"
+)
+
+// EmailVerificationMode menentukan kapan akun yang belum verifikasi email ditolak (env EMAIL_VERIFICATION):
+// "login" -> tidak bisa login, "post" -> bisa login tapi tidak bisa membuat post/comment, selain itu off
+func EmailVerificationMode() string {
+	switch mode := os.Getenv("EMAIL_VERIFICATION"); mode {
+	case EmailVerificationLogin, EmailVerificationPost:
+		return mode
+	default:
+		return EmailVerificationOff
+	}
+}
+
+const defaultAccountDeletionGrace = 30 * 24 * time.Hour
+
+// AccountDeletionGrace adalah jeda sebelum akun yang diminta dihapus benar-benar dihapus (env ACCOUNT_DELETION_GRACE,
+// format time.Duration, contoh "720h"). Selama jeda ini penghapusan bisa dibatalkan dengan login
+func AccountDeletionGrace() time.Duration {
+	grace, err := time.ParseDuration(os.Getenv("ACCOUNT_DELETION_GRACE"))
+	if err != nil || grace < 0 {
+		return defaultAccountDeletionGrace
+	}
+	return grace
+}
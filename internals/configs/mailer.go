@@ -0,0 +1,21 @@
+package configs
+
+import (
+	"os"
+
+	"github.com/raihaninkam/finalPhase3/pkg"
+)
+
+// InitMailer memilih implementasi mailer dari env MAIL_DRIVER (smtp | log), default log
+func InitMailer() pkg.Mailer {
+	if os.Getenv("MAIL_DRIVER") == "smtp" {
+		return pkg.NewSMTPMailer(
+			os.Getenv("SMTP_HOST"),
+			os.Getenv("SMTP_PORT"),
+			os.Getenv("SMTP_USER"),
+			os.Getenv("SMTP_PASS"),
+			os.Getenv("MAIL_FROM"),
+		)
+	}
+	return pkg.NewLogMailer(os.Getenv("MAIL_LOG_PATH"))
+}
@@ -0,0 +1,330 @@
+package configs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	database "github.com/raihaninkam/finalPhase3/db"
+)
+
+// migrationLockID adalah key pg_advisory_lock agar dua instance tidak menjalankan migration bersamaan
+const migrationLockID = 7212093401
+
+// Migration adalah satu pasang file <version>_<name>.up.sql / .down.sql di db/migrations
+type Migration struct {
+	Version uint64
+	Name    string
+	Up      string
+	Down    string
+}
+
+// MigrationStatus adalah versi database saat ini beserta migration yang belum dijalankan
+type MigrationStatus struct {
+	Version uint64
+	Dirty   bool
+	Latest  uint64
+	Pending []Migration
+}
+
+// LoadMigrations membaca migration yang di-embed ke binary, diurutkan berdasarkan versi
+func LoadMigrations() ([]Migration, error) {
+	files, err := fs.ReadDir(database.Migrations, "migrations")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := map[uint64]*Migration{}
+	for _, file := range files {
+		name := file.Name()
+		var direction string
+		switch {
+		case strings.HasSuffix(name, ".up.sql"):
+			direction = "up"
+		case strings.HasSuffix(name, ".down.sql"):
+			direction = "down"
+		default:
+			continue
+		}
+
+		prefix, rest, ok := strings.Cut(name, "_")
+		if !ok {
+			return nil, fmt.Errorf("invalid migration file name %s", name)
+		}
+		version, err := strconv.ParseUint(prefix, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration version in %s", name)
+		}
+		content, err := fs.ReadFile(database.Migrations, path.Join("migrations", name))
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: strings.TrimSuffix(rest, "."+direction+".sql")}
+			byVersion[version] = m
+		}
+		if direction == "up" {
+			m.Up = string(content)
+		} else {
+			m.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" {
+			return nil, fmt.Errorf("migration %d has no up file", m.Version)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// SchemaVersion mengembalikan versi migration terbaru yang di-embed, yaitu versi yang dibutuhkan kode ini
+func SchemaVersion() (uint64, error) {
+	migrations, err := LoadMigrations()
+	if err != nil {
+		return 0, err
+	}
+	if len(migrations) == 0 {
+		return 0, nil
+	}
+	return migrations[len(migrations)-1].Version, nil
+}
+
+// CheckSchema memastikan database sudah dimigrasi sampai SchemaVersion dan tidak dalam keadaan dirty.
+// Tabel schema_migrations kompatibel dengan golang-migrate
+func CheckSchema(db *pgxpool.Pool) error {
+	status, err := GetMigrationStatus(context.Background(), db)
+	if err != nil {
+		return err
+	}
+	if status.Dirty {
+		return fmt.Errorf("database schema version %d is dirty, fix the failed migration and force the version", status.Version)
+	}
+	if status.Version == 0 && status.Latest > 0 {
+		return errors.New("database is not migrated, run the migrations first")
+	}
+	if status.Version < status.Latest {
+		return fmt.Errorf("database schema version %d is older than required version %d, run the migrations first", status.Version, status.Latest)
+	}
+	if status.Version > status.Latest {
+		// migration terbaru biasanya hanya menambah kolom / tabel, kode lama masih bisa jalan
+		log.Printf("database schema version %d is newer than expected version %d\n", status.Version, status.Latest)
+	}
+	return nil
+}
+
+// GetMigrationStatus membaca versi database dan migration yang belum dijalankan
+func GetMigrationStatus(ctx context.Context, db *pgxpool.Pool) (*MigrationStatus, error) {
+	migrations, err := LoadMigrations()
+	if err != nil {
+		return nil, err
+	}
+	version, dirty, err := currentVersion(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+
+	status := &MigrationStatus{Version: version, Dirty: dirty}
+	for _, m := range migrations {
+		status.Latest = m.Version
+		if m.Version > version {
+			status.Pending = append(status.Pending, m)
+		}
+	}
+	return status, nil
+}
+
+// MigrateUp menjalankan semua migration yang belum dijalankan. Setiap migration dijalankan di dalam transaction
+// bersama update versinya, sehingga migration yang gagal tidak meninggalkan database dalam keadaan dirty
+func MigrateUp(ctx context.Context, db *pgxpool.Pool) ([]Migration, error) {
+	conn, err := lockMigrations(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+	defer unlockMigrations(ctx, conn)
+
+	if err := ensureMigrationTable(ctx, db); err != nil {
+		return nil, err
+	}
+	status, err := GetMigrationStatus(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+	if status.Dirty {
+		return nil, fmt.Errorf("database schema version %d is dirty", status.Version)
+	}
+
+	for i, m := range status.Pending {
+		if err := applyMigration(ctx, conn.Conn(), m.Up, m.Version); err != nil {
+			return status.Pending[:i], fmt.Errorf("migration %d_%s failed: %w", m.Version, m.Name, err)
+		}
+		log.Printf("migrated %d_%s\n", m.Version, m.Name)
+	}
+	return status.Pending, nil
+}
+
+// MigrateDown me-rollback sejumlah steps migration terakhir
+func MigrateDown(ctx context.Context, db *pgxpool.Pool, steps int) ([]Migration, error) {
+	conn, err := lockMigrations(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+	defer unlockMigrations(ctx, conn)
+
+	migrations, err := LoadMigrations()
+	if err != nil {
+		return nil, err
+	}
+	if err := ensureMigrationTable(ctx, db); err != nil {
+		return nil, err
+	}
+	version, dirty, err := currentVersion(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+	if dirty {
+		return nil, fmt.Errorf("database schema version %d is dirty", version)
+	}
+
+	var applied []Migration
+	for i := len(migrations) - 1; i >= 0 && len(applied) < steps; i-- {
+		m := migrations[i]
+		if m.Version > version {
+			continue
+		}
+		var previous uint64
+		if i > 0 {
+			previous = migrations[i-1].Version
+		}
+		if err := applyMigration(ctx, conn.Conn(), m.Down, previous); err != nil {
+			return applied, fmt.Errorf("rollback %d_%s failed: %w", m.Version, m.Name, err)
+		}
+		log.Printf("rolled back %d_%s\n", m.Version, m.Name)
+		applied = append(applied, m)
+	}
+	return applied, nil
+}
+
+// ForceVersion menandai database berada di versi tertentu tanpa menjalankan migration,
+// dipakai untuk membersihkan status dirty setelah migration yang gagal diperbaiki manual
+func ForceVersion(ctx context.Context, db *pgxpool.Pool, version uint64) error {
+	if err := ensureMigrationTable(ctx, db); err != nil {
+		return err
+	}
+	return setVersion(ctx, db, version)
+}
+
+// Seed menjalankan file seed (db/seeds) sesuai urutan foreign key di dalam satu transaction
+func Seed(ctx context.Context, db *pgxpool.Pool) error {
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	for _, name := range database.SeedOrder {
+		content, err := fs.ReadFile(database.Seeds, path.Join("seeds", name))
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(ctx, string(content)); err != nil {
+			return fmt.Errorf("seed %s failed: %w", name, err)
+		}
+		log.Printf("seeded %s\n", name)
+	}
+	return tx.Commit(ctx)
+}
+
+type sqlExecer interface {
+	Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error)
+}
+
+func ensureMigrationTable(ctx context.Context, db *pgxpool.Pool) error {
+	_, err := db.Exec(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (version BIGINT NOT NULL PRIMARY KEY, dirty BOOLEAN NOT NULL)`)
+	return err
+}
+
+func currentVersion(ctx context.Context, db *pgxpool.Pool) (uint64, bool, error) {
+	// tabel belum ada berarti database belum pernah dimigrasi. Dicek tanpa membuat tabel agar
+	// pengecekan status (CheckSchema, migrate status) tidak menulis ke database
+	var exists bool
+	if err := db.QueryRow(ctx, `SELECT to_regclass('schema_migrations') IS NOT NULL`).Scan(&exists); err != nil {
+		return 0, false, err
+	}
+	if !exists {
+		return 0, false, nil
+	}
+
+	var version int64
+	var dirty bool
+	err := db.QueryRow(ctx, `SELECT version, dirty FROM schema_migrations LIMIT 1`).Scan(&version, &dirty)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, err
+	}
+	return uint64(version), dirty, nil
+}
+
+func applyMigration(ctx context.Context, conn *pgx.Conn, sql string, version uint64) error {
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	// tanpa argumen pgx memakai simple protocol, sehingga satu file boleh berisi banyak statement
+	if _, err := tx.Exec(ctx, sql); err != nil {
+		return err
+	}
+	if err := setVersion(ctx, tx, version); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+func setVersion(ctx context.Context, q sqlExecer, version uint64) error {
+	if _, err := q.Exec(ctx, `DELETE FROM schema_migrations`); err != nil {
+		return err
+	}
+	// versi 0 berarti semua migration sudah di-rollback, sama seperti golang-migrate tabel dibiarkan kosong
+	if version == 0 {
+		return nil
+	}
+	_, err := q.Exec(ctx, `INSERT INTO schema_migrations (version, dirty) VALUES ($1, false)`, int64(version))
+	return err
+}
+
+func lockMigrations(ctx context.Context, db *pgxpool.Pool) (*pgxpool.Conn, error) {
+	conn, err := db.Acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := conn.Exec(ctx, `SELECT pg_advisory_lock($1)`, migrationLockID); err != nil {
+		conn.Release()
+		return nil, err
+	}
+	return conn, nil
+}
+
+func unlockMigrations(ctx context.Context, conn *pgxpool.Conn) {
+	if _, err := conn.Exec(ctx, `SELECT pg_advisory_unlock($1)`, migrationLockID); err != nil {
+		log.Println("Failed to release migration lock.\nCause: ", err.Error())
+	}
+	conn.Release()
+}
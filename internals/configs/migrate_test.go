@@ -0,0 +1,192 @@
+package configs
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// newTestDB membuka koneksi ke TEST_DATABASE_URL dengan schema kosong sendiri (search_path),
+// sehingga tidak bentrok dengan test package lain yang memakai database yang sama
+func newTestDB(t *testing.T) *pgxpool.Pool {
+	t.Helper()
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+
+	const schema = "configs_test"
+	config, err := pgxpool.ParseConfig(url)
+	if err != nil {
+		t.Fatalf("invalid TEST_DATABASE_URL: %v", err)
+	}
+	config.ConnConfig.RuntimeParams["search_path"] = schema
+
+	ctx := context.Background()
+	db, err := pgxpool.NewWithConfig(ctx, config)
+	if err != nil {
+		t.Fatalf("failed to connect to test database: %v", err)
+	}
+	t.Cleanup(db.Close)
+
+	if _, err := db.Exec(ctx, `DROP SCHEMA IF EXISTS `+schema+` CASCADE; CREATE SCHEMA `+schema); err != nil {
+		t.Fatalf("failed to reset test schema: %v", err)
+	}
+	return db
+}
+
+func TestLoadMigrations(t *testing.T) {
+	migrations, err := LoadMigrations()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(migrations) == 0 {
+		t.Fatal("no embedded migrations")
+	}
+	for i, m := range migrations {
+		if m.Down == "" {
+			t.Errorf("migration %d_%s has no down file", m.Version, m.Name)
+		}
+		if i > 0 && m.Version <= migrations[i-1].Version {
+			t.Errorf("migration %d is not sorted", m.Version)
+		}
+	}
+
+	latest, err := SchemaVersion()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if latest != migrations[len(migrations)-1].Version {
+		t.Errorf("SchemaVersion() = %d, want %d", latest, migrations[len(migrations)-1].Version)
+	}
+}
+
+func TestCheckSchemaOnEmptyDatabase(t *testing.T) {
+	db := newTestDB(t)
+	ctx := context.Background()
+
+	err := CheckSchema(db)
+	if err == nil || !strings.Contains(err.Error(), "not migrated") {
+		t.Fatalf("CheckSchema() = %v, want not migrated error", err)
+	}
+
+	// CheckSchema hanya membaca, tidak boleh membuat tabel schema_migrations
+	var exists bool
+	if err := db.QueryRow(ctx, `SELECT to_regclass('schema_migrations') IS NOT NULL`).Scan(&exists); err != nil {
+		t.Fatal(err)
+	}
+	if exists {
+		t.Error("CheckSchema created schema_migrations")
+	}
+
+	// tabel tanpa versi (semua migration sudah di-rollback) juga dianggap belum dimigrasi
+	if _, err := db.Exec(ctx, `CREATE TABLE schema_migrations (version bigint NOT NULL PRIMARY KEY, dirty boolean NOT NULL)`); err != nil {
+		t.Fatal(err)
+	}
+	if err := CheckSchema(db); err == nil || !strings.Contains(err.Error(), "not migrated") {
+		t.Errorf("CheckSchema() with empty schema_migrations = %v, want not migrated error", err)
+	}
+}
+
+func TestCheckSchema(t *testing.T) {
+	db := newTestDB(t)
+	ctx := context.Background()
+
+	latest, err := SchemaVersion()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec(ctx, `CREATE TABLE schema_migrations (version bigint NOT NULL PRIMARY KEY, dirty boolean NOT NULL)`); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		version uint64
+		dirty   bool
+		wantErr string
+	}{
+		{latest - 1, false, "older than required"},
+		{latest, true, "dirty"},
+		{latest, false, ""},
+		// migration yang lebih baru dari kode hanya dicatat di log
+		{latest + 1, false, ""},
+	}
+	for _, tt := range tests {
+		if _, err := db.Exec(ctx, `DELETE FROM schema_migrations`); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := db.Exec(ctx, `INSERT INTO schema_migrations (version, dirty) VALUES ($1, $2)`, int64(tt.version), tt.dirty); err != nil {
+			t.Fatal(err)
+		}
+		err := CheckSchema(db)
+		if tt.wantErr == "" && err != nil {
+			t.Errorf("CheckSchema() version %d dirty %v = %v, want nil", tt.version, tt.dirty, err)
+		}
+		if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+			t.Errorf("CheckSchema() version %d dirty %v = %v, want %q", tt.version, tt.dirty, err, tt.wantErr)
+		}
+	}
+}
+
+func TestMigrateUpAndDown(t *testing.T) {
+	db := newTestDB(t)
+	ctx := context.Background()
+
+	migrations, err := LoadMigrations()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	applied, err := MigrateUp(ctx, db)
+	if err != nil {
+		t.Fatalf("MigrateUp() error: %v", err)
+	}
+	if len(applied) != len(migrations) {
+		t.Errorf("MigrateUp() applied %d migrations, want %d", len(applied), len(migrations))
+	}
+	if err := CheckSchema(db); err != nil {
+		t.Fatalf("CheckSchema() after MigrateUp: %v", err)
+	}
+
+	// migration yang sudah dijalankan tidak dijalankan lagi
+	applied, err = MigrateUp(ctx, db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(applied) != 0 {
+		t.Errorf("second MigrateUp() applied %d migrations, want 0", len(applied))
+	}
+
+	rolledBack, err := MigrateDown(ctx, db, 1)
+	if err != nil {
+		t.Fatalf("MigrateDown() error: %v", err)
+	}
+	if len(rolledBack) != 1 {
+		t.Fatalf("MigrateDown(1) rolled back %d migrations", len(rolledBack))
+	}
+	err = CheckSchema(db)
+	if err == nil || !strings.Contains(err.Error(), "older than required") {
+		t.Fatalf("CheckSchema() after MigrateDown = %v, want older version error", err)
+	}
+
+	// semua down migration harus bisa dijalankan sampai database kosong, lalu naik lagi
+	if _, err := MigrateDown(ctx, db, len(migrations)); err != nil {
+		t.Fatalf("MigrateDown() all: %v", err)
+	}
+	status, err := GetMigrationStatus(ctx, db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.Version != 0 || len(status.Pending) != len(migrations) {
+		t.Errorf("status after full rollback = version %d, %d pending", status.Version, len(status.Pending))
+	}
+	if _, err := MigrateUp(ctx, db); err != nil {
+		t.Fatalf("MigrateUp() after full rollback: %v", err)
+	}
+	if err := CheckSchema(db); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,19 @@
+package configs
+
+import (
+	"os"
+	"strings"
+)
+
+// TrustedProxies adalah daftar IP / CIDR reverse proxy yang header X-Forwarded-For-nya dipercaya
+// untuk menentukan IP client (env TRUSTED_PROXIES, dipisah koma). Kosong berarti tidak ada proxy yang dipercaya
+// dan IP client selalu diambil dari alamat koneksi
+func TrustedProxies() []string {
+	var proxies []string
+	for _, proxy := range strings.Split(os.Getenv("TRUSTED_PROXIES"), ",") {
+		if proxy = strings.TrimSpace(proxy); proxy != "" {
+			proxies = append(proxies, proxy)
+		}
+	}
+	return proxies
+}
@@ -0,0 +1,31 @@
+package configs
+
+import (
+	"os"
+	"strconv"
+)
+
+const (
+	defaultTimelineMaxLength  = 800
+	defaultCelebrityThreshold = 10000
+)
+
+// TimelineMaxLength adalah jumlah post maksimal yang disimpan di timeline redis tiap user (env TIMELINE_MAX_LENGTH).
+// Post yang lebih lama dari itu tidak muncul lagi di feed
+func TimelineMaxLength() int {
+	n, err := strconv.Atoi(os.Getenv("TIMELINE_MAX_LENGTH"))
+	if err != nil || n <= 0 {
+		return defaultTimelineMaxLength
+	}
+	return n
+}
+
+// CelebrityThreshold adalah jumlah follower minimal agar post user tidak di-fan-out ke timeline follower-nya,
+// melainkan diambil langsung dari database saat feed dibaca (env TIMELINE_CELEBRITY_THRESHOLD)
+func CelebrityThreshold() int {
+	n, err := strconv.Atoi(os.Getenv("TIMELINE_CELEBRITY_THRESHOLD"))
+	if err != nil || n <= 0 {
+		return defaultCelebrityThreshold
+	}
+	return n
+}
@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/raihaninkam/finalPhase3/internals/configs"
+	"github.com/raihaninkam/finalPhase3/internals/models"
+	"github.com/raihaninkam/finalPhase3/internals/utils"
+	"github.com/raihaninkam/finalPhase3/pkg"
+)
+
+// DeleteAccount godoc
+// @Summary     Hapus Akun
+// @Description Menjadwalkan penghapusan akun setelah masa tenggang (env ACCOUNT_DELETION_GRACE, default 30 hari).
+// @Description Semua device langsung logout. Login kembali sebelum jadwal akan membatalkan penghapusan.
+// @Description Jika 2FA aktif, kode TOTP / recovery code wajib diisi.
+// @Tags        Auth
+// @Accept      json
+// @Produce     json
+// @Security    BearerAuth
+// @Param       body body models.DeleteAccountRequest true "Delete Account Request"
+// @Success     202 {object} map[string]interface{} "Penghapusan akun dijadwalkan"
+// @Failure     400 {object} map[string]interface{} "Bad Request - Password atau kode salah"
+// @Failure     401 {object} map[string]interface{} "Unauthorized - Token tidak valid"
+// @Failure     500 {object} map[string]interface{} "Internal Server Error"
+// @Router      /auth/account [delete]
+func (a *AuthHandler) DeleteAccount(ctx *gin.Context) {
+	userID, err := utils.GetUserFromCtx(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "Unauthorized",
+		})
+		return
+	}
+
+	var body models.DeleteAccountRequest
+	if err := ctx.ShouldBind(&body); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Password harus diisi",
+		})
+		return
+	}
+
+	user, err := a.ar.GetUserByID(ctx.Request.Context(), userID)
+	if err != nil {
+		log.Println("Internal Server Error.\nCause: ", err.Error())
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "internal server error",
+		})
+		return
+	}
+
+	hc := pkg.NewHashConfig()
+	isValid, err := hc.CompareHashAndPassword(body.Password, user.Password)
+	if err == nil && isValid && user.MfaEnabled {
+		isValid, err = a.checkMfaCode(ctx, user.ID, body.Code)
+	}
+	if err != nil {
+		log.Println("Internal Server Error.\nCause: ", err.Error())
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "internal server error",
+		})
+		return
+	}
+	if !isValid {
+		message := "Password salah"
+		if user.MfaEnabled {
+			message = "Password atau kode salah"
+		}
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   message,
+		})
+		return
+	}
+
+	deleteAt := time.Now().Add(configs.AccountDeletionGrace())
+	if err := a.ar.ScheduleDeletion(ctx.Request.Context(), user.ID, deleteAt); err != nil {
+		log.Println("Internal Server Error.\nCause: ", err.Error())
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "internal server error",
+		})
+		return
+	}
+
+	// logout dari semua device, user harus login lagi untuk membatalkan penghapusan
+	if err := a.revokeAllUserTokens(ctx, user.ID); err != nil {
+		log.Println("Failed to revoke tokens of deleted account.\nCause: ", err.Error())
+	}
+
+	go func() {
+		body := fmt.Sprintf("Akun anda akan dihapus permanen pada %s beserta semua post, komentar dan file yang anda upload.\n\n"+
+			"Login kembali sebelum waktu tersebut untuk membatalkan penghapusan.", deleteAt.Format(time.RFC1123))
+		if err := a.mailer.Send(user.Email, "Penghapusan Akun Dijadwalkan", body); err != nil {
+			log.Println("Failed to send account deletion email.\nCause: ", err.Error())
+		}
+	}()
+
+	ctx.JSON(http.StatusAccepted, gin.H{
+		"success":               true,
+		"message":               "Akun dijadwalkan untuk dihapus, login kembali untuk membatalkan",
+		"deletion_scheduled_at": deleteAt,
+	})
+}
+
+// cancelAccountDeletion membatalkan jadwal penghapusan akun saat user berhasil login kembali
+func (a *AuthHandler) cancelAccountDeletion(ctx *gin.Context, user *models.User) error {
+	if user.DeletionScheduledAt == nil {
+		return nil
+	}
+	if err := a.ar.CancelDeletion(ctx.Request.Context(), user.ID); err != nil {
+		return err
+	}
+	user.DeletionScheduledAt = nil
+
+	go func() {
+		if err := a.mailer.Send(user.Email, "Penghapusan Akun Dibatalkan", "Anda login kembali, penghapusan akun anda dibatalkan."); err != nil {
+			log.Println("Failed to send account deletion email.\nCause: ", err.Error())
+		}
+	}()
+	return nil
+}
@@ -0,0 +1,194 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/raihaninkam/finalPhase3/internals/models"
+	"github.com/raihaninkam/finalPhase3/internals/repositories"
+	"github.com/raihaninkam/finalPhase3/internals/utils"
+)
+
+type AdminHandler struct {
+	adr *repositories.AdminRepository
+	la  *repositories.LoginAttemptRepository
+}
+
+func NewAdminHandler(adr *repositories.AdminRepository, la *repositories.LoginAttemptRepository) *AdminHandler {
+	return &AdminHandler{adr: adr, la: la}
+}
+
+// ListUsers godoc
+// @Summary     List Users (Admin)
+// @Description Menampilkan daftar user beserta role-nya. Hanya untuk admin dan moderator.
+// @Tags        Admin
+// @Produce     json
+// @Security    BearerAuth
+// @Param       page query int false "Page number" default(1)
+// @Param       limit query int false "Items per page" default(20)
+// @Success     200 {object} map[string]interface{}
+// @Failure     403 {object} map[string]interface{} "Forbidden - Bukan admin / moderator"
+// @Failure     500 {object} map[string]interface{}
+// @Router      /admin/users [get]
+func (ah *AdminHandler) ListUsers(ctx *gin.Context) {
+	page, err := strconv.Atoi(ctx.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+	limit, err := strconv.Atoi(ctx.DefaultQuery("limit", "20"))
+	if err != nil || limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	users, err := ah.adr.ListUsers(ctx.Request.Context(), limit, (page-1)*limit)
+	if err != nil {
+		log.Println("Error getting users:", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Internal Server Error",
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    users,
+		"pagination": gin.H{
+			"page":  page,
+			"limit": limit,
+		},
+	})
+}
+
+// UpdateRole godoc
+// @Summary     Ubah Role User (Admin)
+// @Description Mengubah role user (user, moderator, admin). Semua sesi user tersebut dicabut agar role baru langsung berlaku.
+// @Tags        Admin
+// @Accept      json
+// @Produce     json
+// @Security    BearerAuth
+// @Param       id path string true "User ID"
+// @Param       body body models.UpdateRoleRequest true "Role baru"
+// @Success     200 {object} map[string]interface{}
+// @Failure     400 {object} map[string]interface{} "Bad Request - Role tidak valid"
+// @Failure     403 {object} map[string]interface{} "Forbidden - Bukan admin"
+// @Failure     404 {object} map[string]interface{} "User tidak ditemukan"
+// @Failure     500 {object} map[string]interface{}
+// @Router      /admin/users/{id}/role [patch]
+func (ah *AdminHandler) UpdateRole(ctx *gin.Context) {
+	adminID, err := utils.GetUserFromCtx(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "Unauthorized",
+		})
+		return
+	}
+
+	userID := ctx.Param("id")
+	if !utils.IsUUID(userID) {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   "User tidak ditemukan",
+		})
+		return
+	}
+
+	// admin tidak boleh mengubah role sendiri agar tidak terkunci tanpa admin
+	if userID == adminID {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Tidak bisa mengubah role sendiri",
+		})
+		return
+	}
+
+	var body models.UpdateRoleRequest
+	if err := ctx.ShouldBind(&body); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Role harus salah satu dari: user, moderator, admin",
+		})
+		return
+	}
+
+	// role tersimpan di access token, sesi user ikut dicabut agar user mendapat token dengan role baru
+	user, err := ah.adr.UpdateRole(ctx.Request.Context(), userID, body.Role)
+	if err != nil {
+		if strings.Contains(err.Error(), "user not found") {
+			ctx.JSON(http.StatusNotFound, gin.H{
+				"success": false,
+				"error":   "User tidak ditemukan",
+			})
+			return
+		}
+		log.Println("Internal Server Error.\nCause: ", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Internal Server Error",
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    user,
+	})
+}
+
+// UnlockUser godoc
+// @Summary     Buka Kunci Login User (Admin)
+// @Description Menghapus kunci login user yang terkunci karena terlalu banyak percobaan login gagal.
+// @Tags        Admin
+// @Produce     json
+// @Security    BearerAuth
+// @Param       id path string true "User ID"
+// @Success     200 {object} map[string]interface{}
+// @Failure     403 {object} map[string]interface{} "Forbidden - Bukan admin / moderator"
+// @Failure     404 {object} map[string]interface{} "User tidak ditemukan"
+// @Failure     500 {object} map[string]interface{}
+// @Router      /admin/users/{id}/unlock [post]
+func (ah *AdminHandler) UnlockUser(ctx *gin.Context) {
+	userID := ctx.Param("id")
+	if !utils.IsUUID(userID) {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   "User tidak ditemukan",
+		})
+		return
+	}
+
+	user, err := ah.adr.GetUser(ctx.Request.Context(), userID)
+	if err != nil {
+		if strings.Contains(err.Error(), "user not found") {
+			ctx.JSON(http.StatusNotFound, gin.H{
+				"success": false,
+				"error":   "User tidak ditemukan",
+			})
+			return
+		}
+		log.Println("Error getting user:", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Internal Server Error",
+		})
+		return
+	}
+
+	if err := ah.la.ResetAccount(ctx.Request.Context(), user.Email); err != nil {
+		log.Println("Error unlocking user:", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Internal Server Error",
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "User berhasil dibuka kuncinya",
+	})
+}
@@ -1,11 +1,19 @@
 package handlers
 
 import (
+	"crypto/rand"
+	"errors"
+	"fmt"
 	"log"
+	"math"
+	"math/big"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/raihaninkam/finalPhase3/internals/configs"
 	"github.com/raihaninkam/finalPhase3/internals/models"
 	"github.com/raihaninkam/finalPhase3/internals/repositories"
 	"github.com/raihaninkam/finalPhase3/internals/utils"
@@ -13,23 +21,33 @@ import (
 )
 
 type AuthHandler struct {
-	ar *repositories.AuthRepositories
+	ar     *repositories.AuthRepositories
+	tr     *repositories.TokenRepository
+	sr     *repositories.SessionRepository
+	la     *repositories.LoginAttemptRepository
+	mr     *repositories.MfaRepository
+	mailer pkg.Mailer
 }
 
-func NewAuthHandler(ar *repositories.AuthRepositories) *AuthHandler {
-	return &AuthHandler{ar: ar}
+// usernameChangeCooldown adalah jarak minimal antar penggantian username
+const usernameChangeCooldown = 30 * 24 * time.Hour
+
+func NewAuthHandler(ar *repositories.AuthRepositories, tr *repositories.TokenRepository, sr *repositories.SessionRepository, la *repositories.LoginAttemptRepository, mr *repositories.MfaRepository, mailer pkg.Mailer) *AuthHandler {
+	return &AuthHandler{ar: ar, tr: tr, sr: sr, la: la, mr: mr, mailer: mailer}
 }
 
 // Register godoc
 // @Summary     Register User
 // @Description Daftar User baru dengan email dan password. Password akan di-hash sebelum disimpan.
+// @Description Username opsional (3-30 karakter: huruf kecil, angka, underscore). Jika kosong, username dibuat dari email.
+// @Description Name opsional, default sama dengan username.
 // @Tags        Auth
 // @Accept      json
 // @Produce     json
-// @Param       body body models.UserAuth true "Register Request"
+// @Param       body body models.AuthRequest true "Register Request"
 // @Success     201 {object} map[string]interface{} "User berhasil didaftarkan"
 // @Failure     400 {object} map[string]interface{} "Bad Request - Input tidak valid (email, password)"
-// @Failure     409 {object} map[string]interface{} "Conflict - Email sudah terdaftar"
+// @Failure     409 {object} map[string]interface{} "Conflict - Email / username sudah terdaftar"
 // @Failure     500 {object} map[string]interface{} "Internal Server Error"
 // @Router      /auth/register [post]
 func (a *AuthHandler) Register(ctx *gin.Context) {
@@ -87,6 +105,28 @@ func (a *AuthHandler) Register(ctx *gin.Context) {
 		return
 	}
 
+	// validasi username jika diisi, jika tidak dibuat otomatis dari email
+	username := utils.NormalizeUsername(body.Username)
+	if username != "" {
+		if err := utils.UsernameValidation(username); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error":   err.Error(),
+			})
+			return
+		}
+	} else {
+		username, err = a.generateUsername(ctx, body.Email)
+		if err != nil {
+			log.Println("Internal Server Error.\nCause: ", err.Error())
+			ctx.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"error":   "internal server error",
+			})
+			return
+		}
+	}
+
 	// hash password sebelum disimpan
 	hc := pkg.NewHashConfig()
 	hc.UseRecommended() // menggunakan konfigurasi yang direkomendasikan
@@ -100,9 +140,17 @@ func (a *AuthHandler) Register(ctx *gin.Context) {
 		return
 	}
 
+	// name wajib di database, gunakan username jika tidak diisi
+	name := strings.TrimSpace(body.Name)
+	if name == "" {
+		name = username
+	}
+
 	user := models.User{
+		Username: username,
 		Email:    body.Email,
 		Password: hashedPassword,
+		Name:     &name,
 	}
 
 	// simpan user ke database
@@ -115,6 +163,13 @@ func (a *AuthHandler) Register(ctx *gin.Context) {
 			})
 			return
 		}
+		if strings.Contains(err.Error(), "username already exists") {
+			ctx.JSON(http.StatusConflict, gin.H{
+				"success": false,
+				"error":   "Username sudah dipakai",
+			})
+			return
+		}
 		log.Println("Internal Server Error.\nCause: ", err.Error())
 		ctx.JSON(http.StatusInternalServerError, gin.H{
 			"success": false,
@@ -123,10 +178,14 @@ func (a *AuthHandler) Register(ctx *gin.Context) {
 		return
 	}
 
-	// response sukses tanpa data user
+	// kirim link verifikasi email
+	a.sendVerificationEmail(user.ID, user.Email)
+
+	// response sukses tanpa data user selain username (bisa jadi dibuat otomatis)
 	ctx.JSON(http.StatusCreated, gin.H{
-		"success": true,
-		"message": "User berhasil didaftarkan",
+		"success":  true,
+		"message":  "User berhasil didaftarkan, silahkan cek email untuk verifikasi",
+		"username": user.Username,
 	})
 }
 
@@ -136,14 +195,17 @@ func (a *AuthHandler) Register(ctx *gin.Context) {
 // @Tags        Auth
 // @Accept      json
 // @Produce     json
-// @Param       body body models.UserAuth true "Login Request"
-// @Success     200 {object} map[string]interface{} "Berhasil login, kembalikan token"
+// @Param       body body models.LoginRequest true "Login Request"
+// @Success     200 {object} map[string]interface{} "Berhasil login, kembalikan token dan refresh token (atau mfa_token jika 2FA aktif)"
 // @Failure     400 {object} map[string]interface{} "Bad Request - Email/Password salah atau input tidak valid"
+// @Failure     403 {object} map[string]interface{} "Forbidden - Email belum diverifikasi"
+// @Failure     423 {object} map[string]interface{} "Locked - Akun dikunci sementara (lihat header Retry-After)"
+// @Failure     429 {object} map[string]interface{} "Too Many Requests - IP dikunci sementara (lihat header Retry-After)"
 // @Failure     500 {object} map[string]interface{} "Internal Server Error"
 // @Router      /auth/login [post]
 func (a *AuthHandler) Login(ctx *gin.Context) {
 	// menerima body
-	var body models.User
+	var body models.LoginRequest
 
 	if err := ctx.ShouldBind(&body); err != nil {
 		if strings.Contains(err.Error(), "required") {
@@ -178,14 +240,17 @@ func (a *AuthHandler) Login(ctx *gin.Context) {
 		return
 	}
 
+	// cek apakah IP atau akun sedang dikunci sebelum melakukan komparasi argon2 yang mahal
+	if a.rejectLockedLogin(ctx, body.Email) {
+		return
+	}
+
 	// ambil data user dari database
 	user, err := a.ar.GetEmail(ctx.Request.Context(), body.Email)
 	if err != nil {
 		if strings.Contains(err.Error(), "user not found") {
-			ctx.JSON(http.StatusBadRequest, gin.H{
-				"success": false,
-				"error":   "Email atau password salah",
-			})
+			pkg.CompareDummyHash(body.Password)
+			a.loginFailed(ctx, body.Email, nil)
 			return
 		}
 		log.Println("Internal Server Error.\nCause: ", err.Error())
@@ -217,16 +282,57 @@ func (a *AuthHandler) Login(ctx *gin.Context) {
 
 	// jika password tidak cocok
 	if !isMatched {
-		ctx.JSON(http.StatusBadRequest, gin.H{
+		a.loginFailed(ctx, body.Email, user)
+		return
+	}
+
+	if err := a.la.ResetAccount(ctx.Request.Context(), body.Email); err != nil {
+		log.Println("Failed to reset login attempts.\nCause: ", err.Error())
+	}
+
+	// upgrade hash lama (bcrypt / parameter argon2 lebih lemah) ke konfigurasi yang direkomendasikan
+	hc = pkg.NewHashConfig()
+	hc.UseRecommended()
+	if hc.NeedsRehash(user.Password) {
+		if newHash, err := hc.GenHash(body.Password); err != nil {
+			log.Println("Failed to rehash password.\nCause: ", err.Error())
+		} else if err := a.ar.UpdatePassword(ctx.Request.Context(), user.ID, newHash); err != nil {
+			log.Println("Failed to upgrade password hash.\nCause: ", err.Error())
+		}
+	}
+
+	// tolak akun yang belum verifikasi email jika diwajibkan saat login
+	if user.EmailVerifiedAt == nil && configs.EmailVerificationMode() == configs.EmailVerificationLogin {
+		ctx.JSON(http.StatusForbidden, gin.H{
 			"success": false,
-			"error":   "Email atau password salah",
+			"error":   "Silahkan verifikasi email terlebih dahulu",
 		})
 		return
 	}
 
-	// jika match, buat JWT token
-	claims := pkg.NewJWTClaims(user.ID, "user")
-	jwtToken, err := claims.GenToken()
+	// akun dengan 2FA harus menukar mfa_token dengan kode TOTP sebelum mendapat access token
+	if user.MfaEnabled {
+		mfaClaims := pkg.NewActionClaims(user.ID, user.Email, mfaPendingPurpose, mfaPendingTTL)
+		mfaToken, err := mfaClaims.GenToken()
+		if err != nil {
+			log.Println("Internal Server Error.\nCause: ", err.Error())
+			ctx.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"error":   "internal server error",
+			})
+			return
+		}
+		ctx.JSON(http.StatusOK, gin.H{
+			"success":      true,
+			"message":      "Masukkan kode dari aplikasi authenticator",
+			"mfa_required": true,
+			"mfa_token":    mfaToken,
+		})
+		return
+	}
+
+	// jika match, catat sesi (device) baru beserta token-nya
+	jwtToken, refreshToken, err := a.startSession(ctx, user, body.DeviceName)
 	if err != nil {
 		log.Println("Internal Server Error.\nCause: ", err.Error())
 		ctx.JSON(http.StatusInternalServerError, gin.H{
@@ -238,27 +344,32 @@ func (a *AuthHandler) Login(ctx *gin.Context) {
 
 	// response sukses dengan token
 	ctx.JSON(http.StatusOK, gin.H{
-		"success": true,
-		"message": "Login berhasil",
-		"token":   jwtToken,
+		"success":       true,
+		"message":       "Login berhasil",
+		"token":         jwtToken,
+		"refresh_token": refreshToken,
 	})
 }
 
 // UpdateProfile godoc
 // @Summary     Update User Profile
-// @Description Update profil user (name, bio, avatar). Avatar akan diupload jika disertakan.
+// @Description Update profil user (username, name, bio, avatar). Avatar akan diupload jika disertakan.
+// @Description Username hanya bisa diganti sekali setiap 30 hari.
 // @Tags        Auth
 // @Accept      multipart/form-data
 // @Produce     json
 // @Security    BearerAuth
+// @Param       username formData string false "Username baru"
 // @Param       name formData string false "Nama user"
 // @Param       bio formData string false "Bio user"
 // @Param       avatar formData file false "Avatar image (JPG, PNG, max 2MB)"
 // @Success     200 {object} map[string]interface{} "Profile berhasil diupdate"
 // @Failure     400 {object} map[string]interface{} "Bad Request - Input tidak valid"
 // @Failure     401 {object} map[string]interface{} "Unauthorized - Token tidak valid"
+// @Failure     409 {object} map[string]interface{} "Conflict - Username sudah dipakai"
+// @Failure     429 {object} map[string]interface{} "Too Many Requests - Username baru saja diganti"
 // @Failure     500 {object} map[string]interface{} "Internal Server Error"
-// @Router      /auth/profile [put]
+// @Router      /auth/profile [patch]
 func (a *AuthHandler) UpdateProfile(ctx *gin.Context) {
 	// Ambil userID dari context (dari middleware JWT)
 	userID, err := utils.GetUserFromCtx(ctx)
@@ -271,9 +382,20 @@ func (a *AuthHandler) UpdateProfile(ctx *gin.Context) {
 	}
 
 	// Ambil form field
+	username := utils.NormalizeUsername(ctx.PostForm("username"))
 	name := ctx.PostForm("name")
 	bio := ctx.PostForm("bio")
 
+	if username != "" {
+		if err := utils.UsernameValidation(username); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error":   err.Error(),
+			})
+			return
+		}
+	}
+
 	// Ambil file avatar jika ada
 	file, err := ctx.FormFile("avatar")
 	var avatarUrl string
@@ -291,14 +413,21 @@ func (a *AuthHandler) UpdateProfile(ctx *gin.Context) {
 	}
 
 	// Validasi: minimal harus ada salah satu field yang diisi
-	if name == "" && bio == "" && avatarUrl == "" {
+	if username == "" && name == "" && bio == "" && avatarUrl == "" {
 		ctx.JSON(http.StatusBadRequest, gin.H{
 			"success": false,
-			"error":   "Minimal satu field harus diisi (name, bio, atau avatar)",
+			"error":   "Minimal satu field harus diisi (username, name, bio, atau avatar)",
 		})
 		return
 	}
 
+	// ganti username lebih dulu agar field lain tidak ikut tersimpan jika username ditolak
+	if username != "" {
+		if ok := a.changeUsername(ctx, userID, username); !ok {
+			return
+		}
+	}
+
 	// Buat object untuk update
 	updateData := &models.UserUpdate{
 		ID:        userID,
@@ -330,6 +459,7 @@ func (a *AuthHandler) UpdateProfile(ctx *gin.Context) {
 		"success": true,
 		"message": "Profile berhasil diupdate",
 		"data": gin.H{
+			"username":   updatedUser.Username,
 			"email":      updatedUser.Email,
 			"name":       updatedUser.Name,
 			"bio":        updatedUser.Bio,
@@ -337,3 +467,459 @@ func (a *AuthHandler) UpdateProfile(ctx *gin.Context) {
 		},
 	})
 }
+
+// Logout godoc
+// @Summary     Logout User
+// @Description Logout dari device saat ini. Sesi beserta refresh token-nya dicabut dan token yang dipakai
+// @Description akan di-blacklist sampai masa berlakunya habis.
+// @Tags        Auth
+// @Produce     json
+// @Security    BearerAuth
+// @Success     200 {object} map[string]interface{} "Berhasil logout"
+// @Failure     401 {object} map[string]interface{} "Unauthorized - Token tidak valid"
+// @Failure     500 {object} map[string]interface{} "Internal Server Error"
+// @Router      /auth/logout [post]
+func (a *AuthHandler) Logout(ctx *gin.Context) {
+	claims, err := utils.GetClaimsFromCtx(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "Unauthorized",
+		})
+		return
+	}
+	token, err := utils.GetTokenFromCtx(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "Unauthorized",
+		})
+		return
+	}
+
+	// cabut sesi saat ini beserta refresh token-nya
+	if claims.ID != "" {
+		if err := a.sr.RevokeSession(ctx.Request.Context(), claims.UserId, claims.ID); err != nil && !strings.Contains(err.Error(), "session not found") {
+			log.Println("Internal Server Error.\nCause: ", err.Error())
+			ctx.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"error":   "internal server error",
+			})
+			return
+		}
+	}
+
+	// blacklist token sesuai sisa masa berlakunya
+	if err := a.ar.BlacklistToken(ctx.Request.Context(), token, claims.RemainingTTL()); err != nil {
+		log.Println("Internal Server Error.\nCause: ", err.Error())
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "internal server error",
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Logout berhasil",
+	})
+}
+
+// LogoutAll godoc
+// @Summary     Logout Semua Device
+// @Description Logout dari semua device. Semua token milik user yang dibuat sebelum request ini akan ditolak.
+// @Tags        Auth
+// @Produce     json
+// @Security    BearerAuth
+// @Success     200 {object} map[string]interface{} "Berhasil logout dari semua device"
+// @Failure     401 {object} map[string]interface{} "Unauthorized - Token tidak valid"
+// @Failure     500 {object} map[string]interface{} "Internal Server Error"
+// @Router      /auth/logout-all [post]
+func (a *AuthHandler) LogoutAll(ctx *gin.Context) {
+	userID, err := utils.GetUserFromCtx(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "Unauthorized",
+		})
+		return
+	}
+
+	if err := a.revokeAllUserTokens(ctx, userID); err != nil {
+		log.Println("Internal Server Error.\nCause: ", err.Error())
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "internal server error",
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Logout dari semua device berhasil",
+	})
+}
+
+// Refresh godoc
+// @Summary     Refresh Access Token
+// @Description Tukar refresh token dengan access token dan refresh token baru (rotasi).
+// @Description Refresh token yang sudah pernah dipakai akan mencabut seluruh sesi dari login yang sama.
+// @Tags        Auth
+// @Accept      json
+// @Produce     json
+// @Param       body body models.RefreshRequest true "Refresh Request"
+// @Success     200 {object} map[string]interface{} "Token baru"
+// @Failure     400 {object} map[string]interface{} "Bad Request - refresh_token harus diisi"
+// @Failure     401 {object} map[string]interface{} "Unauthorized - Refresh token tidak valid"
+// @Failure     500 {object} map[string]interface{} "Internal Server Error"
+// @Router      /auth/refresh [post]
+func (a *AuthHandler) Refresh(ctx *gin.Context) {
+	var body models.RefreshRequest
+	if err := ctx.ShouldBindJSON(&body); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Refresh token harus diisi",
+		})
+		return
+	}
+
+	refreshToken, userID, sessionID, err := a.tr.RotateRefreshToken(ctx.Request.Context(), body.RefreshToken)
+	if err != nil {
+		if strings.Contains(err.Error(), "refresh token") {
+			ctx.JSON(http.StatusUnauthorized, gin.H{
+				"success": false,
+				"error":   "Refresh token tidak valid, silahkan login kembali",
+			})
+			return
+		}
+		log.Println("Internal Server Error.\nCause: ", err.Error())
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "internal server error",
+		})
+		return
+	}
+
+	// ambil role terbaru dari database agar perubahan role langsung berlaku saat refresh
+	user, err := a.ar.GetUserByID(ctx.Request.Context(), userID)
+	if err != nil {
+		if strings.Contains(err.Error(), "user not found") {
+			ctx.JSON(http.StatusUnauthorized, gin.H{
+				"success": false,
+				"error":   "Refresh token tidak valid, silahkan login kembali",
+			})
+			return
+		}
+		log.Println("Internal Server Error.\nCause: ", err.Error())
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "internal server error",
+		})
+		return
+	}
+
+	claims := pkg.NewJWTClaims(user.ID, user.Role, sessionID)
+	jwtToken, err := claims.GenToken()
+	if err != nil {
+		log.Println("Internal Server Error.\nCause: ", err.Error())
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "internal server error",
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success":       true,
+		"message":       "Token berhasil diperbarui",
+		"token":         jwtToken,
+		"refresh_token": refreshToken,
+	})
+}
+
+// GetSessions godoc
+// @Summary     List Sesi Login
+// @Description Menampilkan semua device yang sedang login dengan akun ini.
+// @Tags        Auth
+// @Produce     json
+// @Security    BearerAuth
+// @Success     200 {object} map[string]interface{} "Daftar sesi aktif"
+// @Failure     401 {object} map[string]interface{} "Unauthorized - Token tidak valid"
+// @Failure     500 {object} map[string]interface{} "Internal Server Error"
+// @Router      /auth/sessions [get]
+func (a *AuthHandler) GetSessions(ctx *gin.Context) {
+	claims, err := utils.GetClaimsFromCtx(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "Unauthorized",
+		})
+		return
+	}
+
+	sessions, err := a.sr.GetUserSessions(ctx.Request.Context(), claims.UserId)
+	if err != nil {
+		log.Println("Internal Server Error.\nCause: ", err.Error())
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "internal server error",
+		})
+		return
+	}
+
+	for i := range sessions {
+		sessions[i].IsCurrent = sessions[i].Id == claims.ID
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    sessions,
+	})
+}
+
+// RevokeSession godoc
+// @Summary     Cabut Sesi Login
+// @Description Logout dari satu device berdasarkan id sesi.
+// @Tags        Auth
+// @Produce     json
+// @Security    BearerAuth
+// @Param       id path string true "Session ID"
+// @Success     200 {object} map[string]interface{} "Sesi berhasil dicabut"
+// @Failure     401 {object} map[string]interface{} "Unauthorized - Token tidak valid"
+// @Failure     404 {object} map[string]interface{} "Sesi tidak ditemukan"
+// @Failure     500 {object} map[string]interface{} "Internal Server Error"
+// @Router      /auth/sessions/{id} [delete]
+func (a *AuthHandler) RevokeSession(ctx *gin.Context) {
+	userID, err := utils.GetUserFromCtx(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "Unauthorized",
+		})
+		return
+	}
+
+	sessionID := ctx.Param("id")
+	if !utils.IsUUID(sessionID) {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   "Sesi tidak ditemukan",
+		})
+		return
+	}
+
+	if err := a.sr.RevokeSession(ctx.Request.Context(), userID, sessionID); err != nil {
+		if strings.Contains(err.Error(), "session not found") {
+			ctx.JSON(http.StatusNotFound, gin.H{
+				"success": false,
+				"error":   "Sesi tidak ditemukan",
+			})
+			return
+		}
+		log.Println("Internal Server Error.\nCause: ", err.Error())
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "internal server error",
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Sesi berhasil dicabut",
+	})
+}
+
+// loginFailed mencatat gagal login dan mengirim link unlock jika akun baru saja dikunci.
+// user bernilai nil jika email tidak terdaftar, tetap dicatat agar perilakunya sama
+// rejectLockedLogin mengirim response 429 / 423 dan mengembalikan true jika IP atau akun email sedang dikunci
+// karena terlalu banyak percobaan password yang salah
+func (a *AuthHandler) rejectLockedLogin(ctx *gin.Context, email string) bool {
+	ipLock, accountLock, err := a.la.GetLockout(ctx.Request.Context(), email, ctx.ClientIP())
+	if err != nil {
+		log.Println("Internal Server Error.\nCause: ", err.Error())
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "internal server error",
+		})
+		return true
+	}
+	if ipLock > 0 {
+		ctx.Header("Retry-After", retryAfter(ipLock))
+		ctx.JSON(http.StatusTooManyRequests, gin.H{
+			"success": false,
+			"error":   "Terlalu banyak percobaan login, coba lagi nanti",
+		})
+		return true
+	}
+	if accountLock > 0 {
+		ctx.Header("Retry-After", retryAfter(accountLock))
+		ctx.JSON(http.StatusLocked, gin.H{
+			"success": false,
+			"error":   "Akun dikunci sementara karena terlalu banyak percobaan login",
+		})
+		return true
+	}
+	return false
+}
+
+// registerLoginFailure mencatat password yang salah, dan mengirim email unlock jika akun jadi dikunci
+func (a *AuthHandler) registerLoginFailure(ctx *gin.Context, email string, user *models.User) {
+	lockDuration, err := a.la.RegisterFailure(ctx.Request.Context(), email, ctx.ClientIP())
+	if err != nil {
+		log.Println("Failed to register login failure.\nCause: ", err.Error())
+	}
+	if lockDuration > 0 && user != nil {
+		a.sendUnlockEmail(user.ID, user.Email, lockDuration)
+	}
+}
+
+func (a *AuthHandler) loginFailed(ctx *gin.Context, email string, user *models.User) {
+	a.registerLoginFailure(ctx, email, user)
+
+	ctx.JSON(http.StatusBadRequest, gin.H{
+		"success": false,
+		"error":   "Email atau password salah",
+	})
+}
+
+func retryAfter(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
+
+// startSession mencatat sesi (device) baru untuk user lalu membuat token-nya.
+// Login yang berhasil sekaligus membatalkan jadwal penghapusan akun
+func (a *AuthHandler) startSession(ctx *gin.Context, user *models.User, deviceName string) (string, string, error) {
+	if err := a.cancelAccountDeletion(ctx, user); err != nil {
+		return "", "", err
+	}
+	session := models.Session{
+		UserId:     user.ID,
+		DeviceName: nullableString(deviceName),
+		UserAgent:  nullableString(ctx.Request.UserAgent()),
+		IpAddress:  nullableString(ctx.ClientIP()),
+	}
+	if err := a.sr.CreateSession(ctx.Request.Context(), &session); err != nil {
+		return "", "", err
+	}
+	return a.issueTokens(ctx, user.ID, user.Role, session.Id)
+}
+
+// issueTokens membuat access token JWT dan refresh token untuk sesi yang diberikan
+func (a *AuthHandler) issueTokens(ctx *gin.Context, userID, role, sessionID string) (string, string, error) {
+	claims := pkg.NewJWTClaims(userID, role, sessionID)
+	jwtToken, err := claims.GenToken()
+	if err != nil {
+		return "", "", err
+	}
+
+	refreshToken, err := a.tr.CreateRefreshToken(ctx.Request.Context(), userID, sessionID)
+	if err != nil {
+		return "", "", err
+	}
+	return jwtToken, refreshToken, nil
+}
+
+// revokeAllUserTokens mencabut semua sesi, refresh token dan access token milik user
+func (a *AuthHandler) revokeAllUserTokens(ctx *gin.Context, userID string) error {
+	if err := a.sr.RevokeUserSessions(ctx.Request.Context(), userID, ""); err != nil {
+		return err
+	}
+	if err := a.tr.RevokeUserRefreshTokens(ctx.Request.Context(), userID, ""); err != nil {
+		return err
+	}
+	return a.ar.RevokeAllTokens(ctx.Request.Context(), userID, pkg.AccessTokenTTL)
+}
+
+// changeUsername mengganti username dengan aturan cooldown. Jika gagal, response error sudah dikirim
+func (a *AuthHandler) changeUsername(ctx *gin.Context, userID, username string) bool {
+	user, err := a.ar.GetUserByID(ctx.Request.Context(), userID)
+	if err != nil {
+		if strings.Contains(err.Error(), "user not found") {
+			ctx.JSON(http.StatusNotFound, gin.H{
+				"success": false,
+				"error":   "User tidak ditemukan",
+			})
+			return false
+		}
+		log.Println("Internal Server Error.\nCause: ", err.Error())
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "internal server error",
+		})
+		return false
+	}
+	if user.Username == username {
+		return true
+	}
+
+	if err := a.ar.ChangeUsername(ctx.Request.Context(), userID, username, usernameChangeCooldown); err != nil {
+		switch {
+		case strings.Contains(err.Error(), "username already exists"):
+			ctx.JSON(http.StatusConflict, gin.H{
+				"success": false,
+				"error":   "Username sudah dipakai",
+			})
+		case strings.Contains(err.Error(), "username change on cooldown"):
+			if user.UsernameChangedAt != nil {
+				ctx.Header("Retry-After", retryAfter(time.Until(user.UsernameChangedAt.Add(usernameChangeCooldown))))
+			}
+			ctx.JSON(http.StatusTooManyRequests, gin.H{
+				"success": false,
+				"error":   "Username hanya bisa diganti sekali setiap 30 hari",
+			})
+		default:
+			log.Println("Internal Server Error.\nCause: ", err.Error())
+			ctx.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"error":   "internal server error",
+			})
+		}
+		return false
+	}
+	return true
+}
+
+// generateUsername membuat username yang valid dan belum dipakai dari bagian depan email,
+// ditambah angka acak jika sudah dipakai / dicadangkan
+func (a *AuthHandler) generateUsername(ctx *gin.Context, email string) (string, error) {
+	local, _, _ := strings.Cut(strings.ToLower(email), "@")
+	base := strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') || r == '_' {
+			return r
+		}
+		return '_'
+	}, local)
+	if len(base) > 24 {
+		base = base[:24]
+	}
+	for len(base) < 3 {
+		base += "_"
+	}
+
+	candidate := base
+	for range 10 {
+		if utils.UsernameValidation(candidate) == nil {
+			exists, err := a.ar.CheckUsernameExists(ctx.Request.Context(), candidate)
+			if err != nil {
+				return "", err
+			}
+			if !exists {
+				return candidate, nil
+			}
+		}
+		suffix, err := rand.Int(rand.Reader, big.NewInt(100000))
+		if err != nil {
+			return "", err
+		}
+		candidate = fmt.Sprintf("%s_%d", base, suffix.Int64())
+	}
+	return "", errors.New("failed to generate unique username")
+}
+
+func nullableString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
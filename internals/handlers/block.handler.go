@@ -0,0 +1,307 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/raihaninkam/finalPhase3/internals/models"
+	"github.com/raihaninkam/finalPhase3/internals/repositories"
+	"github.com/raihaninkam/finalPhase3/internals/utils"
+	"github.com/raihaninkam/finalPhase3/pkg"
+)
+
+type BlockHandler struct {
+	br *repositories.BlockRepository
+	tr *repositories.TimelineRepository
+}
+
+func NewBlockHandler(br *repositories.BlockRepository, tr *repositories.TimelineRepository) *BlockHandler {
+	return &BlockHandler{br: br, tr: tr}
+}
+
+// Block godoc
+// @Summary     Block User
+// @Description Memblokir user. Follow di kedua arah dihapus, dan kedua user tidak bisa saling follow, like, comment
+// @Description ataupun melihat post satu sama lain
+// @Tags        Blocks
+// @Produce     json
+// @Security    BearerAuth
+// @Param       id path string true "User ID"
+// @Success     200 {object} map[string]interface{}
+// @Failure     400 {object} map[string]interface{} "Tidak bisa memblokir diri sendiri"
+// @Failure     401 {object} map[string]interface{} "Unauthorized - Token tidak valid"
+// @Failure     404 {object} map[string]interface{} "User tidak ditemukan"
+// @Failure     409 {object} map[string]interface{} "User sudah diblokir"
+// @Failure     500 {object} map[string]interface{}
+// @Router      /block/{id} [post]
+func (bh *BlockHandler) Block(ctx *gin.Context) {
+	userID, targetID, ok := bh.getTarget(ctx, "Cannot block yourself")
+	if !ok {
+		return
+	}
+
+	if err := bh.br.Block(ctx.Request.Context(), userID, targetID); err != nil {
+		switch {
+		case strings.Contains(err.Error(), "user not found"):
+			ctx.JSON(http.StatusNotFound, gin.H{
+				"success": false,
+				"error":   "User not found",
+			})
+		case strings.Contains(err.Error(), "already blocked"):
+			ctx.JSON(http.StatusConflict, gin.H{
+				"success": false,
+				"error":   "User already blocked",
+			})
+		default:
+			log.Println("Error blocking user:", err)
+			ctx.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"error":   "Internal Server Error",
+			})
+		}
+		return
+	}
+
+	// follow di kedua arah sudah dihapus, bersihkan juga timeline keduanya
+	if err := bh.tr.RemoveAuthor(ctx.Request.Context(), userID, targetID); err != nil {
+		log.Println("Failed to remove posts from timeline.\nCause: ", err.Error())
+	}
+	if err := bh.tr.RemoveAuthor(ctx.Request.Context(), targetID, userID); err != nil {
+		log.Println("Failed to remove posts from timeline.\nCause: ", err.Error())
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "User blocked",
+	})
+}
+
+// Unblock godoc
+// @Summary     Unblock User
+// @Description Membuka blokir user. Follow yang terhapus saat block tidak dikembalikan
+// @Tags        Blocks
+// @Produce     json
+// @Security    BearerAuth
+// @Param       id path string true "User ID"
+// @Success     200 {object} map[string]interface{}
+// @Failure     401 {object} map[string]interface{} "Unauthorized - Token tidak valid"
+// @Failure     404 {object} map[string]interface{} "User tidak diblokir"
+// @Failure     500 {object} map[string]interface{}
+// @Router      /block/{id} [delete]
+func (bh *BlockHandler) Unblock(ctx *gin.Context) {
+	userID, targetID, ok := bh.getTarget(ctx, "Cannot unblock yourself")
+	if !ok {
+		return
+	}
+
+	if err := bh.br.Unblock(ctx.Request.Context(), userID, targetID); err != nil {
+		if strings.Contains(err.Error(), "not blocked") {
+			ctx.JSON(http.StatusNotFound, gin.H{
+				"success": false,
+				"error":   "User is not blocked",
+			})
+			return
+		}
+		log.Println("Error unblocking user:", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Internal Server Error",
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "User unblocked",
+	})
+}
+
+// GetBlocks godoc
+// @Summary     List Blocked Users
+// @Description Menampilkan user yang diblokir, terbaru lebih dulu
+// @Tags        Blocks
+// @Produce     json
+// @Security    BearerAuth
+// @Param       cursor query string false "Cursor dari pagination.next_cursor"
+// @Param       limit  query int    false "Items per page" default(20)
+// @Success     200 {object} map[string]interface{}
+// @Failure     400 {object} map[string]interface{} "Cursor tidak valid"
+// @Failure     401 {object} map[string]interface{} "Unauthorized - Token tidak valid"
+// @Failure     500 {object} map[string]interface{}
+// @Router      /blocks [get]
+func (bh *BlockHandler) GetBlocks(ctx *gin.Context) {
+	bh.listRestricted(ctx, utils.CursorBlocks, bh.br.GetBlocks)
+}
+
+// Mute godoc
+// @Summary     Mute User
+// @Description Menyembunyikan post dan komentar user dari feed sendiri. User yang di-mute tidak diberi tahu
+// @Tags        Blocks
+// @Produce     json
+// @Security    BearerAuth
+// @Param       id path string true "User ID"
+// @Success     200 {object} map[string]interface{}
+// @Failure     400 {object} map[string]interface{} "Tidak bisa mute diri sendiri"
+// @Failure     401 {object} map[string]interface{} "Unauthorized - Token tidak valid"
+// @Failure     404 {object} map[string]interface{} "User tidak ditemukan"
+// @Failure     409 {object} map[string]interface{} "User sudah di-mute"
+// @Failure     500 {object} map[string]interface{}
+// @Router      /mute/{id} [post]
+func (bh *BlockHandler) Mute(ctx *gin.Context) {
+	userID, targetID, ok := bh.getTarget(ctx, "Cannot mute yourself")
+	if !ok {
+		return
+	}
+
+	if err := bh.br.Mute(ctx.Request.Context(), userID, targetID); err != nil {
+		switch {
+		case strings.Contains(err.Error(), "user not found"):
+			ctx.JSON(http.StatusNotFound, gin.H{
+				"success": false,
+				"error":   "User not found",
+			})
+		case strings.Contains(err.Error(), "already muted"):
+			ctx.JSON(http.StatusConflict, gin.H{
+				"success": false,
+				"error":   "User already muted",
+			})
+		default:
+			log.Println("Error muting user:", err)
+			ctx.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"error":   "Internal Server Error",
+			})
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "User muted",
+	})
+}
+
+// Unmute godoc
+// @Summary     Unmute User
+// @Description Menampilkan kembali post dan komentar user di feed
+// @Tags        Blocks
+// @Produce     json
+// @Security    BearerAuth
+// @Param       id path string true "User ID"
+// @Success     200 {object} map[string]interface{}
+// @Failure     401 {object} map[string]interface{} "Unauthorized - Token tidak valid"
+// @Failure     404 {object} map[string]interface{} "User tidak di-mute"
+// @Failure     500 {object} map[string]interface{}
+// @Router      /mute/{id} [delete]
+func (bh *BlockHandler) Unmute(ctx *gin.Context) {
+	userID, targetID, ok := bh.getTarget(ctx, "Cannot unmute yourself")
+	if !ok {
+		return
+	}
+
+	if err := bh.br.Unmute(ctx.Request.Context(), userID, targetID); err != nil {
+		if strings.Contains(err.Error(), "not muted") {
+			ctx.JSON(http.StatusNotFound, gin.H{
+				"success": false,
+				"error":   "User is not muted",
+			})
+			return
+		}
+		log.Println("Error unmuting user:", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Internal Server Error",
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "User unmuted",
+	})
+}
+
+// GetMutes godoc
+// @Summary     List Muted Users
+// @Description Menampilkan user yang di-mute, terbaru lebih dulu
+// @Tags        Blocks
+// @Produce     json
+// @Security    BearerAuth
+// @Param       cursor query string false "Cursor dari pagination.next_cursor"
+// @Param       limit  query int    false "Items per page" default(20)
+// @Success     200 {object} map[string]interface{}
+// @Failure     400 {object} map[string]interface{} "Cursor tidak valid"
+// @Failure     401 {object} map[string]interface{} "Unauthorized - Token tidak valid"
+// @Failure     500 {object} map[string]interface{}
+// @Router      /mutes [get]
+func (bh *BlockHandler) GetMutes(ctx *gin.Context) {
+	bh.listRestricted(ctx, utils.CursorMutes, bh.br.GetMutes)
+}
+
+// getTarget mengambil user yang login dan user tujuan di path :id. Response error sudah dikirim jika ok = false
+func (bh *BlockHandler) getTarget(ctx *gin.Context, selfError string) (userID, targetID string, ok bool) {
+	userID, err := utils.GetUserFromCtx(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "Unauthorized",
+		})
+		return "", "", false
+	}
+
+	targetID = ctx.Param("id")
+	if !utils.IsUUID(targetID) {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   "User not found",
+		})
+		return "", "", false
+	}
+	if targetID == userID {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   selfError,
+		})
+		return "", "", false
+	}
+	return userID, targetID, true
+}
+
+func (bh *BlockHandler) listRestricted(ctx *gin.Context, kind string, list func(context.Context, string, int, *pkg.Cursor) ([]models.RestrictedUser, *pkg.Cursor, error)) {
+	userID, err := utils.GetUserFromCtx(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "Unauthorized",
+		})
+		return
+	}
+
+	limit, cursor, err := utils.GetPaginationParams(ctx, kind)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid cursor",
+		})
+		return
+	}
+
+	users, next, err := list(ctx.Request.Context(), userID, limit, cursor)
+	if err != nil {
+		log.Println("Error getting users:", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Internal Server Error",
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success":    true,
+		"data":       users,
+		"pagination": utils.NewPagination(limit, next),
+	})
+}
@@ -3,6 +3,7 @@ package handlers
 import (
 	"log"
 	"net/http"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/raihaninkam/finalPhase3/internals/models"
@@ -29,10 +30,10 @@ func (ch *CommentHandler) CreateComment(ctx *gin.Context) {
 	}
 
 	postID := ctx.Param("id")
-	if postID == "" {
-		ctx.JSON(http.StatusBadRequest, gin.H{
+	if !utils.IsUUID(postID) {
+		ctx.JSON(http.StatusNotFound, gin.H{
 			"success": false,
-			"error":   "Post ID is required",
+			"error":   "Post not found",
 		})
 		return
 	}
@@ -54,6 +55,13 @@ func (ch *CommentHandler) CreateComment(ctx *gin.Context) {
 
 	newComment, err := ch.cr.CreateComment(ctx, comment)
 	if err != nil {
+		if strings.Contains(err.Error(), "post not found") {
+			ctx.JSON(http.StatusNotFound, gin.H{
+				"success": false,
+				"error":   "Post not found",
+			})
+			return
+		}
 		log.Println("Error creating comment:", err)
 		ctx.JSON(http.StatusInternalServerError, gin.H{
 			"success": false,
@@ -69,17 +77,42 @@ func (ch *CommentHandler) CreateComment(ctx *gin.Context) {
 }
 
 func (ch *CommentHandler) GetPostComments(ctx *gin.Context) {
+	userID, err := utils.GetUserFromCtx(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "Unauthorized",
+		})
+		return
+	}
+
 	postID := ctx.Param("id")
-	if postID == "" {
+	if !utils.IsUUID(postID) {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   "Post not found",
+		})
+		return
+	}
+
+	limit, cursor, err := utils.GetPaginationParams(ctx, utils.CursorComments)
+	if err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{
 			"success": false,
-			"error":   "Post ID is required",
+			"error":   "Invalid cursor",
 		})
 		return
 	}
 
-	comments, err := ch.cr.GetPostComments(ctx, postID)
+	comments, next, err := ch.cr.GetPostComments(ctx, postID, userID, limit, cursor)
 	if err != nil {
+		if strings.Contains(err.Error(), "post not found") {
+			ctx.JSON(http.StatusNotFound, gin.H{
+				"success": false,
+				"error":   "Post not found",
+			})
+			return
+		}
 		log.Println("Error getting comments:", err)
 		ctx.JSON(http.StatusInternalServerError, gin.H{
 			"success": false,
@@ -89,8 +122,9 @@ func (ch *CommentHandler) GetPostComments(ctx *gin.Context) {
 	}
 
 	ctx.JSON(http.StatusOK, gin.H{
-		"success": true,
-		"data":    comments,
+		"success":    true,
+		"data":       comments,
+		"pagination": utils.NewPagination(limit, next),
 	})
 }
 
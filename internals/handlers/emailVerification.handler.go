@@ -0,0 +1,154 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/raihaninkam/finalPhase3/internals/models"
+	"github.com/raihaninkam/finalPhase3/internals/utils"
+	"github.com/raihaninkam/finalPhase3/pkg"
+)
+
+const (
+	verifyEmailPurpose  = "verify-email"
+	verifyEmailTTL      = time.Hour * 24
+	verifyEmailCooldown = time.Minute
+)
+
+// VerifyEmail godoc
+// @Summary     Verifikasi Email
+// @Description Verifikasi email menggunakan token dari link yang dikirim ke email.
+// @Tags        Auth
+// @Accept      json
+// @Produce     json
+// @Param       body body models.VerifyEmailRequest true "Verify Email Request"
+// @Success     200 {object} map[string]interface{} "Email berhasil diverifikasi"
+// @Failure     400 {object} map[string]interface{} "Bad Request - Token tidak valid atau expired"
+// @Failure     500 {object} map[string]interface{} "Internal Server Error"
+// @Router      /auth/verify-email [post]
+func (a *AuthHandler) VerifyEmail(ctx *gin.Context) {
+	var body models.VerifyEmailRequest
+	if err := ctx.ShouldBind(&body); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Token harus diisi",
+		})
+		return
+	}
+
+	var claims pkg.ActionClaims
+	if err := claims.VerifyToken(body.Token, verifyEmailPurpose); err != nil {
+		log.Println("Invalid verification token.\nCause: ", err.Error())
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Link verifikasi tidak valid atau sudah expired",
+		})
+		return
+	}
+
+	if err := a.ar.VerifyEmail(ctx.Request.Context(), claims.UserId, claims.Email); err != nil {
+		if strings.Contains(err.Error(), "user not found") {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error":   "Link verifikasi tidak valid atau sudah expired",
+			})
+			return
+		}
+		log.Println("Internal Server Error.\nCause: ", err.Error())
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "internal server error",
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Email berhasil diverifikasi",
+	})
+}
+
+// ResendVerification godoc
+// @Summary     Kirim Ulang Email Verifikasi
+// @Description Kirim ulang link verifikasi email. Response selalu sama walaupun email tidak terdaftar.
+// @Tags        Auth
+// @Accept      json
+// @Produce     json
+// @Param       body body models.ForgotPasswordRequest true "Email"
+// @Success     200 {object} map[string]interface{} "Link verifikasi dikirim jika email terdaftar dan belum terverifikasi"
+// @Failure     400 {object} map[string]interface{} "Bad Request - Email tidak valid"
+// @Failure     500 {object} map[string]interface{} "Internal Server Error"
+// @Router      /auth/verify-email/resend [post]
+func (a *AuthHandler) ResendVerification(ctx *gin.Context) {
+	var body models.ForgotPasswordRequest
+	if err := ctx.ShouldBind(&body); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Email harus diisi dengan format yang benar",
+		})
+		return
+	}
+
+	response := gin.H{
+		"success": true,
+		"message": "Jika email terdaftar dan belum terverifikasi, link verifikasi sudah dikirim",
+	}
+
+	user, err := a.ar.GetEmail(ctx.Request.Context(), body.Email)
+	if err != nil {
+		if strings.Contains(err.Error(), "user not found") {
+			ctx.JSON(http.StatusOK, response)
+			return
+		}
+		log.Println("Internal Server Error.\nCause: ", err.Error())
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "internal server error",
+		})
+		return
+	}
+
+	if user.EmailVerifiedAt != nil {
+		ctx.JSON(http.StatusOK, response)
+		return
+	}
+
+	allowed, err := a.ar.AllowVerificationEmail(ctx.Request.Context(), user.ID, verifyEmailCooldown)
+	if err != nil {
+		log.Println("Internal Server Error.\nCause: ", err.Error())
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "internal server error",
+		})
+		return
+	}
+	if allowed {
+		a.sendVerificationEmail(user.ID, user.Email)
+	}
+
+	ctx.JSON(http.StatusOK, response)
+}
+
+// sendVerificationEmail membuat link verifikasi bertanda tangan dan mengirimnya di background
+func (a *AuthHandler) sendVerificationEmail(userID, email string) {
+	claims := pkg.NewActionClaims(userID, email, verifyEmailPurpose, verifyEmailTTL)
+	token, err := claims.GenToken()
+	if err != nil {
+		log.Println("Failed to generate verification token.\nCause: ", err.Error())
+		return
+	}
+
+	link := utils.AppURL("/verify-email?token=" + url.QueryEscape(token))
+	go func() {
+		body := fmt.Sprintf("Klik link berikut untuk verifikasi email anda (berlaku %d jam):\n\n%s",
+			int(verifyEmailTTL.Hours()), link)
+		if err := a.mailer.Send(email, "Verifikasi Email", body); err != nil {
+			log.Println("Failed to send verification email.\nCause: ", err.Error())
+		}
+	}()
+}
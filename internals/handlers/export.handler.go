@@ -0,0 +1,150 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/raihaninkam/finalPhase3/internals/models"
+	"github.com/raihaninkam/finalPhase3/internals/repositories"
+	"github.com/raihaninkam/finalPhase3/internals/utils"
+)
+
+type ExportHandler struct {
+	er *repositories.ExportRepository
+}
+
+func NewExportHandler(er *repositories.ExportRepository) *ExportHandler {
+	return &ExportHandler{er: er}
+}
+
+// RequestExport godoc
+// @Summary     Request Data Export
+// @Description Membuat permintaan export semua data user (profil, post, komentar, like, follow, sesi dan gambar) dalam bentuk ZIP.
+// @Description Export diproses di background, cek statusnya lewat GET /me/export/{id}.
+// @Tags        Me
+// @Produce     json
+// @Security    BearerAuth
+// @Success     202 {object} map[string]interface{} "Export sedang diproses"
+// @Failure     401 {object} map[string]interface{} "Unauthorized - Token tidak valid"
+// @Failure     409 {object} map[string]interface{} "Conflict - Masih ada export yang sedang diproses"
+// @Failure     500 {object} map[string]interface{} "Internal Server Error"
+// @Router      /me/export [post]
+func (eh *ExportHandler) RequestExport(ctx *gin.Context) {
+	userID, err := utils.GetUserFromCtx(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "Unauthorized",
+		})
+		return
+	}
+
+	export, err := eh.er.CreateExport(ctx.Request.Context(), userID)
+	if err != nil {
+		if strings.Contains(err.Error(), "export already in progress") {
+			ctx.JSON(http.StatusConflict, gin.H{
+				"success": false,
+				"error":   "Masih ada export yang sedang diproses",
+			})
+			return
+		}
+		log.Println("Internal Server Error.\nCause: ", err.Error())
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "internal server error",
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, gin.H{
+		"success": true,
+		"message": "Export sedang diproses",
+		"data":    export,
+	})
+}
+
+// GetExport godoc
+// @Summary     Get / Download Data Export
+// @Description Mengecek status export. Selama masih diproses mengembalikan 202, jika sudah selesai langsung mengirim file ZIP.
+// @Tags        Me
+// @Produce     json
+// @Produce     application/zip
+// @Security    BearerAuth
+// @Param       id path string true "Export ID"
+// @Success     200 {file} file "Arsip ZIP"
+// @Success     202 {object} map[string]interface{} "Export masih diproses"
+// @Failure     401 {object} map[string]interface{} "Unauthorized - Token tidak valid"
+// @Failure     404 {object} map[string]interface{} "Export tidak ditemukan"
+// @Failure     410 {object} map[string]interface{} "Export sudah expired"
+// @Failure     500 {object} map[string]interface{} "Export gagal / Internal Server Error"
+// @Router      /me/export/{id} [get]
+func (eh *ExportHandler) GetExport(ctx *gin.Context) {
+	userID, err := utils.GetUserFromCtx(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "Unauthorized",
+		})
+		return
+	}
+
+	exportID := ctx.Param("id")
+	if !utils.IsUUID(exportID) {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   "Export tidak ditemukan",
+		})
+		return
+	}
+
+	export, err := eh.er.GetExport(ctx.Request.Context(), userID, exportID)
+	if err != nil {
+		if strings.Contains(err.Error(), "export not found") {
+			ctx.JSON(http.StatusNotFound, gin.H{
+				"success": false,
+				"error":   "Export tidak ditemukan",
+			})
+			return
+		}
+		log.Println("Internal Server Error.\nCause: ", err.Error())
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "internal server error",
+		})
+		return
+	}
+
+	switch export.Status {
+	case models.ExportPending, models.ExportProcessing:
+		ctx.Header("Retry-After", "15")
+		ctx.JSON(http.StatusAccepted, gin.H{
+			"success": true,
+			"message": "Export sedang diproses",
+			"data":    export,
+		})
+	case models.ExportFailed:
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Export gagal, silahkan request ulang",
+			"data":    export,
+		})
+	case models.ExportCompleted:
+		if export.FilePath == nil || (export.ExpiresAt != nil && time.Now().After(*export.ExpiresAt)) {
+			ctx.JSON(http.StatusGone, gin.H{
+				"success": false,
+				"error":   "Export sudah expired, silahkan request ulang",
+			})
+			return
+		}
+		ctx.Header("Cache-Control", "no-store")
+		ctx.FileAttachment(*export.FilePath, "export-"+export.CreatedAt.Format("20060102")+".zip")
+	default:
+		ctx.JSON(http.StatusGone, gin.H{
+			"success": false,
+			"error":   "Export sudah expired, silahkan request ulang",
+		})
+	}
+}
@@ -1,19 +1,25 @@
 package handlers
 
 import (
+	"context"
+	"log"
 	"net/http"
+	"strings"
 
 	"github.com/gin-gonic/gin"
+	"github.com/raihaninkam/finalPhase3/internals/models"
 	"github.com/raihaninkam/finalPhase3/internals/repositories"
 	"github.com/raihaninkam/finalPhase3/internals/utils"
+	"github.com/raihaninkam/finalPhase3/pkg"
 )
 
 type FollowHandler struct {
 	fr *repositories.FollowRepository
+	tr *repositories.TimelineRepository
 }
 
-func NewFollowHandler(fr *repositories.FollowRepository) *FollowHandler {
-	return &FollowHandler{fr: fr}
+func NewFollowHandler(fr *repositories.FollowRepository, tr *repositories.TimelineRepository) *FollowHandler {
+	return &FollowHandler{fr: fr, tr: tr}
 }
 
 func (fh *FollowHandler) GetFollowing(ctx *gin.Context) {
@@ -26,7 +32,16 @@ func (fh *FollowHandler) GetFollowing(ctx *gin.Context) {
 		return
 	}
 
-	users, err := fh.fr.GetFollowing(ctx, userID)
+	limit, cursor, err := utils.GetPaginationParams(ctx, utils.CursorFollowing)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid cursor",
+		})
+		return
+	}
+
+	users, next, err := fh.fr.GetFollowing(ctx, userID, limit, cursor)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{
 			"success": false,
@@ -36,8 +51,9 @@ func (fh *FollowHandler) GetFollowing(ctx *gin.Context) {
 	}
 
 	ctx.JSON(http.StatusOK, gin.H{
-		"success": true,
-		"data":    users,
+		"success":    true,
+		"data":       users,
+		"pagination": utils.NewPagination(limit, next),
 	})
 }
 
@@ -52,10 +68,10 @@ func (fh *FollowHandler) Follow(ctx *gin.Context) {
 	}
 
 	followingID := ctx.Param("id")
-	if followingID == "" {
-		ctx.JSON(http.StatusBadRequest, gin.H{
+	if !utils.IsUUID(followingID) {
+		ctx.JSON(http.StatusNotFound, gin.H{
 			"success": false,
-			"error":   "User ID is required",
+			"error":   "User not found",
 		})
 		return
 	}
@@ -68,16 +84,460 @@ func (fh *FollowHandler) Follow(ctx *gin.Context) {
 		return
 	}
 
-	if err := fh.fr.Follow(ctx, userID, followingID); err != nil {
+	requested, err := fh.fr.Follow(ctx.Request.Context(), userID, followingID)
+	if err != nil {
+		switch {
+		case strings.Contains(err.Error(), "user not found"):
+			ctx.JSON(http.StatusNotFound, gin.H{
+				"success": false,
+				"error":   "User not found",
+			})
+		case strings.Contains(err.Error(), "already following"):
+			ctx.JSON(http.StatusConflict, gin.H{
+				"success": false,
+				"error":   "Already following this user",
+			})
+		case strings.Contains(err.Error(), "user blocked"):
+			ctx.JSON(http.StatusForbidden, gin.H{
+				"success": false,
+				"error":   "You cannot follow this user",
+			})
+		case strings.Contains(err.Error(), "follow request already sent"):
+			ctx.JSON(http.StatusConflict, gin.H{
+				"success": false,
+				"error":   "Follow request already sent",
+			})
+		default:
+			log.Println("Error following user:", err)
+			ctx.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"error":   "Failed to follow user",
+			})
+		}
+		return
+	}
+
+	// akun private: menunggu persetujuan pemilik akun
+	if requested {
+		ctx.JSON(http.StatusAccepted, gin.H{
+			"success": true,
+			"message": "Follow request sent",
+		})
+		return
+	}
+
+	// masukkan post user yang di-follow ke timeline
+	if err := fh.tr.Backfill(ctx.Request.Context(), userID, followingID); err != nil {
+		log.Println("Failed to backfill timeline.\nCause: ", err.Error())
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Successfully followed user",
+	})
+}
+
+// Unfollow godoc
+// @Summary     Unfollow User
+// @Description Berhenti mengikuti user, post user tersebut dihapus dari feed. Jika masih berupa follow request, request dibatalkan
+// @Tags        Follows
+// @Produce     json
+// @Security    BearerAuth
+// @Param       id path string true "User ID"
+// @Success     200 {object} map[string]interface{}
+// @Failure     401 {object} map[string]interface{} "Unauthorized - Token tidak valid"
+// @Failure     404 {object} map[string]interface{} "User tidak ditemukan / belum di-follow"
+// @Failure     500 {object} map[string]interface{}
+// @Router      /follow/{id} [delete]
+func (fh *FollowHandler) Unfollow(ctx *gin.Context) {
+	userID, err := utils.GetUserFromCtx(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "Unauthorized",
+		})
+		return
+	}
+
+	followingID := ctx.Param("id")
+	if !utils.IsUUID(followingID) || followingID == userID {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   "User not found",
+		})
+		return
+	}
+
+	if err := fh.fr.Unfollow(ctx.Request.Context(), userID, followingID); err != nil {
+		switch {
+		case strings.Contains(err.Error(), "user not found"):
+			ctx.JSON(http.StatusNotFound, gin.H{
+				"success": false,
+				"error":   "User not found",
+			})
+		case strings.Contains(err.Error(), "not following"):
+			ctx.JSON(http.StatusNotFound, gin.H{
+				"success": false,
+				"error":   "You are not following this user",
+			})
+		default:
+			log.Println("Error unfollowing user:", err)
+			ctx.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"error":   "Failed to unfollow user",
+			})
+		}
+		return
+	}
+
+	// hapus post user yang di-unfollow dari timeline
+	if err := fh.tr.RemoveAuthor(ctx.Request.Context(), userID, followingID); err != nil {
+		log.Println("Failed to remove posts from timeline.\nCause: ", err.Error())
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Successfully unfollowed user",
+	})
+}
+
+// GetUserFollowers godoc
+// @Summary     Get User Followers
+// @Description Menampilkan daftar follower user (username atau id), follower terbaru lebih dulu
+// @Tags        Follows
+// @Produce     json
+// @Security    BearerAuth
+// @Param       username path  string true  "Username atau User ID"
+// @Param       cursor   query string false "Cursor dari pagination.next_cursor"
+// @Param       limit    query int    false "Items per page" default(20)
+// @Success     200 {object} map[string]interface{}
+// @Failure     400 {object} map[string]interface{} "Cursor tidak valid"
+// @Failure     401 {object} map[string]interface{} "Unauthorized - Token tidak valid"
+// @Failure     403 {object} map[string]interface{} "Akun private / diblokir"
+// @Failure     404 {object} map[string]interface{} "User tidak ditemukan"
+// @Failure     500 {object} map[string]interface{}
+// @Router      /users/{username}/followers [get]
+func (fh *FollowHandler) GetUserFollowers(ctx *gin.Context) {
+	fh.listUserFollows(ctx, utils.CursorFollowers, fh.fr.GetFollowers)
+}
+
+// GetUserFollowing godoc
+// @Summary     Get User Following
+// @Description Menampilkan daftar user yang di-follow oleh user (username atau id), yang terakhir di-follow lebih dulu
+// @Tags        Follows
+// @Produce     json
+// @Security    BearerAuth
+// @Param       username path  string true  "Username atau User ID"
+// @Param       cursor   query string false "Cursor dari pagination.next_cursor"
+// @Param       limit    query int    false "Items per page" default(20)
+// @Success     200 {object} map[string]interface{}
+// @Failure     400 {object} map[string]interface{} "Cursor tidak valid"
+// @Failure     401 {object} map[string]interface{} "Unauthorized - Token tidak valid"
+// @Failure     403 {object} map[string]interface{} "Akun private / diblokir"
+// @Failure     404 {object} map[string]interface{} "User tidak ditemukan"
+// @Failure     500 {object} map[string]interface{}
+// @Router      /users/{username}/following [get]
+func (fh *FollowHandler) GetUserFollowing(ctx *gin.Context) {
+	fh.listUserFollows(ctx, utils.CursorFollowing, fh.fr.GetFollowing)
+}
+
+// listUserFollows menjalankan listing followers / following milik user di path :username.
+// Daftar milik akun private hanya bisa dilihat pemiliknya dan follower-nya, dan tidak bisa dilihat jika ada block
+func (fh *FollowHandler) listUserFollows(ctx *gin.Context, kind string, list func(context.Context, string, int, *pkg.Cursor) ([]models.UserProfile, *pkg.Cursor, error)) {
+	viewerID, err := utils.GetUserFromCtx(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "Unauthorized",
+		})
+		return
+	}
+
+	username := ctx.Param("username")
+	if !utils.IsUUID(username) {
+		username = utils.NormalizeUsername(username)
+	}
+
+	limit, cursor, err := utils.GetPaginationParams(ctx, kind)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid cursor",
+		})
+		return
+	}
+
+	userID, err := fh.fr.GetUserID(ctx.Request.Context(), username)
+	if err != nil {
+		if strings.Contains(err.Error(), "user not found") {
+			ctx.JSON(http.StatusNotFound, gin.H{
+				"success": false,
+				"error":   "User not found",
+			})
+			return
+		}
+		log.Println("Error getting user:", err)
 		ctx.JSON(http.StatusInternalServerError, gin.H{
 			"success": false,
-			"error":   "Failed to follow user",
+			"error":   "Internal Server Error",
+		})
+		return
+	}
+
+	visible, err := fh.fr.CanView(ctx.Request.Context(), viewerID, userID)
+	if err != nil {
+		log.Println("Error checking visibility:", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Internal Server Error",
+		})
+		return
+	}
+	if !visible {
+		ctx.JSON(http.StatusForbidden, gin.H{
+			"success": false,
+			"error":   "You are not allowed to view this account",
+		})
+		return
+	}
+
+	users, next, err := list(ctx.Request.Context(), userID, limit, cursor)
+	if err != nil {
+		log.Println("Error getting follows:", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Internal Server Error",
 		})
 		return
 	}
 
+	ctx.JSON(http.StatusOK, gin.H{
+		"success":    true,
+		"data":       users,
+		"pagination": utils.NewPagination(limit, next),
+	})
+}
+
+// UpdatePrivacy godoc
+// @Summary     Update Account Privacy
+// @Description Mengubah akun menjadi private / publik. Akun private hanya bisa dilihat follower yang sudah disetujui.
+// @Description Saat akun menjadi publik, semua follow request yang pending otomatis disetujui.
+// @Tags        Me
+// @Accept      json
+// @Produce     json
+// @Security    BearerAuth
+// @Param       body body models.PrivacyRequest true "Privacy"
+// @Success     200 {object} map[string]interface{}
+// @Failure     400 {object} map[string]interface{} "Input tidak valid"
+// @Failure     401 {object} map[string]interface{} "Unauthorized - Token tidak valid"
+// @Failure     500 {object} map[string]interface{}
+// @Router      /me/privacy [patch]
+func (fh *FollowHandler) UpdatePrivacy(ctx *gin.Context) {
+	userID, err := utils.GetUserFromCtx(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "Unauthorized",
+		})
+		return
+	}
+
+	var req models.PrivacyRequest
+	if err := ctx.ShouldBind(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "is_private is required",
+		})
+		return
+	}
+
+	approved, err := fh.fr.SetPrivacy(ctx.Request.Context(), userID, *req.IsPrivate)
+	if err != nil {
+		if strings.Contains(err.Error(), "user not found") {
+			ctx.JSON(http.StatusNotFound, gin.H{
+				"success": false,
+				"error":   "User not found",
+			})
+			return
+		}
+		log.Println("Error updating privacy:", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Internal Server Error",
+		})
+		return
+	}
+
+	for _, requesterID := range approved {
+		if err := fh.tr.Backfill(ctx.Request.Context(), requesterID, userID); err != nil {
+			log.Println("Failed to backfill timeline.\nCause: ", err.Error())
+		}
+	}
+
 	ctx.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"message": "Successfully followed user",
+		"data": gin.H{
+			"is_private": *req.IsPrivate,
+		},
+	})
+}
+
+// GetFollowRequests godoc
+// @Summary     List Follow Requests
+// @Description Menampilkan follow request yang menunggu persetujuan, terbaru lebih dulu
+// @Tags        Me
+// @Produce     json
+// @Security    BearerAuth
+// @Param       cursor query string false "Cursor dari pagination.next_cursor"
+// @Param       limit  query int    false "Items per page" default(20)
+// @Success     200 {object} map[string]interface{}
+// @Failure     400 {object} map[string]interface{} "Cursor tidak valid"
+// @Failure     401 {object} map[string]interface{} "Unauthorized - Token tidak valid"
+// @Failure     500 {object} map[string]interface{}
+// @Router      /me/follow-requests [get]
+func (fh *FollowHandler) GetFollowRequests(ctx *gin.Context) {
+	userID, err := utils.GetUserFromCtx(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "Unauthorized",
+		})
+		return
+	}
+
+	limit, cursor, err := utils.GetPaginationParams(ctx, utils.CursorFollowRequests)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid cursor",
+		})
+		return
+	}
+
+	requests, next, err := fh.fr.GetFollowRequests(ctx.Request.Context(), userID, limit, cursor)
+	if err != nil {
+		log.Println("Error getting follow requests:", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Internal Server Error",
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success":    true,
+		"data":       requests,
+		"pagination": utils.NewPagination(limit, next),
+	})
+}
+
+// ApproveFollowRequest godoc
+// @Summary     Approve Follow Request
+// @Description Menyetujui follow request, requester menjadi follower
+// @Tags        Me
+// @Produce     json
+// @Security    BearerAuth
+// @Param       id path string true "User ID requester"
+// @Success     200 {object} map[string]interface{}
+// @Failure     401 {object} map[string]interface{} "Unauthorized - Token tidak valid"
+// @Failure     404 {object} map[string]interface{} "Follow request tidak ditemukan"
+// @Failure     500 {object} map[string]interface{}
+// @Router      /me/follow-requests/{id}/approve [post]
+func (fh *FollowHandler) ApproveFollowRequest(ctx *gin.Context) {
+	userID, err := utils.GetUserFromCtx(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "Unauthorized",
+		})
+		return
+	}
+
+	requesterID := ctx.Param("id")
+	if !utils.IsUUID(requesterID) {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   "Follow request not found",
+		})
+		return
+	}
+
+	if err := fh.fr.ApproveFollowRequest(ctx.Request.Context(), userID, requesterID); err != nil {
+		if strings.Contains(err.Error(), "follow request not found") || strings.Contains(err.Error(), "user not found") {
+			ctx.JSON(http.StatusNotFound, gin.H{
+				"success": false,
+				"error":   "Follow request not found",
+			})
+			return
+		}
+		log.Println("Error approving follow request:", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Internal Server Error",
+		})
+		return
+	}
+
+	// masukkan post user ini ke timeline requester
+	if err := fh.tr.Backfill(ctx.Request.Context(), requesterID, userID); err != nil {
+		log.Println("Failed to backfill timeline.\nCause: ", err.Error())
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Follow request approved",
+	})
+}
+
+// RejectFollowRequest godoc
+// @Summary     Reject Follow Request
+// @Description Menolak follow request
+// @Tags        Me
+// @Produce     json
+// @Security    BearerAuth
+// @Param       id path string true "User ID requester"
+// @Success     200 {object} map[string]interface{}
+// @Failure     401 {object} map[string]interface{} "Unauthorized - Token tidak valid"
+// @Failure     404 {object} map[string]interface{} "Follow request tidak ditemukan"
+// @Failure     500 {object} map[string]interface{}
+// @Router      /me/follow-requests/{id} [delete]
+func (fh *FollowHandler) RejectFollowRequest(ctx *gin.Context) {
+	userID, err := utils.GetUserFromCtx(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "Unauthorized",
+		})
+		return
+	}
+
+	requesterID := ctx.Param("id")
+	if !utils.IsUUID(requesterID) {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   "Follow request not found",
+		})
+		return
+	}
+
+	if err := fh.fr.RejectFollowRequest(ctx.Request.Context(), userID, requesterID); err != nil {
+		if strings.Contains(err.Error(), "follow request not found") {
+			ctx.JSON(http.StatusNotFound, gin.H{
+				"success": false,
+				"error":   "Follow request not found",
+			})
+			return
+		}
+		log.Println("Error rejecting follow request:", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Internal Server Error",
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Follow request rejected",
 	})
 }
@@ -0,0 +1,31 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/raihaninkam/finalPhase3/pkg"
+)
+
+// JWKS godoc
+// @Summary     JSON Web Key Set
+// @Description Public key untuk memverifikasi access token (RS256 / EdDSA) oleh service lain. Format mengikuti RFC 7517, bukan format response standar API.
+// @Tags        Auth
+// @Produce     json
+// @Success     200 {object} map[string]interface{}
+// @Failure     500 {object} map[string]interface{}
+// @Router      /.well-known/jwks.json [get]
+func JWKS(ctx *gin.Context) {
+	keys, err := pkg.JWKS()
+	if err != nil {
+		log.Println("Failed to load jwt keys.\nCause: ", err.Error())
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "internal server error",
+		})
+		return
+	}
+	ctx.Header("Cache-Control", "public, max-age=300")
+	ctx.JSON(http.StatusOK, gin.H{"keys": keys})
+}
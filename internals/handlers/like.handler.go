@@ -3,6 +3,7 @@ package handlers
 import (
 	"log"
 	"net/http"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/raihaninkam/finalPhase3/internals/repositories"
@@ -28,15 +29,22 @@ func (lh *LikeHandler) LikePost(ctx *gin.Context) {
 	}
 
 	postID := ctx.Param("id")
-	if postID == "" {
-		ctx.JSON(http.StatusBadRequest, gin.H{
+	if !utils.IsUUID(postID) {
+		ctx.JSON(http.StatusNotFound, gin.H{
 			"success": false,
-			"error":   "Post ID is required",
+			"error":   "Post not found",
 		})
 		return
 	}
 
 	if err := lh.lr.LikePost(ctx, userID, postID); err != nil {
+		if strings.Contains(err.Error(), "post not found") {
+			ctx.JSON(http.StatusNotFound, gin.H{
+				"success": false,
+				"error":   "Post not found",
+			})
+			return
+		}
 		log.Println("Error liking post:", err)
 		ctx.JSON(http.StatusBadRequest, gin.H{
 			"success": false,
@@ -0,0 +1,453 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/raihaninkam/finalPhase3/internals/models"
+	"github.com/raihaninkam/finalPhase3/internals/utils"
+	"github.com/raihaninkam/finalPhase3/pkg"
+)
+
+const (
+	mfaPendingPurpose = "mfa-pending"
+	mfaPendingTTL     = 5 * time.Minute
+	recoveryCodeCount = 10
+)
+
+var totpCodeRegex = regexp.MustCompile(`^\d{6}$`)
+
+// EnrollMfa godoc
+// @Summary     Daftar 2FA (TOTP)
+// @Description Membuat secret TOTP baru dan mengembalikan URI otpauth:// untuk di-scan aplikasi authenticator.
+// @Description 2FA baru aktif setelah dikonfirmasi dengan kode pertama lewat /auth/mfa/confirm.
+// @Tags        Auth
+// @Produce     json
+// @Security    BearerAuth
+// @Success     200 {object} map[string]interface{} "Secret dan otpauth URI"
+// @Failure     401 {object} map[string]interface{} "Unauthorized - Token tidak valid"
+// @Failure     409 {object} map[string]interface{} "Conflict - 2FA sudah aktif"
+// @Failure     500 {object} map[string]interface{} "Internal Server Error"
+// @Router      /auth/mfa/enroll [post]
+func (a *AuthHandler) EnrollMfa(ctx *gin.Context) {
+	userID, err := utils.GetUserFromCtx(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "Unauthorized",
+		})
+		return
+	}
+
+	user, err := a.ar.GetUserByID(ctx.Request.Context(), userID)
+	if err != nil {
+		log.Println("Internal Server Error.\nCause: ", err.Error())
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "internal server error",
+		})
+		return
+	}
+
+	secret, err := pkg.GenTOTPSecret()
+	if err != nil {
+		log.Println("Internal Server Error.\nCause: ", err.Error())
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "internal server error",
+		})
+		return
+	}
+
+	if err := a.mr.SetPendingSecret(ctx.Request.Context(), userID, secret); err != nil {
+		if strings.Contains(err.Error(), "mfa already enabled") {
+			ctx.JSON(http.StatusConflict, gin.H{
+				"success": false,
+				"error":   "2FA sudah aktif",
+			})
+			return
+		}
+		log.Println("Internal Server Error.\nCause: ", err.Error())
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "internal server error",
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Scan QR code lalu konfirmasi dengan kode dari aplikasi authenticator",
+		"data": gin.H{
+			"secret":      secret,
+			"otpauth_uri": pkg.TOTPAuthURI(mfaIssuer(), user.Email, secret),
+		},
+	})
+}
+
+// ConfirmMfa godoc
+// @Summary     Konfirmasi 2FA (TOTP)
+// @Description Mengaktifkan 2FA dengan kode pertama dari aplikasi authenticator. Recovery code hanya ditampilkan sekali.
+// @Tags        Auth
+// @Accept      json
+// @Produce     json
+// @Security    BearerAuth
+// @Param       body body models.MfaCodeRequest true "Kode TOTP"
+// @Success     200 {object} map[string]interface{} "2FA aktif, kembalikan recovery code"
+// @Failure     400 {object} map[string]interface{} "Bad Request - Kode tidak valid atau belum enroll"
+// @Failure     401 {object} map[string]interface{} "Unauthorized - Token tidak valid"
+// @Failure     409 {object} map[string]interface{} "Conflict - 2FA sudah aktif"
+// @Failure     500 {object} map[string]interface{} "Internal Server Error"
+// @Router      /auth/mfa/confirm [post]
+func (a *AuthHandler) ConfirmMfa(ctx *gin.Context) {
+	userID, err := utils.GetUserFromCtx(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "Unauthorized",
+		})
+		return
+	}
+
+	var body models.MfaCodeRequest
+	if err := ctx.ShouldBind(&body); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Kode harus diisi",
+		})
+		return
+	}
+
+	secret, enabled, err := a.mr.GetSecret(ctx.Request.Context(), userID)
+	if err != nil {
+		log.Println("Internal Server Error.\nCause: ", err.Error())
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "internal server error",
+		})
+		return
+	}
+	if enabled {
+		ctx.JSON(http.StatusConflict, gin.H{
+			"success": false,
+			"error":   "2FA sudah aktif",
+		})
+		return
+	}
+	if secret == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Silahkan enroll 2FA terlebih dahulu",
+		})
+		return
+	}
+
+	if _, ok := pkg.ValidateTOTP(secret, body.Code, time.Now()); !ok {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Kode tidak valid",
+		})
+		return
+	}
+
+	recoveryCodes, err := genRecoveryCodes(recoveryCodeCount)
+	if err != nil {
+		log.Println("Internal Server Error.\nCause: ", err.Error())
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "internal server error",
+		})
+		return
+	}
+
+	if err := a.mr.Enable(ctx.Request.Context(), userID, recoveryCodes); err != nil {
+		log.Println("Internal Server Error.\nCause: ", err.Error())
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "internal server error",
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "2FA berhasil diaktifkan, simpan recovery code di tempat yang aman",
+		"data": gin.H{
+			"recovery_codes": recoveryCodes,
+		},
+	})
+}
+
+// VerifyMfa godoc
+// @Summary     Login Tahap 2 (2FA)
+// @Description Tukar mfa_token dari /auth/login dengan kode TOTP atau recovery code untuk mendapatkan access token.
+// @Tags        Auth
+// @Accept      json
+// @Produce     json
+// @Param       body body models.MfaVerifyRequest true "MFA Verify Request"
+// @Success     200 {object} map[string]interface{} "Berhasil login, kembalikan token dan refresh token"
+// @Failure     400 {object} map[string]interface{} "Bad Request - Kode tidak valid"
+// @Failure     401 {object} map[string]interface{} "Unauthorized - mfa_token tidak valid atau expired"
+// @Failure     423 {object} map[string]interface{} "Locked - Akun dikunci sementara (lihat header Retry-After)"
+// @Failure     429 {object} map[string]interface{} "Too Many Requests - IP dikunci sementara (lihat header Retry-After)"
+// @Failure     500 {object} map[string]interface{} "Internal Server Error"
+// @Router      /auth/mfa/verify [post]
+func (a *AuthHandler) VerifyMfa(ctx *gin.Context) {
+	var body models.MfaVerifyRequest
+	if err := ctx.ShouldBind(&body); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "mfa_token dan kode harus diisi",
+		})
+		return
+	}
+
+	var claims pkg.ActionClaims
+	if err := claims.VerifyToken(body.MfaToken, mfaPendingPurpose); err != nil {
+		log.Println("Invalid mfa token.\nCause: ", err.Error())
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "Sesi login sudah expired, silahkan login kembali",
+		})
+		return
+	}
+
+	// kode 2FA juga dihitung sebagai percobaan login agar tidak bisa di-brute force
+	ipLock, accountLock, err := a.la.GetLockout(ctx.Request.Context(), claims.Email, ctx.ClientIP())
+	if err != nil {
+		log.Println("Internal Server Error.\nCause: ", err.Error())
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "internal server error",
+		})
+		return
+	}
+	if ipLock > 0 {
+		ctx.Header("Retry-After", retryAfter(ipLock))
+		ctx.JSON(http.StatusTooManyRequests, gin.H{
+			"success": false,
+			"error":   "Terlalu banyak percobaan login, coba lagi nanti",
+		})
+		return
+	}
+	if accountLock > 0 {
+		ctx.Header("Retry-After", retryAfter(accountLock))
+		ctx.JSON(http.StatusLocked, gin.H{
+			"success": false,
+			"error":   "Akun dikunci sementara karena terlalu banyak percobaan login",
+		})
+		return
+	}
+
+	user, err := a.ar.GetUserByID(ctx.Request.Context(), claims.UserId)
+	if err != nil {
+		if strings.Contains(err.Error(), "user not found") {
+			ctx.JSON(http.StatusUnauthorized, gin.H{
+				"success": false,
+				"error":   "Sesi login sudah expired, silahkan login kembali",
+			})
+			return
+		}
+		log.Println("Internal Server Error.\nCause: ", err.Error())
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "internal server error",
+		})
+		return
+	}
+
+	isValid, err := a.checkMfaCode(ctx, user.ID, body.Code)
+	if err != nil {
+		log.Println("Internal Server Error.\nCause: ", err.Error())
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "internal server error",
+		})
+		return
+	}
+	if !isValid {
+		lockDuration, err := a.la.RegisterFailure(ctx.Request.Context(), user.Email, ctx.ClientIP())
+		if err != nil {
+			log.Println("Failed to register login failure.\nCause: ", err.Error())
+		}
+		if lockDuration > 0 {
+			a.sendUnlockEmail(user.ID, user.Email, lockDuration)
+		}
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Kode tidak valid",
+		})
+		return
+	}
+
+	if err := a.la.ResetAccount(ctx.Request.Context(), user.Email); err != nil {
+		log.Println("Failed to reset login attempts.\nCause: ", err.Error())
+	}
+
+	jwtToken, refreshToken, err := a.startSession(ctx, user, body.DeviceName)
+	if err != nil {
+		log.Println("Internal Server Error.\nCause: ", err.Error())
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "internal server error",
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success":       true,
+		"message":       "Login berhasil",
+		"token":         jwtToken,
+		"refresh_token": refreshToken,
+	})
+}
+
+// DisableMfa godoc
+// @Summary     Nonaktifkan 2FA
+// @Description Menonaktifkan 2FA dengan konfirmasi password dan kode TOTP / recovery code.
+// @Tags        Auth
+// @Accept      json
+// @Produce     json
+// @Security    BearerAuth
+// @Param       body body models.MfaDisableRequest true "MFA Disable Request"
+// @Success     200 {object} map[string]interface{} "2FA dinonaktifkan"
+// @Failure     400 {object} map[string]interface{} "Bad Request - Password atau kode salah"
+// @Failure     401 {object} map[string]interface{} "Unauthorized - Token tidak valid"
+// @Failure     500 {object} map[string]interface{} "Internal Server Error"
+// @Router      /auth/mfa [delete]
+func (a *AuthHandler) DisableMfa(ctx *gin.Context) {
+	userID, err := utils.GetUserFromCtx(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "Unauthorized",
+		})
+		return
+	}
+
+	var body models.MfaDisableRequest
+	if err := ctx.ShouldBind(&body); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Password dan kode harus diisi",
+		})
+		return
+	}
+
+	user, err := a.ar.GetUserByID(ctx.Request.Context(), userID)
+	if err != nil {
+		log.Println("Internal Server Error.\nCause: ", err.Error())
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "internal server error",
+		})
+		return
+	}
+	if !user.MfaEnabled {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "2FA belum aktif",
+		})
+		return
+	}
+
+	hc := pkg.NewHashConfig()
+	isMatched, err := hc.CompareHashAndPassword(body.Password, user.Password)
+	if err != nil {
+		log.Println("Internal Server Error.\nCause: ", err.Error())
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "internal server error",
+		})
+		return
+	}
+	isValid := false
+	if isMatched {
+		isValid, err = a.checkMfaCode(ctx, user.ID, body.Code)
+		if err != nil {
+			log.Println("Internal Server Error.\nCause: ", err.Error())
+			ctx.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"error":   "internal server error",
+			})
+			return
+		}
+	}
+	if !isValid {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Password atau kode salah",
+		})
+		return
+	}
+
+	if err := a.mr.Disable(ctx.Request.Context(), user.ID); err != nil {
+		log.Println("Internal Server Error.\nCause: ", err.Error())
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "internal server error",
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "2FA berhasil dinonaktifkan",
+	})
+}
+
+// checkMfaCode menerima kode TOTP 6 digit (sekali pakai per time step) atau recovery code
+func (a *AuthHandler) checkMfaCode(ctx *gin.Context, userID, code string) (bool, error) {
+	code = strings.TrimSpace(code)
+	if !totpCodeRegex.MatchString(code) {
+		return a.mr.UseRecoveryCode(ctx.Request.Context(), userID, normalizeRecoveryCode(code))
+	}
+
+	secret, enabled, err := a.mr.GetSecret(ctx.Request.Context(), userID)
+	if err != nil {
+		return false, err
+	}
+	if !enabled {
+		return false, nil
+	}
+
+	step, ok := pkg.ValidateTOTP(secret, code, time.Now())
+	if !ok {
+		return false, nil
+	}
+	return a.mr.MarkStepUsed(ctx.Request.Context(), userID, step)
+}
+
+// genRecoveryCodes membuat recovery code acak dengan format xxxxx-xxxxx
+func genRecoveryCodes(n int) ([]string, error) {
+	codes := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		secret, err := pkg.GenTOTPSecret()
+		if err != nil {
+			return nil, err
+		}
+		code := strings.ToLower(secret[:10])
+		codes = append(codes, code[:5]+"-"+code[5:])
+	}
+	return codes, nil
+}
+
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(strings.ReplaceAll(code, " ", ""))
+	if len(code) == 10 {
+		code = code[:5] + "-" + code[5:]
+	}
+	return code
+}
+
+func mfaIssuer() string {
+	if issuer := os.Getenv("JWT_ISSUER"); issuer != "" {
+		return issuer
+	}
+	return "Social Media"
+}
@@ -0,0 +1,157 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/raihaninkam/finalPhase3/internals/models"
+	"github.com/raihaninkam/finalPhase3/internals/repositories"
+	"github.com/raihaninkam/finalPhase3/internals/utils"
+)
+
+type NotificationHandler struct {
+	nr *repositories.NotificationRepository
+}
+
+func NewNotificationHandler(nr *repositories.NotificationRepository) *NotificationHandler {
+	return &NotificationHandler{nr: nr}
+}
+
+// GetNotifications godoc
+// @Summary     List Notifications
+// @Description Menampilkan notifikasi like, komentar, follow dan mention. Notifikasi sejenis yang belum dibaca digabung,
+// @Description misalnya "budi and 4 others liked your post". Diurutkan dari yang terakhir diperbarui
+// @Tags        Notifications
+// @Produce     json
+// @Security    BearerAuth
+// @Param       cursor query string false "Cursor dari pagination.next_cursor"
+// @Param       limit  query int    false "Items per page" default(20)
+// @Success     200 {object} map[string]interface{}
+// @Failure     400 {object} map[string]interface{} "Cursor tidak valid"
+// @Failure     401 {object} map[string]interface{} "Unauthorized - Token tidak valid"
+// @Failure     500 {object} map[string]interface{}
+// @Router      /notifications [get]
+func (nh *NotificationHandler) GetNotifications(ctx *gin.Context) {
+	userID, err := utils.GetUserFromCtx(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "Unauthorized",
+		})
+		return
+	}
+
+	limit, cursor, err := utils.GetPaginationParams(ctx, utils.CursorNotifications)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid cursor",
+		})
+		return
+	}
+
+	notifications, next, err := nh.nr.GetNotifications(ctx.Request.Context(), userID, limit, cursor)
+	if err != nil {
+		log.Println("Error getting notifications:", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Internal Server Error",
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success":    true,
+		"data":       notifications,
+		"pagination": utils.NewPagination(limit, next),
+	})
+}
+
+// GetUnreadCount godoc
+// @Summary     Unread Notifications Count
+// @Description Jumlah notifikasi (yang sudah digabung) yang belum dibaca
+// @Tags        Notifications
+// @Produce     json
+// @Security    BearerAuth
+// @Success     200 {object} map[string]interface{}
+// @Failure     401 {object} map[string]interface{} "Unauthorized - Token tidak valid"
+// @Failure     500 {object} map[string]interface{}
+// @Router      /notifications/unread-count [get]
+func (nh *NotificationHandler) GetUnreadCount(ctx *gin.Context) {
+	userID, err := utils.GetUserFromCtx(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "Unauthorized",
+		})
+		return
+	}
+
+	count, err := nh.nr.GetUnreadCount(ctx.Request.Context(), userID)
+	if err != nil {
+		log.Println("Error counting notifications:", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Internal Server Error",
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    gin.H{"unread_count": count},
+	})
+}
+
+// MarkAsRead godoc
+// @Summary     Mark Notifications As Read
+// @Description Menandai notifikasi sudah dibaca. Tanpa body / ids kosong, semua notifikasi ditandai sudah dibaca.
+// @Description Event baru setelah notifikasi dibaca masuk ke notifikasi baru
+// @Tags        Notifications
+// @Accept      json
+// @Produce     json
+// @Security    BearerAuth
+// @Param       request body models.MarkNotificationsRequest false "Id notifikasi (maksimal 100)"
+// @Success     200 {object} map[string]interface{}
+// @Failure     400 {object} map[string]interface{} "Id notifikasi tidak valid"
+// @Failure     401 {object} map[string]interface{} "Unauthorized - Token tidak valid"
+// @Failure     500 {object} map[string]interface{}
+// @Router      /notifications/read [patch]
+func (nh *NotificationHandler) MarkAsRead(ctx *gin.Context) {
+	userID, err := utils.GetUserFromCtx(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "Unauthorized",
+		})
+		return
+	}
+
+	var req models.MarkNotificationsRequest
+	if ctx.Request.ContentLength != 0 {
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error":   "ids must be a list of at most 100 notification ids",
+			})
+			return
+		}
+	}
+
+	updated, err := nh.nr.MarkAsRead(ctx.Request.Context(), userID, req.Ids)
+	if err != nil {
+		log.Println("Error marking notifications as read:", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Internal Server Error",
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Notifications marked as read",
+		"data":    gin.H{"updated": updated},
+	})
+}
@@ -0,0 +1,309 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/raihaninkam/finalPhase3/internals/models"
+	"github.com/raihaninkam/finalPhase3/internals/utils"
+	"github.com/raihaninkam/finalPhase3/pkg"
+)
+
+// ForgotPassword godoc
+// @Summary     Lupa Password
+// @Description Kirim link reset password ke email. Response selalu sama walaupun email tidak terdaftar.
+// @Tags        Auth
+// @Accept      json
+// @Produce     json
+// @Param       body body models.ForgotPasswordRequest true "Forgot Password Request"
+// @Success     200 {object} map[string]interface{} "Link reset dikirim jika email terdaftar"
+// @Failure     400 {object} map[string]interface{} "Bad Request - Email tidak valid"
+// @Failure     500 {object} map[string]interface{} "Internal Server Error"
+// @Router      /auth/forgot-password [post]
+func (a *AuthHandler) ForgotPassword(ctx *gin.Context) {
+	var body models.ForgotPasswordRequest
+	if err := ctx.ShouldBind(&body); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Email harus diisi dengan format yang benar",
+		})
+		return
+	}
+
+	// response yang sama untuk email terdaftar maupun tidak, agar email user tidak bisa ditebak
+	response := gin.H{
+		"success": true,
+		"message": "Jika email terdaftar, link reset password sudah dikirim",
+	}
+
+	user, err := a.ar.GetEmail(ctx.Request.Context(), body.Email)
+	if err != nil {
+		if strings.Contains(err.Error(), "user not found") {
+			ctx.JSON(http.StatusOK, response)
+			return
+		}
+		log.Println("Internal Server Error.\nCause: ", err.Error())
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "internal server error",
+		})
+		return
+	}
+
+	token, err := a.tr.CreatePasswordResetToken(ctx.Request.Context(), user.ID)
+	if err != nil {
+		log.Println("Internal Server Error.\nCause: ", err.Error())
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "internal server error",
+		})
+		return
+	}
+
+	// kirim email di background agar waktu response tidak membocorkan email mana yang terdaftar
+	link := utils.AppURL("/reset-password?token=" + url.QueryEscape(token))
+	go func(to string) {
+		body := fmt.Sprintf("Klik link berikut untuk reset password anda (berlaku %d menit):\n\n%s\n\nAbaikan email ini jika anda tidak meminta reset password.",
+			int(pkg.PasswordResetTokenTTL.Minutes()), link)
+		if err := a.mailer.Send(to, "Reset Password", body); err != nil {
+			log.Println("Failed to send reset password email.\nCause: ", err.Error())
+		}
+	}(user.Email)
+
+	ctx.JSON(http.StatusOK, response)
+}
+
+// ResetPassword godoc
+// @Summary     Reset Password
+// @Description Ganti password menggunakan token dari email. Token hanya bisa dipakai sekali dan
+// @Description semua sesi login user akan dicabut.
+// @Tags        Auth
+// @Accept      json
+// @Produce     json
+// @Param       body body models.ResetPasswordRequest true "Reset Password Request"
+// @Success     200 {object} map[string]interface{} "Password berhasil direset"
+// @Failure     400 {object} map[string]interface{} "Bad Request - Token tidak valid atau password lemah"
+// @Failure     500 {object} map[string]interface{} "Internal Server Error"
+// @Router      /auth/reset-password [post]
+func (a *AuthHandler) ResetPassword(ctx *gin.Context) {
+	var body models.ResetPasswordRequest
+	if err := ctx.ShouldBind(&body); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Token dan password baru harus diisi",
+		})
+		return
+	}
+
+	if err := utils.PasswordValidation(body.NewPassword); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	hc := pkg.NewHashConfig()
+	hc.UseRecommended()
+	hashedPassword, err := hc.GenHash(body.NewPassword)
+	if err != nil {
+		log.Println("Internal Server Error.\nCause: ", err.Error())
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "internal server error",
+		})
+		return
+	}
+
+	userID, err := a.tr.ResetPassword(ctx.Request.Context(), body.Token, hashedPassword)
+	if err != nil {
+		if strings.Contains(err.Error(), "reset token invalid") {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error":   "Token reset password tidak valid atau sudah expired",
+			})
+			return
+		}
+		log.Println("Internal Server Error.\nCause: ", err.Error())
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "internal server error",
+		})
+		return
+	}
+
+	// password sudah berubah, buka kunci akun jika sebelumnya terkunci karena gagal login
+	if user, err := a.ar.GetUserByID(ctx.Request.Context(), userID); err == nil {
+		if err := a.la.ResetAccount(ctx.Request.Context(), user.Email); err != nil {
+			log.Println("Failed to reset login attempts.\nCause: ", err.Error())
+		}
+	}
+
+	// cabut semua sesi yang masih login
+	if err := a.revokeAllUserTokens(ctx, userID); err != nil {
+		log.Println("Internal Server Error.\nCause: ", err.Error())
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "internal server error",
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Password berhasil direset, silahkan login kembali",
+	})
+}
+
+// ChangePassword godoc
+// @Summary     Ganti Password
+// @Description Ganti password dengan menyertakan password saat ini. Semua sesi lain milik user akan dicabut.
+// @Tags        Auth
+// @Accept      json
+// @Produce     json
+// @Security    BearerAuth
+// @Param       body body models.ChangePasswordRequest true "Change Password Request"
+// @Success     200 {object} map[string]interface{} "Password berhasil diganti"
+// @Failure     400 {object} map[string]interface{} "Bad Request - Password saat ini salah atau password baru lemah"
+// @Failure     401 {object} map[string]interface{} "Unauthorized - Token tidak valid"
+// @Failure     423 {object} map[string]interface{} "Locked - Akun dikunci sementara (lihat header Retry-After)"
+// @Failure     429 {object} map[string]interface{} "Too Many Requests - IP dikunci sementara (lihat header Retry-After)"
+// @Failure     500 {object} map[string]interface{} "Internal Server Error"
+// @Router      /auth/password [patch]
+func (a *AuthHandler) ChangePassword(ctx *gin.Context) {
+	claims, err := utils.GetClaimsFromCtx(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "Unauthorized",
+		})
+		return
+	}
+
+	var body models.ChangePasswordRequest
+	if err := ctx.ShouldBind(&body); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Password saat ini dan password baru harus diisi",
+		})
+		return
+	}
+
+	if err := utils.PasswordValidation(body.NewPassword); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	user, err := a.ar.GetUserByID(ctx.Request.Context(), claims.UserId)
+	if err != nil {
+		if strings.Contains(err.Error(), "user not found") {
+			ctx.JSON(http.StatusUnauthorized, gin.H{
+				"success": false,
+				"error":   "Unauthorized",
+			})
+			return
+		}
+		log.Println("Internal Server Error.\nCause: ", err.Error())
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "internal server error",
+		})
+		return
+	}
+
+	// token yang dicuri tidak boleh dipakai untuk menebak password, jadi batas gagal login juga berlaku di sini
+	if a.rejectLockedLogin(ctx, user.Email) {
+		return
+	}
+
+	// pastikan password saat ini benar
+	hc := pkg.NewHashConfig()
+	isMatched, err := hc.CompareHashAndPassword(body.CurrentPassword, user.Password)
+	if err != nil {
+		log.Println("Internal Server Error.\nCause: ", err.Error())
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "internal server error",
+		})
+		return
+	}
+	if !isMatched {
+		a.registerLoginFailure(ctx, user.Email, user)
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Password saat ini salah",
+		})
+		return
+	}
+	if err := a.la.ResetAccount(ctx.Request.Context(), user.Email); err != nil {
+		log.Println("Failed to reset login attempts.\nCause: ", err.Error())
+	}
+
+	hc = pkg.NewHashConfig()
+	hc.UseRecommended()
+	hashedPassword, err := hc.GenHash(body.NewPassword)
+	if err != nil {
+		log.Println("Internal Server Error.\nCause: ", err.Error())
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "internal server error",
+		})
+		return
+	}
+
+	if err := a.ar.UpdatePassword(ctx.Request.Context(), user.ID, hashedPassword); err != nil {
+		log.Println("Internal Server Error.\nCause: ", err.Error())
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "internal server error",
+		})
+		return
+	}
+
+	// token lama tanpa sesi tidak bisa dibedakan dari token lain, jadi semua token dicabut
+	if claims.ID == "" {
+		if err := a.revokeAllUserTokens(ctx, user.ID); err != nil {
+			log.Println("Internal Server Error.\nCause: ", err.Error())
+			ctx.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"error":   "internal server error",
+			})
+			return
+		}
+		ctx.JSON(http.StatusOK, gin.H{
+			"success": true,
+			"message": "Password berhasil diganti, silahkan login kembali",
+		})
+		return
+	}
+
+	// cabut semua sesi lain, sesi saat ini tetap login
+	if err := a.sr.RevokeUserSessions(ctx.Request.Context(), user.ID, claims.ID); err != nil {
+		log.Println("Internal Server Error.\nCause: ", err.Error())
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "internal server error",
+		})
+		return
+	}
+	if err := a.tr.RevokeUserRefreshTokens(ctx.Request.Context(), user.ID, claims.ID); err != nil {
+		log.Println("Internal Server Error.\nCause: ", err.Error())
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "internal server error",
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Password berhasil diganti, sesi di device lain sudah dicabut",
+	})
+}
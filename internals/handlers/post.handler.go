@@ -1,9 +1,10 @@
 package handlers
 
 import (
+	"context"
 	"log"
 	"net/http"
-	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/raihaninkam/finalPhase3/internals/models"
@@ -13,10 +14,11 @@ import (
 
 type PostHandler struct {
 	pr *repositories.PostRepository
+	tr *repositories.TimelineRepository
 }
 
-func NewPostHandler(pr *repositories.PostRepository) *PostHandler {
-	return &PostHandler{pr: pr}
+func NewPostHandler(pr *repositories.PostRepository, tr *repositories.TimelineRepository) *PostHandler {
+	return &PostHandler{pr: pr, tr: tr}
 }
 
 func (ph *PostHandler) CreatePost(ctx *gin.Context) {
@@ -72,6 +74,13 @@ func (ph *PostHandler) CreatePost(ctx *gin.Context) {
 		return
 	}
 
+	// kirim post ke timeline follower di background, follower dengan banyak user tidak perlu ditunggu
+	go func(post models.Posts) {
+		if err := ph.tr.FanOut(context.Background(), post.UserId, post.Id, *post.CreatedAt); err != nil {
+			log.Println("Failed to fan out post.\nCause: ", err.Error())
+		}
+	}(*newPost)
+
 	ctx.JSON(http.StatusCreated, gin.H{
 		"success": true,
 		"data":    newPost,
@@ -88,23 +97,16 @@ func (ph *PostHandler) GetFeed(ctx *gin.Context) {
 		return
 	}
 
-	// Get pagination params
-	limit := 20
-	offset := 0
-
-	if l := ctx.Query("limit"); l != "" {
-		if parsedLimit, err := strconv.Atoi(l); err == nil && parsedLimit > 0 {
-			limit = parsedLimit
-		}
-	}
-
-	if o := ctx.Query("offset"); o != "" {
-		if parsedOffset, err := strconv.Atoi(o); err == nil && parsedOffset >= 0 {
-			offset = parsedOffset
-		}
+	limit, cursor, err := utils.GetPaginationParams(ctx, utils.CursorFeed)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid cursor",
+		})
+		return
 	}
 
-	posts, err := ph.pr.GetFollowingPosts(ctx, userID, limit, offset)
+	posts, next, err := ph.tr.GetTimeline(ctx.Request.Context(), userID, limit, cursor)
 	if err != nil {
 		log.Println("Error getting feed:", err)
 		ctx.JSON(http.StatusInternalServerError, gin.H{
@@ -115,8 +117,9 @@ func (ph *PostHandler) GetFeed(ctx *gin.Context) {
 	}
 
 	ctx.JSON(http.StatusOK, gin.H{
-		"success": true,
-		"data":    posts,
+		"success":    true,
+		"data":       posts,
+		"pagination": utils.NewPagination(limit, next),
 	})
 }
 
@@ -126,19 +129,88 @@ func (ph *PostHandler) GetFeed(ctx *gin.Context) {
 // @Tags        Posts
 // @Accept      json
 // @Produce     json
-// @Param       page query int false "Page number" default(1)
-// @Param       limit query int false "Items per page" default(10)
+// @Param       cursor query string false "Cursor dari pagination.next_cursor"
+// @Param       limit query int false "Items per page" default(20)
 // @Success     200 {object} map[string]interface{}
+// @Failure     400 {object} map[string]interface{} "Cursor tidak valid"
 // @Failure     500 {object} map[string]interface{}
-// @Router      /posts/popular [get]
+// @Router      /post/popular [get]
 func (ph *PostHandler) GetPopularPosts(ctx *gin.Context) {
-	// Pagination
-	page, _ := strconv.Atoi(ctx.DefaultQuery("page", "1"))
-	limit, _ := strconv.Atoi(ctx.DefaultQuery("limit", "10"))
-	offset := (page - 1) * limit
+	userID, err := utils.GetUserFromCtx(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "Unauthorized",
+		})
+		return
+	}
+
+	limit, cursor, err := utils.GetPaginationParams(ctx, utils.CursorPopular)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid cursor",
+		})
+		return
+	}
+
+	posts, next, err := ph.pr.GetPopularPosts(ctx.Request.Context(), userID, limit, cursor)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Internal Server Error",
+		})
+		return
+	}
 
-	posts, err := ph.pr.GetPopularPosts(ctx.Request.Context(), limit, offset)
+	ctx.JSON(http.StatusOK, gin.H{
+		"success":    true,
+		"data":       posts,
+		"pagination": utils.NewPagination(limit, next),
+	})
+}
+
+// GetPostDetail godoc
+// @Summary     Get Post Detail
+// @Description Mendapatkan satu post beserta author, jumlah like, jumlah comment, is_liked dan is_bookmarked untuk user yang login
+// @Tags        Posts
+// @Produce     json
+// @Security    BearerAuth
+// @Param       id path string true "Post ID"
+// @Success     200 {object} map[string]interface{}
+// @Failure     401 {object} map[string]interface{} "Unauthorized - Token tidak valid"
+// @Failure     404 {object} map[string]interface{} "Post tidak ditemukan"
+// @Failure     500 {object} map[string]interface{}
+// @Router      /post/{id} [get]
+func (ph *PostHandler) GetPostDetail(ctx *gin.Context) {
+	userID, err := utils.GetUserFromCtx(ctx)
 	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "Unauthorized",
+		})
+		return
+	}
+
+	postID := ctx.Param("id")
+	if !utils.IsUUID(postID) {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   "Post not found",
+		})
+		return
+	}
+
+	post, err := ph.pr.GetPostByID(ctx.Request.Context(), postID, userID)
+	if err != nil {
+		if strings.Contains(err.Error(), "post not found") {
+			ctx.JSON(http.StatusNotFound, gin.H{
+				"success": false,
+				"error":   "Post not found",
+			})
+			return
+		}
+		log.Println("Error getting post:", err)
 		ctx.JSON(http.StatusInternalServerError, gin.H{
 			"success": false,
 			"error":   "Internal Server Error",
@@ -148,10 +220,350 @@ func (ph *PostHandler) GetPopularPosts(ctx *gin.Context) {
 
 	ctx.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"data":    posts,
-		"pagination": gin.H{
-			"page":  page,
-			"limit": limit,
+		"data":    post,
+	})
+}
+
+// BookmarkPost godoc
+// @Summary     Bookmark Post
+// @Description Menyimpan post ke bookmark user
+// @Tags        Posts
+// @Produce     json
+// @Security    BearerAuth
+// @Param       id path string true "Post ID"
+// @Success     200 {object} map[string]interface{}
+// @Failure     401 {object} map[string]interface{} "Unauthorized - Token tidak valid"
+// @Failure     404 {object} map[string]interface{} "Post tidak ditemukan"
+// @Failure     500 {object} map[string]interface{}
+// @Router      /post/{id}/bookmark [post]
+func (ph *PostHandler) BookmarkPost(ctx *gin.Context) {
+	userID, err := utils.GetUserFromCtx(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "Unauthorized",
+		})
+		return
+	}
+
+	postID := ctx.Param("id")
+	if !utils.IsUUID(postID) {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   "Post not found",
+		})
+		return
+	}
+
+	if err := ph.pr.BookmarkPost(ctx.Request.Context(), userID, postID); err != nil {
+		if strings.Contains(err.Error(), "post not found") {
+			ctx.JSON(http.StatusNotFound, gin.H{
+				"success": false,
+				"error":   "Post not found",
+			})
+			return
+		}
+		log.Println("Error bookmarking post:", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Internal Server Error",
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Post bookmarked successfully",
+	})
+}
+
+// UnbookmarkPost godoc
+// @Summary     Unbookmark Post
+// @Description Menghapus post dari bookmark user
+// @Tags        Posts
+// @Produce     json
+// @Security    BearerAuth
+// @Param       id path string true "Post ID"
+// @Success     200 {object} map[string]interface{}
+// @Failure     401 {object} map[string]interface{} "Unauthorized - Token tidak valid"
+// @Failure     404 {object} map[string]interface{} "Bookmark tidak ditemukan"
+// @Failure     500 {object} map[string]interface{}
+// @Router      /post/{id}/bookmark [delete]
+func (ph *PostHandler) UnbookmarkPost(ctx *gin.Context) {
+	userID, err := utils.GetUserFromCtx(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "Unauthorized",
+		})
+		return
+	}
+
+	postID := ctx.Param("id")
+	if !utils.IsUUID(postID) {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   "Bookmark not found",
+		})
+		return
+	}
+
+	if err := ph.pr.UnbookmarkPost(ctx.Request.Context(), userID, postID); err != nil {
+		if strings.Contains(err.Error(), "bookmark not found") {
+			ctx.JSON(http.StatusNotFound, gin.H{
+				"success": false,
+				"error":   "Bookmark not found",
+			})
+			return
+		}
+		log.Println("Error unbookmarking post:", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Internal Server Error",
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Post unbookmarked successfully",
+	})
+}
+
+// canModeratePosts menentukan apakah user boleh mengubah / menghapus post milik orang lain
+func canModeratePosts(ctx *gin.Context) bool {
+	claims, err := utils.GetClaimsFromCtx(ctx)
+	if err != nil {
+		return false
+	}
+	return claims.Role == models.RoleModerator || claims.Role == models.RoleAdmin
+}
+
+// UpdatePost godoc
+// @Summary     Update Post
+// @Description Mengubah isi dan/atau gambar post. Hanya pemilik post atau moderator/admin. Versi sebelumnya disimpan di riwayat edit.
+// @Tags        Posts
+// @Accept      multipart/form-data
+// @Produce     json
+// @Security    BearerAuth
+// @Param       id           path     string true  "Post ID"
+// @Param       content_text formData string false "Isi post"
+// @Param       image        formData file   false "Gambar post"
+// @Success     200 {object} map[string]interface{}
+// @Failure     400 {object} map[string]interface{} "Tidak ada perubahan / post menjadi kosong"
+// @Failure     401 {object} map[string]interface{} "Unauthorized - Token tidak valid"
+// @Failure     403 {object} map[string]interface{} "Bukan pemilik post"
+// @Failure     404 {object} map[string]interface{} "Post tidak ditemukan"
+// @Failure     500 {object} map[string]interface{}
+// @Router      /post/{id} [patch]
+func (ph *PostHandler) UpdatePost(ctx *gin.Context) {
+	userID, err := utils.GetUserFromCtx(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "Unauthorized",
+		})
+		return
+	}
+
+	postID := ctx.Param("id")
+	if !utils.IsUUID(postID) {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   "Post not found",
+		})
+		return
+	}
+
+	var update models.PostsRequest
+	if content, ok := ctx.GetPostForm("content_text"); ok {
+		update.Content = &content
+	}
+
+	// gambar baru (opsional)
+	if file, err := ctx.FormFile("image"); err == nil {
+		uploadedFile, err := utils.FileUpload(ctx, file, "post")
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error":   err.Error(),
+			})
+			return
+		}
+		imageUrl := "/public/" + uploadedFile
+		update.ImageUrl = &imageUrl
+	}
+
+	if update.Content == nil && update.ImageUrl == nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Content or image is required",
+		})
+		return
+	}
+
+	post, err := ph.pr.UpdatePost(ctx.Request.Context(), postID, userID, canModeratePosts(ctx), &update)
+	if err != nil {
+		// gambar baru tidak jadi dipakai
+		if update.ImageUrl != nil {
+			utils.RemoveUploadedFile(*update.ImageUrl)
+		}
+		switch {
+		case strings.Contains(err.Error(), "post not found"):
+			ctx.JSON(http.StatusNotFound, gin.H{
+				"success": false,
+				"error":   "Post not found",
+			})
+		case strings.Contains(err.Error(), "not the post author"):
+			ctx.JSON(http.StatusForbidden, gin.H{
+				"success": false,
+				"error":   "You can only edit your own post",
+			})
+		case strings.Contains(err.Error(), "content or image is required"):
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error":   "Content or image is required",
+			})
+		default:
+			log.Println("Error updating post:", err)
+			ctx.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"error":   "Internal Server Error",
+			})
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": models.PostWithUser{
+			Id:        post.Id,
+			UserId:    post.UserId,
+			Content:   post.Content,
+			ImageUrl:  post.ImageUrl,
+			CreatedAt: *post.CreatedAt,
+			EditedAt:  post.EditedAt,
+			IsEdited:  post.IsEdited,
 		},
 	})
 }
+
+// DeletePost godoc
+// @Summary     Delete Post
+// @Description Menghapus post beserta like, komentar, bookmark, dan riwayat edit-nya. Hanya pemilik post atau moderator/admin.
+// @Tags        Posts
+// @Produce     json
+// @Security    BearerAuth
+// @Param       id path string true "Post ID"
+// @Success     200 {object} map[string]interface{}
+// @Failure     401 {object} map[string]interface{} "Unauthorized - Token tidak valid"
+// @Failure     403 {object} map[string]interface{} "Bukan pemilik post"
+// @Failure     404 {object} map[string]interface{} "Post tidak ditemukan"
+// @Failure     500 {object} map[string]interface{}
+// @Router      /post/{id} [delete]
+func (ph *PostHandler) DeletePost(ctx *gin.Context) {
+	userID, err := utils.GetUserFromCtx(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "Unauthorized",
+		})
+		return
+	}
+
+	postID := ctx.Param("id")
+	if !utils.IsUUID(postID) {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   "Post not found",
+		})
+		return
+	}
+
+	images, err := ph.pr.DeletePost(ctx.Request.Context(), postID, userID, canModeratePosts(ctx))
+	if err != nil {
+		switch {
+		case strings.Contains(err.Error(), "post not found"):
+			ctx.JSON(http.StatusNotFound, gin.H{
+				"success": false,
+				"error":   "Post not found",
+			})
+		case strings.Contains(err.Error(), "not the post author"):
+			ctx.JSON(http.StatusForbidden, gin.H{
+				"success": false,
+				"error":   "You can only delete your own post",
+			})
+		default:
+			log.Println("Error deleting post:", err)
+			ctx.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"error":   "Internal Server Error",
+			})
+		}
+		return
+	}
+
+	// hapus file gambar post dan revisinya
+	for _, image := range images {
+		utils.RemoveUploadedFile(image)
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Post deleted successfully",
+	})
+}
+
+// GetPostHistory godoc
+// @Summary     Get Post Edit History
+// @Description Menampilkan versi-versi sebelumnya dari post yang sudah diedit, terbaru lebih dulu
+// @Tags        Posts
+// @Produce     json
+// @Security    BearerAuth
+// @Param       id path string true "Post ID"
+// @Success     200 {object} map[string]interface{}
+// @Failure     401 {object} map[string]interface{} "Unauthorized - Token tidak valid"
+// @Failure     404 {object} map[string]interface{} "Post tidak ditemukan"
+// @Failure     500 {object} map[string]interface{}
+// @Router      /post/{id}/history [get]
+func (ph *PostHandler) GetPostHistory(ctx *gin.Context) {
+	userID, err := utils.GetUserFromCtx(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "Unauthorized",
+		})
+		return
+	}
+
+	postID := ctx.Param("id")
+	if !utils.IsUUID(postID) {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   "Post not found",
+		})
+		return
+	}
+
+	revisions, err := ph.pr.GetPostHistory(ctx.Request.Context(), postID, userID)
+	if err != nil {
+		if strings.Contains(err.Error(), "post not found") {
+			ctx.JSON(http.StatusNotFound, gin.H{
+				"success": false,
+				"error":   "Post not found",
+			})
+			return
+		}
+		log.Println("Error getting post history:", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Internal Server Error",
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    revisions,
+	})
+}
@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/raihaninkam/finalPhase3/internals/repositories"
+	"github.com/raihaninkam/finalPhase3/internals/utils"
+)
+
+type SuggestionHandler struct {
+	sr *repositories.SuggestionRepository
+}
+
+func NewSuggestionHandler(sr *repositories.SuggestionRepository) *SuggestionHandler {
+	return &SuggestionHandler{sr: sr}
+}
+
+// GetUserSuggestions godoc
+// @Summary     Who To Follow
+// @Description Rekomendasi akun untuk di-follow, diurutkan berdasarkan jumlah akun yang di-follow user yang juga
+// @Description mem-follow akun tsb (friends-of-friends), follower yang belum di-follow balik dan aktivitas posting terbaru.
+// @Description Rekomendasi dihitung ulang setiap jam dan disertai alasan, misalnya "followed by budi and 3 others"
+// @Tags        Follows
+// @Produce     json
+// @Security    BearerAuth
+// @Param       limit query int false "Jumlah rekomendasi" default(20)
+// @Success     200 {object} map[string]interface{}
+// @Failure     401 {object} map[string]interface{} "Unauthorized - Token tidak valid"
+// @Failure     500 {object} map[string]interface{}
+// @Router      /suggestions/users [get]
+func (sh *SuggestionHandler) GetUserSuggestions(ctx *gin.Context) {
+	userID, err := utils.GetUserFromCtx(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "Unauthorized",
+		})
+		return
+	}
+
+	limit := utils.DefaultPageLimit
+	if l, err := strconv.Atoi(ctx.Query("limit")); err == nil && l > 0 {
+		limit = min(l, utils.MaxPageLimit)
+	}
+
+	suggestions, err := sh.sr.GetSuggestions(ctx.Request.Context(), userID, limit)
+	if err != nil {
+		log.Println("Error getting suggestions:", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Internal Server Error",
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    suggestions,
+	})
+}
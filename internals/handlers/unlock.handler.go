@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/raihaninkam/finalPhase3/internals/models"
+	"github.com/raihaninkam/finalPhase3/internals/utils"
+	"github.com/raihaninkam/finalPhase3/pkg"
+)
+
+const (
+	unlockAccountPurpose = "unlock-account"
+	unlockAccountTTL     = time.Hour
+)
+
+// UnlockAccount godoc
+// @Summary     Buka Kunci Akun
+// @Description Buka kunci akun yang terkunci karena terlalu banyak gagal login, menggunakan token dari email.
+// @Tags        Auth
+// @Accept      json
+// @Produce     json
+// @Param       body body models.UnlockAccountRequest true "Unlock Account Request"
+// @Success     200 {object} map[string]interface{} "Akun berhasil dibuka"
+// @Failure     400 {object} map[string]interface{} "Bad Request - Token tidak valid atau expired"
+// @Failure     500 {object} map[string]interface{} "Internal Server Error"
+// @Router      /auth/unlock [post]
+func (a *AuthHandler) UnlockAccount(ctx *gin.Context) {
+	var body models.UnlockAccountRequest
+	if err := ctx.ShouldBind(&body); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Token harus diisi",
+		})
+		return
+	}
+
+	var claims pkg.ActionClaims
+	if err := claims.VerifyToken(body.Token, unlockAccountPurpose); err != nil {
+		log.Println("Invalid unlock token.\nCause: ", err.Error())
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Link unlock tidak valid atau sudah expired",
+		})
+		return
+	}
+
+	if err := a.la.ResetAccount(ctx.Request.Context(), claims.Email); err != nil {
+		log.Println("Internal Server Error.\nCause: ", err.Error())
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "internal server error",
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Akun berhasil dibuka, silahkan login kembali",
+	})
+}
+
+// sendUnlockEmail memberi tahu user bahwa akunnya dikunci beserta link untuk membukanya
+func (a *AuthHandler) sendUnlockEmail(userID, email string, lockDuration time.Duration) {
+	claims := pkg.NewActionClaims(userID, strings.ToLower(email), unlockAccountPurpose, unlockAccountTTL)
+	token, err := claims.GenToken()
+	if err != nil {
+		log.Println("Failed to generate unlock token.\nCause: ", err.Error())
+		return
+	}
+
+	link := utils.AppURL("/unlock-account?token=" + url.QueryEscape(token))
+	go func() {
+		body := fmt.Sprintf("Akun anda dikunci selama %s karena terlalu banyak percobaan login yang gagal.\n\n"+
+			"Jika itu memang anda, klik link berikut untuk membuka kunci akun:\n\n%s\n\n"+
+			"Jika bukan anda, segera reset password anda.", lockDuration, link)
+		if err := a.mailer.Send(email, "Akun Dikunci Sementara", body); err != nil {
+			log.Println("Failed to send unlock email.\nCause: ", err.Error())
+		}
+	}()
+}
@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/raihaninkam/finalPhase3/internals/repositories"
+	"github.com/raihaninkam/finalPhase3/internals/utils"
+)
+
+type UserHandler struct {
+	ur *repositories.UserRepository
+}
+
+func NewUserHandler(ur *repositories.UserRepository) *UserHandler {
+	return &UserHandler{ur: ur}
+}
+
+// GetProfile godoc
+// @Summary     Get User Profile
+// @Description Menampilkan profil publik user berdasarkan username (atau id), jumlah follower / following
+// @Description dan apakah user yang login mengikuti user tersebut.
+// @Tags        Users
+// @Produce     json
+// @Security    BearerAuth
+// @Param       username path string true "Username atau User ID"
+// @Success     200 {object} map[string]interface{}
+// @Failure     401 {object} map[string]interface{} "Unauthorized - Token tidak valid"
+// @Failure     404 {object} map[string]interface{} "User tidak ditemukan"
+// @Failure     500 {object} map[string]interface{}
+// @Router      /users/{username} [get]
+func (uh *UserHandler) GetProfile(ctx *gin.Context) {
+	viewerID, err := utils.GetUserFromCtx(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "Unauthorized",
+		})
+		return
+	}
+
+	username := ctx.Param("username")
+	if !utils.IsUUID(username) {
+		username = utils.NormalizeUsername(username)
+	}
+
+	profile, err := uh.ur.GetProfile(ctx.Request.Context(), username, viewerID)
+	if err != nil {
+		if strings.Contains(err.Error(), "user not found") {
+			ctx.JSON(http.StatusNotFound, gin.H{
+				"success": false,
+				"error":   "User tidak ditemukan",
+			})
+			return
+		}
+		log.Println("Internal Server Error.\nCause: ", err.Error())
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "internal server error",
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    profile,
+	})
+}
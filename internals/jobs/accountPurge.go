@@ -0,0 +1,81 @@
+package jobs
+
+import (
+	"context"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/raihaninkam/finalPhase3/internals/repositories"
+	"github.com/raihaninkam/finalPhase3/internals/utils"
+)
+
+const (
+	// accountPurgeInterval adalah jarak antar pengecekan akun yang sudah jatuh tempo
+	accountPurgeInterval = 10 * time.Minute
+	// accountPurgeBatch adalah jumlah akun maksimal yang dihapus dalam satu kali jalan
+	accountPurgeBatch = 50
+)
+
+// AccountPurger menghapus permanen akun yang jadwal penghapusannya (DELETE /auth/account) sudah lewat
+type AccountPurger struct {
+	acr *repositories.AccountRepository
+}
+
+func NewAccountPurger(acr *repositories.AccountRepository) *AccountPurger {
+	return &AccountPurger{acr: acr}
+}
+
+// Start menjalankan purge secara berkala sampai ctx dibatalkan
+func (ap *AccountPurger) Start(ctx context.Context) {
+	ticker := time.NewTicker(accountPurgeInterval)
+	defer ticker.Stop()
+
+	for {
+		ap.RunOnce(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce menghapus akun yang sudah jatuh tempo beserta file upload dan arsip export-nya
+func (ap *AccountPurger) RunOnce(ctx context.Context) {
+	userIDs, err := ap.acr.GetDueDeletions(ctx, accountPurgeBatch)
+	if err != nil {
+		log.Println("Failed to get accounts to purge.\nCause: ", err.Error())
+		return
+	}
+
+	for _, userID := range userIDs {
+		files, deleted, err := ap.acr.PurgeUser(ctx, userID)
+		if err != nil {
+			log.Println("Failed to purge account.\nCause: ", err.Error())
+			continue
+		}
+		if !deleted {
+			continue
+		}
+		for _, file := range files {
+			removeLocalFile(file)
+		}
+		log.Printf("account %s purged\n", userID)
+	}
+}
+
+// removeLocalFile menghapus file hasil utils.FileUpload ("/public/<nama file>") atau arsip export
+// ("exports/<nama file>"). Url eksternal diabaikan
+func removeLocalFile(path string) {
+	if !strings.HasPrefix(path, ExportDir+"/") {
+		utils.RemoveUploadedFile(path)
+		return
+	}
+	location := filepath.Join(ExportDir, filepath.Base(path))
+	if err := os.Remove(location); err != nil && !os.IsNotExist(err) {
+		log.Println("Failed to remove uploaded file.\nCause: ", err.Error())
+	}
+}
@@ -0,0 +1,169 @@
+package jobs
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/raihaninkam/finalPhase3/internals/models"
+	"github.com/raihaninkam/finalPhase3/internals/repositories"
+)
+
+const (
+	// ExportDir adalah folder arsip export, sengaja di luar public agar tidak bisa diakses tanpa login
+	ExportDir = "exports"
+	// exportTTL adalah lama arsip export bisa didownload sebelum dihapus
+	exportTTL = 7 * 24 * time.Hour
+	// exportPollInterval adalah jarak antar pengecekan export baru
+	exportPollInterval = 15 * time.Second
+	// exportStaleAfter adalah batas export dianggap macet di status processing
+	exportStaleAfter = 30 * time.Minute
+)
+
+// DataExporter memproses permintaan export data (POST /me/export) menjadi arsip ZIP
+type DataExporter struct {
+	er *repositories.ExportRepository
+}
+
+func NewDataExporter(er *repositories.ExportRepository) *DataExporter {
+	return &DataExporter{er: er}
+}
+
+// Start memproses export secara berkala sampai ctx dibatalkan
+func (de *DataExporter) Start(ctx context.Context) {
+	ticker := time.NewTicker(exportPollInterval)
+	defer ticker.Stop()
+
+	for {
+		de.RunOnce(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce memproses semua export pending lalu menghapus arsip yang sudah expired
+func (de *DataExporter) RunOnce(ctx context.Context) {
+	if err := de.er.RequeueStaleExports(ctx, exportStaleAfter); err != nil {
+		log.Println(err.Error())
+	}
+
+	for {
+		export, err := de.er.ClaimPendingExport(ctx)
+		if err != nil {
+			log.Println(err.Error())
+			break
+		}
+		if export == nil {
+			break
+		}
+
+		filePath, err := de.buildArchive(ctx, export)
+		if err != nil {
+			log.Println("Failed to build data export.\nCause: ", err.Error())
+			if err := de.er.FailExport(ctx, export.Id, "failed to build archive"); err != nil {
+				log.Println(err.Error())
+			}
+			continue
+		}
+		if err := de.er.CompleteExport(ctx, export.Id, filePath, exportTTL); err != nil {
+			log.Println(err.Error())
+		}
+	}
+
+	files, err := de.er.ExpireExports(ctx)
+	if err != nil {
+		log.Println(err.Error())
+		return
+	}
+	for _, file := range files {
+		removeLocalFile(file)
+	}
+}
+
+// buildArchive menulis data user ke <ExportDir>/<id>.zip berisi <section>.json dan folder media
+func (de *DataExporter) buildArchive(ctx context.Context, export *models.DataExport) (string, error) {
+	data, err := de.er.GetExportData(ctx, export.UserId)
+	if err != nil {
+		return "", err
+	}
+
+	if err := os.MkdirAll(ExportDir, 0o750); err != nil {
+		return "", err
+	}
+	location := filepath.Join(ExportDir, export.Id+".zip")
+	// tulis ke file sementara agar file yang belum selesai tidak pernah terdownload
+	tmp := location + ".tmp"
+	file, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o640)
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmp)
+
+	archive := zip.NewWriter(file)
+	if err := writeArchive(archive, data); err != nil {
+		file.Close()
+		return "", err
+	}
+	if err := archive.Close(); err != nil {
+		file.Close()
+		return "", err
+	}
+	if err := file.Close(); err != nil {
+		return "", err
+	}
+	if err := os.Rename(tmp, location); err != nil {
+		return "", err
+	}
+	return location, nil
+}
+
+func writeArchive(archive *zip.Writer, data *models.ExportData) error {
+	for name, section := range data.Sections {
+		var pretty bytes.Buffer
+		if err := json.Indent(&pretty, section, "", "  "); err != nil {
+			return fmt.Errorf("invalid json for %s: %w", name, err)
+		}
+		w, err := archive.Create(name + ".json")
+		if err != nil {
+			return err
+		}
+		if _, err := w.Write(pretty.Bytes()); err != nil {
+			return err
+		}
+	}
+
+	// hanya file yang diupload ke server ini (/public/...), url eksternal sudah tercatat di json
+	for _, url := range data.Media {
+		if !strings.HasPrefix(url, "/public/") {
+			continue
+		}
+		name := filepath.Base(url)
+		src, err := os.Open(filepath.Join("public", name))
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return err
+		}
+		w, err := archive.Create("media/" + name)
+		if err == nil {
+			_, err = io.Copy(w, src)
+		}
+		src.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package jobs
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/raihaninkam/finalPhase3/internals/repositories"
+)
+
+const (
+	// suggestionInterval adalah jarak antar perhitungan ulang rekomendasi akun
+	suggestionInterval = time.Hour
+	// suggestionBatch adalah jumlah user yang diambil per query saat menghitung ulang rekomendasi
+	suggestionBatch = 200
+)
+
+// SuggestionBuilder menghitung ulang rekomendasi akun (GET /suggestions/users) untuk user yang aktif
+// dan menyimpannya ke redis, sehingga endpoint tidak perlu menjalankan query graph saat diminta
+type SuggestionBuilder struct {
+	sr *repositories.SuggestionRepository
+}
+
+func NewSuggestionBuilder(sr *repositories.SuggestionRepository) *SuggestionBuilder {
+	return &SuggestionBuilder{sr: sr}
+}
+
+// Start menghitung ulang rekomendasi secara berkala sampai ctx dibatalkan
+func (sb *SuggestionBuilder) Start(ctx context.Context) {
+	ticker := time.NewTicker(suggestionInterval)
+	defer ticker.Stop()
+
+	for {
+		sb.RunOnce(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce menghitung ulang rekomendasi semua user yang aktif, per batch
+func (sb *SuggestionBuilder) RunOnce(ctx context.Context) {
+	var afterID *string
+	refreshed := 0
+	for {
+		userIDs, err := sb.sr.GetActiveUserIDs(ctx, afterID, suggestionBatch)
+		if err != nil {
+			log.Println("Failed to get users for suggestions.\nCause: ", err.Error())
+			return
+		}
+
+		for _, userID := range userIDs {
+			if ctx.Err() != nil {
+				return
+			}
+			if _, err := sb.sr.RefreshSuggestions(ctx, userID); err != nil {
+				log.Println("Failed to refresh suggestions.\nCause: ", err.Error())
+				continue
+			}
+			refreshed++
+		}
+
+		if len(userIDs) < suggestionBatch {
+			break
+		}
+		afterID = &userIDs[len(userIDs)-1]
+	}
+	if refreshed > 0 {
+		log.Printf("suggestions refreshed for %d users\n", refreshed)
+	}
+}
@@ -0,0 +1,33 @@
+package middleware
+
+import (
+	"net/http"
+	"slices"
+
+	"github.com/gin-gonic/gin"
+	"github.com/raihaninkam/finalPhase3/internals/utils"
+)
+
+// RequireRole hanya meneruskan request jika role pada token termasuk salah satu roles.
+// Dipasang setelah VerifyToken
+func RequireRole(roles ...string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		claims, err := utils.GetClaimsFromCtx(ctx)
+		if err != nil {
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+				"success": false,
+				"error":   "Unauthorized",
+			})
+			return
+		}
+
+		if !slices.Contains(roles, claims.Role) {
+			ctx.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"success": false,
+				"error":   "Anda tidak memiliki akses",
+			})
+			return
+		}
+		ctx.Next()
+	}
+}
@@ -0,0 +1,51 @@
+package middleware
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/raihaninkam/finalPhase3/internals/configs"
+	"github.com/raihaninkam/finalPhase3/internals/utils"
+)
+
+// RequireVerifiedEmail menolak user yang belum verifikasi email jika EMAIL_VERIFICATION=post.
+// Dipasang setelah VerifyToken
+func RequireVerifiedEmail(db *pgxpool.Pool) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if configs.EmailVerificationMode() != configs.EmailVerificationPost {
+			ctx.Next()
+			return
+		}
+
+		userID, err := utils.GetUserFromCtx(ctx)
+		if err != nil {
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+				"success": false,
+				"error":   "Unauthorized",
+			})
+			return
+		}
+
+		var isVerified bool
+		sql := `SELECT email_verified_at IS NOT NULL FROM users WHERE id = $1`
+		if err := db.QueryRow(ctx.Request.Context(), sql, userID).Scan(&isVerified); err != nil {
+			log.Println("Error when checking email verification:", err)
+			ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"error":   "Internal Server Error",
+			})
+			return
+		}
+
+		if !isVerified {
+			ctx.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"success": false,
+				"error":   "Silahkan verifikasi email terlebih dahulu",
+			})
+			return
+		}
+		ctx.Next()
+	}
+}
@@ -1,13 +1,14 @@
 package middleware
 
 import (
-	"fmt"
 	"log"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/raihaninkam/finalPhase3/internals/utils"
 	"github.com/raihaninkam/finalPhase3/pkg"
 	"github.com/redis/go-redis/v9"
 )
@@ -63,7 +64,7 @@ func VerifyToken(rdb *redis.Client) gin.HandlerFunc {
 		}
 
 		// !DO cek token from redis if it not blacklisted
-		isBlacklisted, err := rdb.Get(ctx, "Belalai-E-wallet:blacklist:"+bearerToken).Result()
+		isBlacklisted, err := rdb.Get(ctx, utils.RedisKey("blacklist", token)).Result()
 		if err == nil && isBlacklisted == "true" {
 			log.Println("Token sudah logout, silahkan login kembali")
 			ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
@@ -91,6 +92,14 @@ func VerifyToken(rdb *redis.Client) gin.HandlerFunc {
 				})
 				return
 			}
+			if strings.Contains(err.Error(), jwt.ErrTokenInvalidAudience.Error()) {
+				log.Println("JWT Error.\nCause: ", err.Error())
+				ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+					"success": false,
+					"error":   "Token tidak valid",
+				})
+				return
+			}
 			if strings.Contains(err.Error(), jwt.ErrTokenExpired.Error()) {
 				log.Println("JWT Error.\nCause: ", err.Error())
 				ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
@@ -99,7 +108,6 @@ func VerifyToken(rdb *redis.Client) gin.HandlerFunc {
 				})
 				return
 			}
-			fmt.Println(jwt.ErrTokenExpired)
 			log.Println("Internal Server Error.\nCause: ", err.Error())
 			ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
 				"success": false,
@@ -107,7 +115,51 @@ func VerifyToken(rdb *redis.Client) gin.HandlerFunc {
 			})
 			return
 		}
+
+		// !DO cek apakah user sudah logout dari semua device setelah token ini dibuat
+		revokedAt, err := rdb.Get(ctx, utils.RedisKey("logout-all", claims.UserId)).Int64()
+		if err == nil && claims.IssuedAtMs <= revokedAt {
+			log.Println("Token sudah dicabut oleh logout semua device")
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+				"success": false,
+				"error":   "Token sudah logout, silahkan login kembali",
+			})
+			return
+		} else if err != redis.Nil && err != nil {
+			log.Println("Error when checking logout-all redis cache:", err)
+			ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"error":   "Internal Server Error",
+			})
+			return
+		}
+
+		// !DO cek apakah sesi (device) dari token ini sudah dicabut
+		if claims.ID != "" {
+			isRevoked, err := rdb.Exists(ctx, utils.RedisKey("session-revoked", claims.ID)).Result()
+			if err != nil {
+				log.Println("Error when checking session redis cache:", err)
+				ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+					"success": false,
+					"error":   "Internal Server Error",
+				})
+				return
+			}
+			if isRevoked > 0 {
+				log.Println("Sesi sudah dicabut, silahkan login kembali")
+				ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+					"success": false,
+					"error":   "Sesi sudah berakhir, silahkan login kembali",
+				})
+				return
+			}
+
+			// catat aktivitas terakhir sesi, dibaca oleh GET /auth/sessions
+			rdb.Set(ctx, utils.RedisKey("session-last-seen", claims.ID), time.Now().Unix(), pkg.RefreshTokenTTL)
+		}
+
 		ctx.Set("claims", &claims)
+		ctx.Set("token", token)
 		ctx.Next()
 	}
 }
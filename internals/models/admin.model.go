@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+type AdminUser struct {
+	Id            string    `json:"id"`
+	Email         string    `json:"email"`
+	Name          *string   `json:"name"`
+	Role          string    `json:"role"`
+	EmailVerified bool      `json:"email_verified"`
+	MfaEnabled    bool      `json:"mfa_enabled"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+type UpdateRoleRequest struct {
+	Role string `json:"role" form:"role" binding:"required,oneof=user moderator admin"`
+}
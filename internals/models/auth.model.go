@@ -1,26 +1,96 @@
 package models
 
+import "time"
+
+const (
+	RoleUser      = "user"
+	RoleModerator = "moderator"
+	RoleAdmin     = "admin"
+)
+
 type User struct {
-	ID        string  `db:"id"`
-	Email     string  `db:"email"`
-	Password  string  `db:"password"`
-	Name      *string `db:"name"`
-	AvatarUrl *string `db:"avatar_url"`
-	Bio       *string `db:"bio"`
+	ID                  string     `db:"id"`
+	Username            string     `db:"username"`
+	Email               string     `db:"email"`
+	Password            string     `db:"password_hash"`
+	Name                *string    `db:"name"`
+	AvatarUrl           *string    `db:"avatar_url"`
+	Bio                 *string    `db:"bio"`
+	EmailVerifiedAt     *time.Time `db:"email_verified_at"`
+	MfaEnabled          bool       `db:"mfa_enabled"`
+	Role                string     `db:"role"`
+	UsernameChangedAt   *time.Time `db:"username_changed_at"`
+	DeletionScheduledAt *time.Time `db:"deletion_scheduled_at"`
 }
 
 type AuthRequest struct {
 	Email    string `json:"email" form:"email" binding:"required,email"`
 	Password string `json:"password" form:"password" binding:"required"`
+	Username string `json:"username" form:"username"`
+	Name     string `json:"name" form:"name"`
+}
+
+type LoginRequest struct {
+	Email      string `json:"email" form:"email" binding:"required"`
+	Password   string `json:"password" form:"password" binding:"required"`
+	DeviceName string `json:"device_name" form:"device_name"`
 }
 
 type AuthResponse struct {
-	Token string `json:"token"`
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+}
+
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
 }
 
 type UserUpdate struct {
 	ID        string `json:"id"`
+	Username  string `json:"username"`
 	Name      string `json:"name"`
 	Bio       string `json:"bio"`
 	AvatarUrl string `json:"avatar_url"`
 }
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email" form:"email" binding:"required,email"`
+}
+
+type ResetPasswordRequest struct {
+	Token       string `json:"token" form:"token" binding:"required"`
+	NewPassword string `json:"new_password" form:"new_password" binding:"required"`
+}
+
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" form:"current_password" binding:"required"`
+	NewPassword     string `json:"new_password" form:"new_password" binding:"required"`
+}
+
+type VerifyEmailRequest struct {
+	Token string `json:"token" form:"token" binding:"required"`
+}
+
+type UnlockAccountRequest struct {
+	Token string `json:"token" form:"token" binding:"required"`
+}
+
+type MfaCodeRequest struct {
+	Code string `json:"code" form:"code" binding:"required"`
+}
+
+type MfaVerifyRequest struct {
+	MfaToken   string `json:"mfa_token" form:"mfa_token" binding:"required"`
+	Code       string `json:"code" form:"code" binding:"required"`
+	DeviceName string `json:"device_name" form:"device_name"`
+}
+
+type MfaDisableRequest struct {
+	Password string `json:"password" form:"password" binding:"required"`
+	Code     string `json:"code" form:"code" binding:"required"`
+}
+
+type DeleteAccountRequest struct {
+	Password string `json:"password" form:"password" binding:"required"`
+	Code     string `json:"code" form:"code"`
+}
@@ -0,0 +1,9 @@
+package models
+
+import "time"
+
+// RestrictedUser adalah user yang di-block / di-mute beserta waktunya
+type RestrictedUser struct {
+	UserProfile
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,32 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+const (
+	ExportPending    = "pending"
+	ExportProcessing = "processing"
+	ExportCompleted  = "completed"
+	ExportFailed     = "failed"
+	ExportExpired    = "expired"
+)
+
+type DataExport struct {
+	Id          string     `json:"id" db:"id"`
+	UserId      string     `json:"-" db:"user_id"`
+	Status      string     `json:"status" db:"status"`
+	FilePath    *string    `json:"-" db:"file_path"`
+	Error       *string    `json:"error,omitempty" db:"error"`
+	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
+	CompletedAt *time.Time `json:"completed_at,omitempty" db:"completed_at"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty" db:"expires_at"`
+}
+
+// ExportData adalah isi arsip export, setiap section ditulis sebagai <nama>.json di dalam ZIP
+type ExportData struct {
+	Sections map[string]json.RawMessage
+	// Media adalah url file upload milik user (avatar dan gambar post)
+	Media []string
+}
@@ -1,8 +1,21 @@
 package models
 
+import "time"
+
 type UserProfile struct {
-	Id     string  `json:"id"`
-	Name   *string `json:"name"`
-	Avatar *string `json:"avatar_url"`
-	Bio    *string `json:"bio"`
+	Id       string  `json:"id"`
+	Username string  `json:"username"`
+	Name     *string `json:"name"`
+	Avatar   *string `json:"avatar_url"`
+	Bio      *string `json:"bio"`
+}
+
+// FollowRequest adalah permintaan follow ke akun private yang menunggu persetujuan
+type FollowRequest struct {
+	UserProfile
+	RequestedAt time.Time `json:"requested_at"`
+}
+
+type PrivacyRequest struct {
+	IsPrivate *bool `json:"is_private" form:"is_private" binding:"required"`
 }
@@ -0,0 +1,33 @@
+package models
+
+import "time"
+
+// Jenis notifikasi
+const (
+	NotificationLike           = "like"
+	NotificationComment        = "comment"
+	NotificationFollow         = "follow"
+	NotificationFollowRequest  = "follow_request"
+	NotificationFollowAccepted = "follow_accepted"
+	NotificationMention        = "mention"
+)
+
+// Notification adalah notifikasi yang sudah digabung. Actor adalah user terakhir yang memicu notifikasi,
+// ActorCount jumlah seluruh user di dalam notifikasi tsb
+type Notification struct {
+	Id         string      `json:"id"`
+	Type       string      `json:"type"`
+	Actor      UserProfile `json:"actor"`
+	ActorCount int         `json:"actor_count"`
+	PostId     *string     `json:"post_id"`
+	CommentId  *string     `json:"comment_id"`
+	Message    string      `json:"message"`
+	IsRead     bool        `json:"is_read"`
+	CreatedAt  time.Time   `json:"created_at"`
+	UpdatedAt  time.Time   `json:"updated_at"`
+}
+
+// MarkNotificationsRequest berisi id notifikasi yang ditandai sudah dibaca, kosong berarti semua notifikasi
+type MarkNotificationsRequest struct {
+	Ids []string `json:"ids" binding:"omitempty,max=100,dive,uuid"`
+}
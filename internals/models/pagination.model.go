@@ -0,0 +1,9 @@
+package models
+
+// Pagination adalah envelope pagination berbasis cursor yang dipakai semua listing.
+// NextCursor kosong (null) berarti sudah halaman terakhir
+type Pagination struct {
+	Limit      int     `json:"limit"`
+	NextCursor *string `json:"next_cursor"`
+	HasMore    bool    `json:"has_more"`
+}
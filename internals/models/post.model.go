@@ -11,6 +11,8 @@ type Posts struct {
 	ImageUrl  string     `db:"image_url"`
 	CreatedAt *time.Time `db:"created_at"`
 	UpdatedAt *time.Time `db:"updated_at"`
+	EditedAt  *time.Time `db:"edited_at"`
+	IsEdited  bool
 }
 
 type PostsRequest struct {
@@ -19,26 +21,42 @@ type PostsRequest struct {
 }
 
 type PostWithUser struct {
-	Id         string    `json:"id"`
-	UserId     string    `json:"user_id"`
-	Content    string    `json:"content_text"`
-	ImageUrl   string    `json:"image_url"`
-	CreatedAt  time.Time `json:"created_at"`
-	UserName   *string   `json:"user_name"`
-	UserAvatar *string   `json:"user_avatar"`
+	Id         string     `json:"id"`
+	UserId     string     `json:"user_id"`
+	Content    string     `json:"content_text"`
+	ImageUrl   string     `json:"image_url"`
+	CreatedAt  time.Time  `json:"created_at"`
+	EditedAt   *time.Time `json:"edited_at,omitempty"`
+	IsEdited   bool       `json:"is_edited"`
+	UserName   *string    `json:"user_name"`
+	UserAvatar *string    `json:"user_avatar"`
 }
 
 type Posting struct {
-	ID            string    `json:"id" db:"id"`
-	UserID        string    `json:"user_id" db:"user_id"`
-	Content       string    `json:"content" db:"content"`
-	ImageUrl      string    `json:"image_url,omitempty" db:"image_url"`
-	CreatedAt     time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt     time.Time `json:"updated_at" db:"updated_at"`
-	UserName      string    `json:"user_name,omitempty" db:"user_name"`
-	UserAvatarUrl *string   `json:"user_avatar_url,omitempty" db:"user_avatar_url"`
-	LikeCount     int       `json:"like_count" db:"like_count"`
-	CommentCount  int       `json:"comment_count" db:"comment_count"`
-	FollowerCount int       `json:"follower_count" db:"follower_count"`
-	IsLiked       bool      `json:"is_liked" db:"is_liked"`
+	ID            string     `json:"id" db:"id"`
+	UserID        string     `json:"user_id" db:"user_id"`
+	Content       string     `json:"content" db:"content"`
+	ImageUrl      string     `json:"image_url,omitempty" db:"image_url"`
+	CreatedAt     time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at" db:"updated_at"`
+	EditedAt      *time.Time `json:"edited_at,omitempty" db:"edited_at"`
+	IsEdited      bool       `json:"is_edited"`
+	Username      string     `json:"username,omitempty" db:"username"`
+	UserName      string     `json:"user_name,omitempty" db:"user_name"`
+	UserAvatarUrl *string    `json:"user_avatar_url,omitempty" db:"user_avatar_url"`
+	LikeCount     int        `json:"like_count" db:"like_count"`
+	CommentCount  int        `json:"comment_count" db:"comment_count"`
+	FollowerCount int        `json:"follower_count" db:"follower_count"`
+	IsLiked       bool       `json:"is_liked" db:"is_liked"`
+	IsBookmarked  bool       `json:"is_bookmarked" db:"is_bookmarked"`
+}
+
+// PostRevision adalah versi post sebelum diedit
+type PostRevision struct {
+	Id        string    `json:"id" db:"id"`
+	PostId    string    `json:"post_id" db:"post_id"`
+	EditedBy  *string   `json:"edited_by" db:"edited_by"`
+	Content   *string   `json:"content_text" db:"content_text"`
+	ImageUrl  *string   `json:"image_url" db:"image_url"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
 }
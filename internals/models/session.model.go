@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+type Session struct {
+	Id         string    `json:"id" db:"id"`
+	UserId     string    `json:"-" db:"user_id"`
+	DeviceName *string   `json:"device_name" db:"device_name"`
+	UserAgent  *string   `json:"user_agent" db:"user_agent"`
+	IpAddress  *string   `json:"ip_address" db:"ip_address"`
+	CreatedAt  time.Time `json:"created_at" db:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at" db:"last_seen_at"`
+	IsCurrent  bool      `json:"is_current"`
+}
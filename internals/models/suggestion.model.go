@@ -0,0 +1,10 @@
+package models
+
+// UserSuggestion adalah akun yang direkomendasikan untuk di-follow beserta alasannya
+type UserSuggestion struct {
+	UserProfile
+	IsPrivate   bool   `json:"is_private"`
+	MutualCount int    `json:"mutual_count"`
+	FollowsYou  bool   `json:"follows_you"`
+	Reason      string `json:"reason"`
+}
@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// PublicProfile adalah data profil user yang boleh dilihat user lain
+type PublicProfile struct {
+	Id             string    `json:"id"`
+	Username       string    `json:"username"`
+	Name           *string   `json:"name"`
+	Avatar         *string   `json:"avatar_url"`
+	Bio            *string   `json:"bio"`
+	FollowersCount int       `json:"followers_count"`
+	FollowingCount int       `json:"following_count"`
+	IsPrivate      bool      `json:"is_private"`
+	IsFollowing    bool      `json:"is_following"`
+	IsRequested    bool      `json:"is_requested"`
+	CreatedAt      time.Time `json:"created_at"`
+}
@@ -0,0 +1,213 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/raihaninkam/finalPhase3/internals/utils"
+	"github.com/redis/go-redis/v9"
+)
+
+// AccountRepository menangani penghapusan permanen akun yang jadwal penghapusannya sudah lewat
+type AccountRepository struct {
+	db  *pgxpool.Pool
+	rdb *redis.Client
+}
+
+func NewAccountRepository(db *pgxpool.Pool, rdb *redis.Client) *AccountRepository {
+	return &AccountRepository{
+		db:  db,
+		rdb: rdb,
+	}
+}
+
+// purgedAccount adalah data yang dikumpulkan sebelum user dihapus, dipakai untuk membersihkan cache dan file
+type purgedAccount struct {
+	email            string
+	files            []string
+	sessionIDs       []string
+	followerIDs      []string
+	followingIDs     []string
+	postIDs          []string
+	commentedPostIDs []string
+}
+
+// GetDueDeletions mengambil id user yang jadwal penghapusannya sudah lewat
+func (acr *AccountRepository) GetDueDeletions(ctx context.Context, limit int) ([]string, error) {
+	sql := `
+		SELECT id FROM users
+		WHERE deletion_scheduled_at IS NOT NULL AND deletion_scheduled_at <= now()
+		ORDER BY deletion_scheduled_at
+		LIMIT $1
+	`
+
+	rows, err := acr.db.Query(ctx, sql, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get due deletions: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan user id: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// PurgeUser menghapus user beserta seluruh datanya (post, comment, like, follow, sesi, token ikut terhapus
+// lewat ON DELETE CASCADE) lalu membersihkan cache redis yang memuat data user tersebut.
+// Mengembalikan url file upload dan arsip export milik user agar bisa dihapus dari disk.
+// Jika penghapusan sudah dibatalkan (user login kembali), tidak ada yang dihapus dan deleted bernilai false
+func (acr *AccountRepository) PurgeUser(ctx context.Context, userID string) (files []string, deleted bool, err error) {
+	tx, err := acr.db.Begin(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+	defer tx.Rollback(ctx)
+
+	var account purgedAccount
+	var avatar *string
+	err = tx.QueryRow(ctx, `
+		SELECT email, avatar_url FROM users
+		WHERE id = $1 AND deletion_scheduled_at IS NOT NULL AND deletion_scheduled_at <= now()
+		FOR UPDATE
+	`, userID).Scan(&account.email, &avatar)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to lock user: %w", err)
+	}
+	if avatar != nil {
+		account.files = append(account.files, *avatar)
+	}
+
+	queries := []struct {
+		sql  string
+		dest *[]string
+	}{
+		{`SELECT id FROM sessions WHERE user_id = $1`, &account.sessionIDs},
+		{`SELECT follower_id FROM follows WHERE following_id = $1 FOR UPDATE`, &account.followerIDs},
+		{`SELECT following_id FROM follows WHERE follower_id = $1 FOR UPDATE`, &account.followingIDs},
+		{`SELECT id FROM posts WHERE user_id = $1`, &account.postIDs},
+		{`SELECT image_url FROM posts WHERE user_id = $1 AND image_url IS NOT NULL`, &account.files},
+		{`SELECT r.image_url FROM post_revisions r JOIN posts p ON p.id = r.post_id WHERE p.user_id = $1 AND r.image_url IS NOT NULL`, &account.files},
+		{`SELECT DISTINCT post_id FROM comments WHERE user_id = $1`, &account.commentedPostIDs},
+		{`SELECT file_path FROM data_exports WHERE user_id = $1 AND file_path IS NOT NULL`, &account.files},
+	}
+	for _, q := range queries {
+		if err := collectStrings(ctx, tx, q.sql, userID, q.dest); err != nil {
+			return nil, false, err
+		}
+	}
+
+	// follows ikut terhapus lewat cascade, counter user lain dikurangi manual
+	if _, err := tx.Exec(ctx, `UPDATE users SET following_count = following_count - 1 WHERE id = ANY($1)`, account.followerIDs); err != nil {
+		return nil, false, fmt.Errorf("failed to update follow counts: %w", err)
+	}
+	if _, err := tx.Exec(ctx, `UPDATE users SET follower_count = follower_count - 1 WHERE id = ANY($1)`, account.followingIDs); err != nil {
+		return nil, false, fmt.Errorf("failed to update follow counts: %w", err)
+	}
+
+	if _, err := tx.Exec(ctx, `DELETE FROM users WHERE id = $1`, userID); err != nil {
+		return nil, false, fmt.Errorf("failed to delete user: %w", err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, false, err
+	}
+
+	acr.invalidateCaches(ctx, userID, &account)
+	return account.files, true, nil
+}
+
+func collectStrings(ctx context.Context, tx pgx.Tx, sql, userID string, dest *[]string) error {
+	rows, err := tx.Query(ctx, sql, userID)
+	if err != nil {
+		return fmt.Errorf("failed to collect user data: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var value string
+		if err := rows.Scan(&value); err != nil {
+			return fmt.Errorf("failed to scan user data: %w", err)
+		}
+		*dest = append(*dest, value)
+	}
+	return rows.Err()
+}
+
+// invalidateCaches menghapus semua key redis yang memuat data user yang dihapus. Gagal menghapus cache
+// tidak membatalkan penghapusan, cache tetap akan expired dengan sendirinya
+func (acr *AccountRepository) invalidateCaches(ctx context.Context, userID string, account *purgedAccount) {
+	keys := []string{
+		"posts:all",
+		fmt.Sprintf("following:%s", userID),
+		fmt.Sprintf("followers:%s", userID),
+		timelineKey(userID),
+		suggestionKey(userID),
+		utils.RedisKey("logout-all", userID),
+		utils.RedisKey("verify-email-cooldown", userID),
+		utils.RedisKey("login-fail", "account", normalizeEmail(account.email)),
+		utils.RedisKey("login-lock", "account", normalizeEmail(account.email)),
+	}
+	// follower user yang dihapus: daftar following mereka memuat user ini. Post user ini di timeline
+	// follower dibuang saat timeline dibaca
+	for _, id := range account.followerIDs {
+		keys = append(keys, fmt.Sprintf("following:%s", id))
+	}
+	for _, id := range account.followingIDs {
+		keys = append(keys, fmt.Sprintf("followers:%s", id))
+	}
+	for _, id := range account.postIDs {
+		keys = append(keys, fmt.Sprintf("post:%s", id), fmt.Sprintf("comments:post:%s", id))
+	}
+	for _, id := range account.commentedPostIDs {
+		keys = append(keys, fmt.Sprintf("comments:post:%s", id))
+	}
+	for _, id := range account.sessionIDs {
+		keys = append(keys, utils.RedisKey("session-last-seen", id))
+	}
+	if err := acr.rdb.Del(ctx, keys...).Err(); err != nil {
+		log.Println("Failed to invalidate cache of deleted user.\nCause: ", err.Error())
+	}
+
+	patterns := []string{
+		utils.RedisKey("mfa-step", userID, "*"),
+	}
+	for _, pattern := range patterns {
+		if err := deleteKeysByPattern(ctx, acr.rdb, pattern); err != nil {
+			log.Println("Failed to invalidate cache of deleted user.\nCause: ", err.Error())
+		}
+	}
+}
+
+// deleteKeysByPattern menghapus key redis yang cocok dengan pattern menggunakan SCAN (tidak memblokir redis seperti KEYS)
+func deleteKeysByPattern(ctx context.Context, rdb *redis.Client, pattern string) error {
+	iter := rdb.Scan(ctx, 0, pattern, 100).Iterator()
+	var keys []string
+	for iter.Next(ctx) {
+		keys = append(keys, iter.Val())
+		if len(keys) == 100 {
+			if err := rdb.Del(ctx, keys...).Err(); err != nil {
+				return err
+			}
+			keys = keys[:0]
+		}
+	}
+	if err := iter.Err(); err != nil {
+		return err
+	}
+	if len(keys) > 0 {
+		return rdb.Del(ctx, keys...).Err()
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/raihaninkam/finalPhase3/internals/models"
+	"github.com/redis/go-redis/v9"
+)
+
+type AdminRepository struct {
+	db  *pgxpool.Pool
+	rdb *redis.Client
+}
+
+func NewAdminRepository(db *pgxpool.Pool, rdb *redis.Client) *AdminRepository {
+	return &AdminRepository{
+		db:  db,
+		rdb: rdb,
+	}
+}
+
+func (adr *AdminRepository) ListUsers(ctx context.Context, limit, offset int) ([]models.AdminUser, error) {
+	sql := `
+		SELECT id, email, name, role, email_verified_at IS NOT NULL, mfa_enabled, created_at
+		FROM users
+		ORDER BY created_at DESC
+		LIMIT $1 OFFSET $2
+	`
+
+	rows, err := adr.db.Query(ctx, sql, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get users: %w", err)
+	}
+	defer rows.Close()
+
+	users := []models.AdminUser{}
+	for rows.Next() {
+		var user models.AdminUser
+		if err := rows.Scan(&user.Id, &user.Email, &user.Name, &user.Role, &user.EmailVerified, &user.MfaEnabled, &user.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan user: %w", err)
+		}
+		users = append(users, user)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+func (adr *AdminRepository) GetUser(ctx context.Context, userID string) (*models.AdminUser, error) {
+	sql := `SELECT id, email, name, role, email_verified_at IS NOT NULL, mfa_enabled, created_at FROM users WHERE id = $1`
+
+	var user models.AdminUser
+	if err := adr.db.QueryRow(ctx, sql, userID).Scan(&user.Id, &user.Email, &user.Name, &user.Role, &user.EmailVerified, &user.MfaEnabled, &user.CreatedAt); err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, errors.New("user not found")
+		}
+		log.Println("Failed to get user.\nCause: ", err.Error())
+		return nil, err
+	}
+	return &user, nil
+}
+
+// UpdateRole mengganti role user sekaligus mencabut semua sesinya, karena role tersimpan di access token.
+// Sesi ditandai dicabut di redis sebelum commit: jika redis gagal, role tidak jadi diganti, dan jika commit
+// gagal, user hanya perlu login ulang dengan role lama
+func (adr *AdminRepository) UpdateRole(ctx context.Context, userID, role string) (*models.AdminUser, error) {
+	tx, err := adr.db.Begin(ctx)
+	if err != nil {
+		log.Println("Failed to begin transaction:", err.Error())
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	sql := `
+		UPDATE users SET role = $1, updated_at = now()
+		WHERE id = $2
+		RETURNING id, email, name, role, email_verified_at IS NOT NULL, mfa_enabled, created_at
+	`
+
+	var user models.AdminUser
+	if err := tx.QueryRow(ctx, sql, role, userID).Scan(&user.Id, &user.Email, &user.Name, &user.Role, &user.EmailVerified, &user.MfaEnabled, &user.CreatedAt); err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, errors.New("user not found")
+		}
+		log.Println("Failed to update role.\nCause: ", err.Error())
+		return nil, err
+	}
+
+	sessionIDs, err := revokeUserSessions(ctx, tx, userID, "")
+	if err != nil {
+		return nil, err
+	}
+	if err := markSessionsRevoked(ctx, adr.rdb, sessionIDs...); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		log.Println("Failed to commit transaction:", err.Error())
+		return nil, err
+	}
+	return &user, nil
+}
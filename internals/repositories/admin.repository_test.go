@@ -0,0 +1,64 @@
+package repositories
+
+import (
+	"context"
+	"testing"
+
+	"github.com/raihaninkam/finalPhase3/internals/models"
+	"github.com/raihaninkam/finalPhase3/internals/utils"
+	"github.com/redis/go-redis/v9"
+)
+
+func TestUpdateRoleRevokesSessions(t *testing.T) {
+	db, rdb := newTestStores(t)
+	ctx := context.Background()
+	user := createTestUser(t, db, rdb, "budi")
+
+	session := &models.Session{UserId: user.ID}
+	if err := NewSessionRepository(db, rdb).CreateSession(ctx, session); err != nil {
+		t.Fatal(err)
+	}
+
+	// redis mati: role tidak jadi diganti dan sesi tetap aktif
+	broken := redis.NewClient(&redis.Options{Addr: rdb.Options().Addr})
+	broken.Close()
+	if _, err := NewAdminRepository(db, broken).UpdateRole(ctx, user.ID, models.RoleAdmin); err == nil {
+		t.Fatal("UpdateRole() with redis down: want an error")
+	}
+	stored, err := NewAdminRepository(db, rdb).GetUser(ctx, user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.Role != models.RoleUser {
+		t.Errorf("role after failed UpdateRole = %s, want %s", stored.Role, models.RoleUser)
+	}
+	sessions, err := NewSessionRepository(db, rdb).GetUserSessions(ctx, user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sessions) != 1 {
+		t.Errorf("sessions after failed UpdateRole = %d, want 1", len(sessions))
+	}
+
+	updated, err := NewAdminRepository(db, rdb).UpdateRole(ctx, user.ID, models.RoleAdmin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated.Role != models.RoleAdmin {
+		t.Errorf("role = %s, want %s", updated.Role, models.RoleAdmin)
+	}
+	sessions, err = NewSessionRepository(db, rdb).GetUserSessions(ctx, user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sessions) != 0 {
+		t.Errorf("sessions after UpdateRole = %d, want 0", len(sessions))
+	}
+	if rdb.Exists(ctx, utils.RedisKey("session-revoked", session.Id)).Val() != 1 {
+		t.Error("session is not marked revoked on redis")
+	}
+
+	if _, err := NewAdminRepository(db, rdb).UpdateRole(ctx, "00000000-0000-0000-0000-000000000000", models.RoleAdmin); err == nil || err.Error() != "user not found" {
+		t.Errorf("UpdateRole() for a missing user error = %v, want user not found", err)
+	}
+}
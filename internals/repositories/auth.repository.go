@@ -4,10 +4,15 @@ import (
 	"context"
 	"errors"
 	"log"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/raihaninkam/finalPhase3/internals/models"
+	"github.com/raihaninkam/finalPhase3/internals/utils"
 	"github.com/redis/go-redis/v9"
 )
 
@@ -24,10 +29,24 @@ func NewAuthRepository(db *pgxpool.Pool, rdb *redis.Client) *AuthRepositories {
 }
 
 func (ar *AuthRepositories) GetEmail(ctx context.Context, email string) (*models.User, error) {
-	sql := `SELECT id, email, password, name, avatar_url, bio FROM users WHERE email =$1`
+	sql := `SELECT id, username, email, password_hash, name, avatar_url, bio, email_verified_at, mfa_enabled, role, username_changed_at, deletion_scheduled_at FROM users WHERE email =$1`
 
 	var user models.User
-	if err := ar.db.QueryRow(ctx, sql, email).Scan(&user.ID, &user.Email, &user.Password, &user.Name, &user.AvatarUrl, &user.Bio); err != nil {
+	if err := ar.db.QueryRow(ctx, sql, email).Scan(&user.ID, &user.Username, &user.Email, &user.Password, &user.Name, &user.AvatarUrl, &user.Bio, &user.EmailVerifiedAt, &user.MfaEnabled, &user.Role, &user.UsernameChangedAt, &user.DeletionScheduledAt); err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, errors.New("user not found")
+		}
+		log.Println("Internal Server Error.\nCause: ", err.Error())
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (ar *AuthRepositories) GetUserByID(ctx context.Context, userID string) (*models.User, error) {
+	sql := `SELECT id, username, email, password_hash, name, avatar_url, bio, email_verified_at, mfa_enabled, role, username_changed_at, deletion_scheduled_at FROM users WHERE id = $1`
+
+	var user models.User
+	if err := ar.db.QueryRow(ctx, sql, userID).Scan(&user.ID, &user.Username, &user.Email, &user.Password, &user.Name, &user.AvatarUrl, &user.Bio, &user.EmailVerifiedAt, &user.MfaEnabled, &user.Role, &user.UsernameChangedAt, &user.DeletionScheduledAt); err != nil {
 		if err == pgx.ErrNoRows {
 			return nil, errors.New("user not found")
 		}
@@ -49,11 +68,27 @@ func (a *AuthRepositories) CheckEmailExists(rctx context.Context, email string)
 	return exists, nil
 }
 
+// CheckUsernameExists mengecek apakah username sudah dipakai user lain
+func (ar *AuthRepositories) CheckUsernameExists(ctx context.Context, username string) (bool, error) {
+	sql := "SELECT EXISTS(SELECT 1 FROM users WHERE username = $1)"
+
+	var exists bool
+	if err := ar.db.QueryRow(ctx, sql, username).Scan(&exists); err != nil {
+		log.Println("Error checking username existence:", err.Error())
+		return false, err
+	}
+
+	return exists, nil
+}
+
 func (ar *AuthRepositories) CreateAccount(ctx context.Context, user *models.User) error {
-	sql := `INSERT INTO users (email, password) VALUES ($1, $2) RETURNING id`
+	sql := `INSERT INTO users (username, email, password_hash, name) VALUES ($1, $2, $3, $4) RETURNING id`
 
 	var userId string
-	if err := ar.db.QueryRow(ctx, sql, user.Email, user.Password).Scan(&userId); err != nil {
+	if err := ar.db.QueryRow(ctx, sql, user.Username, user.Email, user.Password, user.Name).Scan(&userId); err != nil {
+		if err := uniqueViolation(err); err != nil {
+			return err
+		}
 		log.Println("Failed to create account.\nCause: ", err.Error())
 		return err
 	}
@@ -71,7 +106,7 @@ func (ar *AuthRepositories) UpdateAccount(ctx context.Context, updateData *model
 			avatar_url = COALESCE(NULLIF($3, ''), avatar_url),
 			updated_at = NOW()
 		WHERE id = $4
-		RETURNING id, email, password, name, avatar_url, bio
+		RETURNING id, username, email, password_hash, name, avatar_url, bio
 	`
 
 	var user models.User
@@ -82,6 +117,7 @@ func (ar *AuthRepositories) UpdateAccount(ctx context.Context, updateData *model
 		updateData.ID,
 	).Scan(
 		&user.ID,
+		&user.Username,
 		&user.Email,
 		&user.Password,
 		&user.Name,
@@ -99,3 +135,134 @@ func (ar *AuthRepositories) UpdateAccount(ctx context.Context, updateData *model
 
 	return &user, nil
 }
+
+// ChangeUsername mengganti username user. Kondisi cooldown dicek ulang di query
+// agar dua request bersamaan tidak bisa melewati cooldown
+func (ar *AuthRepositories) ChangeUsername(ctx context.Context, userID, username string, cooldown time.Duration) error {
+	sql := `
+		UPDATE users
+		SET username = $1, username_changed_at = now(), updated_at = now()
+		WHERE id = $2
+			AND (username_changed_at IS NULL OR username_changed_at <= now() - make_interval(secs => $3))
+	`
+
+	result, err := ar.db.Exec(ctx, sql, username, userID, cooldown.Seconds())
+	if err != nil {
+		if err := uniqueViolation(err); err != nil {
+			return err
+		}
+		log.Println("Failed to change username.\nCause: ", err.Error())
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return errors.New("username change on cooldown")
+	}
+	return nil
+}
+
+// uniqueViolation menerjemahkan error unique constraint pada tabel users menjadi error yang bisa dicek handler
+func uniqueViolation(err error) error {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) || pgErr.Code != "23505" {
+		return nil
+	}
+	switch {
+	case strings.Contains(pgErr.ConstraintName, "username"):
+		return errors.New("username already exists")
+	case strings.Contains(pgErr.ConstraintName, "email"):
+		return errors.New("email already exists")
+	}
+	return nil
+}
+
+func (ar *AuthRepositories) UpdatePassword(ctx context.Context, userID, hashedPassword string) error {
+	sql := `UPDATE users SET password_hash = $1, updated_at = now() WHERE id = $2`
+
+	result, err := ar.db.Exec(ctx, sql, hashedPassword, userID)
+	if err != nil {
+		log.Println("Failed to update password.\nCause: ", err.Error())
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return errors.New("user not found")
+	}
+	return nil
+}
+
+// ScheduleDeletion menjadwalkan akun untuk dihapus pada waktu deleteAt
+func (ar *AuthRepositories) ScheduleDeletion(ctx context.Context, userID string, deleteAt time.Time) error {
+	sql := `UPDATE users SET deletion_scheduled_at = $1, updated_at = now() WHERE id = $2`
+
+	result, err := ar.db.Exec(ctx, sql, deleteAt, userID)
+	if err != nil {
+		log.Println("Failed to schedule account deletion.\nCause: ", err.Error())
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return errors.New("user not found")
+	}
+	return nil
+}
+
+// CancelDeletion membatalkan jadwal penghapusan akun
+func (ar *AuthRepositories) CancelDeletion(ctx context.Context, userID string) error {
+	sql := `UPDATE users SET deletion_scheduled_at = NULL, updated_at = now() WHERE id = $1`
+
+	if _, err := ar.db.Exec(ctx, sql, userID); err != nil {
+		log.Println("Failed to cancel account deletion.\nCause: ", err.Error())
+		return err
+	}
+	return nil
+}
+
+// BlacklistToken menyimpan token ke redis sampai token tersebut expired,
+// sehingga middleware VerifyToken akan menolaknya
+func (ar *AuthRepositories) BlacklistToken(ctx context.Context, token string, ttl time.Duration) error {
+	if ttl <= 0 {
+		// token sudah expired, tidak perlu di-blacklist
+		return nil
+	}
+	if err := ar.rdb.Set(ctx, utils.RedisKey("blacklist", token), "true", ttl).Err(); err != nil {
+		log.Println("Failed to blacklist token.\nCause: ", err.Error())
+		return err
+	}
+	return nil
+}
+
+// RevokeAllTokens mencabut semua token milik user yang dibuat sebelum saat ini (logout semua device).
+// Waktu pencabutan disimpan dalam milidetik dan dibandingkan dengan claim iat_ms token.
+// Key disimpan selama masa berlaku access token karena setelah itu semua token lama pasti sudah expired
+func (ar *AuthRepositories) RevokeAllTokens(ctx context.Context, userID string, ttl time.Duration) error {
+	revokedAt := strconv.FormatInt(time.Now().UnixMilli(), 10)
+	if err := ar.rdb.Set(ctx, utils.RedisKey("logout-all", userID), revokedAt, ttl).Err(); err != nil {
+		log.Println("Failed to revoke all tokens.\nCause: ", err.Error())
+		return err
+	}
+	return nil
+}
+
+// VerifyEmail menandai email user sebagai terverifikasi. Email ikut dicocokkan agar link lama
+// tidak berlaku jika user sudah mengganti email
+func (ar *AuthRepositories) VerifyEmail(ctx context.Context, userID, email string) error {
+	sql := `UPDATE users SET email_verified_at = COALESCE(email_verified_at, now()) WHERE id = $1 AND email = $2`
+
+	result, err := ar.db.Exec(ctx, sql, userID, email)
+	if err != nil {
+		log.Println("Failed to verify email.\nCause: ", err.Error())
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return errors.New("user not found")
+	}
+	return nil
+}
+
+// AllowVerificationEmail membatasi pengiriman ulang email verifikasi, mengembalikan false jika masih cooldown
+func (ar *AuthRepositories) AllowVerificationEmail(ctx context.Context, userID string, cooldown time.Duration) (bool, error) {
+	ok, err := ar.rdb.SetNX(ctx, utils.RedisKey("verify-email-cooldown", userID), "1", cooldown).Result()
+	if err != nil {
+		log.Println("Failed to set verification cooldown.\nCause: ", err.Error())
+		return false, err
+	}
+	return ok, nil
+}
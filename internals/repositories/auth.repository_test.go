@@ -0,0 +1,50 @@
+package repositories
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/raihaninkam/finalPhase3/internals/models"
+	"github.com/raihaninkam/finalPhase3/pkg"
+)
+
+func TestRegisterAndLogin(t *testing.T) {
+	db, rdb := newTestStores(t)
+	ctx := context.Background()
+	ar := NewAuthRepository(db, rdb)
+
+	registered := createTestUser(t, db, rdb, "budi")
+	if registered.ID == "" {
+		t.Fatal("CreateAccount did not set the user id")
+	}
+
+	// login: cari user berdasarkan email lalu cocokkan password
+	user, err := ar.GetEmail(ctx, "budi@example.com")
+	if err != nil {
+		t.Fatalf("GetEmail() error: %v", err)
+	}
+	if user.ID != registered.ID || user.Username != "budi" || user.Role != models.RoleUser {
+		t.Errorf("GetEmail() = %+v", user)
+	}
+	hashCfg := pkg.NewHashConfig()
+	if ok, err := hashCfg.CompareHashAndPassword(testPassword, user.Password); err != nil || !ok {
+		t.Errorf("correct password rejected: ok=%v err=%v", ok, err)
+	}
+	if ok, _ := hashCfg.CompareHashAndPassword("wrong-password", user.Password); ok {
+		t.Error("wrong password accepted")
+	}
+
+	if _, err := ar.GetEmail(ctx, "nobody@example.com"); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("GetEmail() unknown email = %v, want not found", err)
+	}
+
+	duplicate := &models.User{Username: "budi2", Email: "budi@example.com", Password: user.Password, Name: user.Name}
+	if err := ar.CreateAccount(ctx, duplicate); err == nil || err.Error() != "email already exists" {
+		t.Errorf("CreateAccount() duplicate email = %v", err)
+	}
+	duplicate = &models.User{Username: "budi", Email: "other@example.com", Password: user.Password, Name: user.Name}
+	if err := ar.CreateAccount(ctx, duplicate); err == nil || err.Error() != "username already exists" {
+		t.Errorf("CreateAccount() duplicate username = %v", err)
+	}
+}
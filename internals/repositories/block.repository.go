@@ -0,0 +1,246 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/raihaninkam/finalPhase3/internals/models"
+	"github.com/raihaninkam/finalPhase3/internals/utils"
+	"github.com/raihaninkam/finalPhase3/pkg"
+	"github.com/redis/go-redis/v9"
+)
+
+type BlockRepository struct {
+	db  *pgxpool.Pool
+	rdb *redis.Client
+}
+
+func NewBlockRepository(db *pgxpool.Pool, rdb *redis.Client) *BlockRepository {
+	return &BlockRepository{
+		db:  db,
+		rdb: rdb,
+	}
+}
+
+// Block memblokir user. Relasi follow dan follow request di kedua arah ikut dihapus
+func (br *BlockRepository) Block(ctx context.Context, blockerID, blockedID string) error {
+	tx, err := br.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := lockFollowPair(ctx, tx, blockerID, blockedID); err != nil {
+		return err
+	}
+
+	tag, err := tx.Exec(ctx, `
+		INSERT INTO blocks (blocker_id, blocked_id, created_at) VALUES ($1, $2, now())
+		ON CONFLICT (blocker_id, blocked_id) DO NOTHING
+	`, blockerID, blockedID)
+	if err != nil {
+		return fmt.Errorf("failed to block user: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return errors.New("already blocked")
+	}
+
+	rows, err := tx.Query(ctx, `
+		DELETE FROM follows
+		WHERE (follower_id = $1 AND following_id = $2) OR (follower_id = $2 AND following_id = $1)
+		RETURNING follower_id, following_id
+	`, blockerID, blockedID)
+	if err != nil {
+		return fmt.Errorf("failed to remove follows: %w", err)
+	}
+	type follow struct{ follower, following string }
+	var removed []follow
+	for rows.Next() {
+		var f follow
+		if err := rows.Scan(&f.follower, &f.following); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to remove follows: %w", err)
+		}
+		removed = append(removed, f)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to remove follows: %w", err)
+	}
+	for _, f := range removed {
+		if err := updateFollowCounts(ctx, tx, f.follower, f.following, -1); err != nil {
+			return err
+		}
+	}
+
+	if _, err := tx.Exec(ctx, `
+		DELETE FROM follow_requests
+		WHERE (requester_id = $1 AND target_id = $2) OR (requester_id = $2 AND target_id = $1)
+	`, blockerID, blockedID); err != nil {
+		return fmt.Errorf("failed to remove follow requests: %w", err)
+	}
+
+	// notifikasi yang dipicu salah satu user untuk user lainnya ikut dihapus
+	if _, err := tx.Exec(ctx, `
+		DELETE FROM notification_actors a USING notifications n
+		WHERE a.notification_id = n.id
+		AND ((n.user_id = $1 AND a.actor_id = $2) OR (n.user_id = $2 AND a.actor_id = $1))
+	`, blockerID, blockedID); err != nil {
+		return fmt.Errorf("failed to remove notifications: %w", err)
+	}
+	if err := deleteEmptyNotifications(ctx, tx, blockerID, blockedID); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return err
+	}
+
+	// Invalidate cache
+	for _, f := range removed {
+		br.rdb.Del(ctx, fmt.Sprintf("following:%s", f.follower), fmt.Sprintf("followers:%s", f.following))
+	}
+	return nil
+}
+
+// Unblock membuka blokir user. Relasi follow yang sudah terhapus tidak dikembalikan
+func (br *BlockRepository) Unblock(ctx context.Context, blockerID, blockedID string) error {
+	tag, err := br.db.Exec(ctx, `DELETE FROM blocks WHERE blocker_id = $1 AND blocked_id = $2`, blockerID, blockedID)
+	if err != nil {
+		return fmt.Errorf("failed to unblock user: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return errors.New("not blocked")
+	}
+	return nil
+}
+
+// Mute menyembunyikan post dan komentar user dari feed muter, tanpa memberi tahu user tsb
+func (br *BlockRepository) Mute(ctx context.Context, muterID, mutedID string) error {
+	tag, err := br.db.Exec(ctx, `
+		INSERT INTO mutes (muter_id, muted_id, created_at)
+		SELECT $1, id, now() FROM users WHERE id = $2
+		ON CONFLICT (muter_id, muted_id) DO NOTHING
+	`, muterID, mutedID)
+	if err != nil {
+		return fmt.Errorf("failed to mute user: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		var exists bool
+		if err := br.db.QueryRow(ctx, `SELECT EXISTS(SELECT 1 FROM users WHERE id = $1)`, mutedID).Scan(&exists); err != nil {
+			return fmt.Errorf("failed to mute user: %w", err)
+		}
+		if !exists {
+			return errors.New("user not found")
+		}
+		return errors.New("already muted")
+	}
+	return nil
+}
+
+func (br *BlockRepository) Unmute(ctx context.Context, muterID, mutedID string) error {
+	tag, err := br.db.Exec(ctx, `DELETE FROM mutes WHERE muter_id = $1 AND muted_id = $2`, muterID, mutedID)
+	if err != nil {
+		return fmt.Errorf("failed to unmute user: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return errors.New("not muted")
+	}
+	return nil
+}
+
+// GetBlocks mengambil daftar user yang diblokir, terbaru lebih dulu (keyset created_at + id)
+func (br *BlockRepository) GetBlocks(ctx context.Context, userID string, limit int, cursor *pkg.Cursor) ([]models.RestrictedUser, *pkg.Cursor, error) {
+	return br.listRestricted(ctx, `
+		SELECT u.id, u.username, u.name, u.avatar_url, u.bio, b.created_at
+		FROM blocks b
+		JOIN users u ON b.blocked_id = u.id
+		WHERE b.blocker_id = $1
+		AND ($2::timestamptz IS NULL OR (b.created_at, b.blocked_id) < ($2, $3::uuid))
+		ORDER BY b.created_at DESC, b.blocked_id DESC
+		LIMIT $4
+	`, userID, limit, cursor, utils.CursorBlocks)
+}
+
+// GetMutes mengambil daftar user yang di-mute, terbaru lebih dulu (keyset created_at + id)
+func (br *BlockRepository) GetMutes(ctx context.Context, userID string, limit int, cursor *pkg.Cursor) ([]models.RestrictedUser, *pkg.Cursor, error) {
+	return br.listRestricted(ctx, `
+		SELECT u.id, u.username, u.name, u.avatar_url, u.bio, m.created_at
+		FROM mutes m
+		JOIN users u ON m.muted_id = u.id
+		WHERE m.muter_id = $1
+		AND ($2::timestamptz IS NULL OR (m.created_at, m.muted_id) < ($2, $3::uuid))
+		ORDER BY m.created_at DESC, m.muted_id DESC
+		LIMIT $4
+	`, userID, limit, cursor, utils.CursorMutes)
+}
+
+func (br *BlockRepository) listRestricted(ctx context.Context, sql, userID string, limit int, cursor *pkg.Cursor, kind string) ([]models.RestrictedUser, *pkg.Cursor, error) {
+	var after *time.Time
+	var afterID *string
+	if cursor != nil {
+		after, afterID = &cursor.CreatedAt, &cursor.ID
+	}
+
+	// ambil satu baris lebih untuk mengetahui apakah masih ada halaman berikutnya
+	rows, err := br.db.Query(ctx, sql, userID, after, afterID, limit+1)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get users: %w", err)
+	}
+	defer rows.Close()
+
+	users := []models.RestrictedUser{}
+	for rows.Next() {
+		var user models.RestrictedUser
+		if err := rows.Scan(&user.Id, &user.Username, &user.Name, &user.Avatar, &user.Bio, &user.CreatedAt); err != nil {
+			return nil, nil, fmt.Errorf("failed to scan user: %w", err)
+		}
+		users = append(users, user)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	var next *pkg.Cursor
+	if len(users) > limit {
+		users = users[:limit]
+		last := users[limit-1]
+		next = pkg.NewCursor(kind, last.CreatedAt, last.Id)
+	}
+	return users, next, nil
+}
+
+// hiddenUserIDs mengambil id user yang kontennya disembunyikan dari viewer:
+// yang diblokir viewer, yang memblokir viewer, dan yang di-mute viewer
+func hiddenUserIDs(ctx context.Context, db *pgxpool.Pool, viewerID string) ([]string, error) {
+	rows, err := db.Query(ctx, `
+		SELECT blocked_id FROM blocks WHERE blocker_id = $1
+		UNION
+		SELECT blocker_id FROM blocks WHERE blocked_id = $1
+		UNION
+		SELECT muted_id FROM mutes WHERE muter_id = $1
+	`, viewerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get hidden users: %w", err)
+	}
+	return collectIDs(rows)
+}
+
+// isBlocked mengecek apakah salah satu user memblokir user lainnya
+func isBlocked(ctx context.Context, tx pgx.Tx, userID, otherID string) (bool, error) {
+	var blocked bool
+	err := tx.QueryRow(ctx, `
+		SELECT EXISTS(
+			SELECT 1 FROM blocks
+			WHERE (blocker_id = $1 AND blocked_id = $2) OR (blocker_id = $2 AND blocked_id = $1)
+		)
+	`, userID, otherID).Scan(&blocked)
+	if err != nil {
+		return false, fmt.Errorf("failed to check block: %w", err)
+	}
+	return blocked, nil
+}
@@ -0,0 +1,135 @@
+package repositories
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/raihaninkam/finalPhase3/internals/models"
+)
+
+func TestBlockRemovesRelations(t *testing.T) {
+	db, rdb := newTestStores(t)
+	ctx := context.Background()
+	br := NewBlockRepository(db, rdb)
+	fr := NewFollowsRepository(db, rdb)
+	nr := NewNotificationRepository(db, rdb)
+
+	budi := createTestUser(t, db, rdb, "budi")
+	ani := createTestUser(t, db, rdb, "ani")
+	if _, err := fr.Follow(ctx, ani.ID, budi.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := fr.Follow(ctx, budi.ID, ani.ID); err != nil {
+		t.Fatal(err)
+	}
+	// isi cache follower agar terlihat jika tidak dihapus saat block
+	if _, _, err := fr.GetFollowers(ctx, budi.ID, 10, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := br.Block(ctx, budi.ID, ani.ID); err != nil {
+		t.Fatalf("Block() error: %v", err)
+	}
+	if err := br.Block(ctx, budi.ID, ani.ID); err == nil || err.Error() != "already blocked" {
+		t.Errorf("Block() twice = %v", err)
+	}
+
+	// follow di kedua arah dihapus beserta counter dan notifikasinya
+	for _, userID := range []string{budi.ID, ani.ID} {
+		followers, following := followCounts(t, db, userID)
+		if followers != 0 || following != 0 {
+			t.Errorf("counts of %s after block = %d / %d, want 0 / 0", userID, followers, following)
+		}
+		if count, _ := nr.GetUnreadCount(ctx, userID); count != 0 {
+			t.Errorf("notifications of %s after block = %d, want 0", userID, count)
+		}
+	}
+	followers, _, err := fr.GetFollowers(ctx, budi.ID, 10, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(followers) != 0 {
+		t.Errorf("GetFollowers() after block = %+v", followers)
+	}
+
+	if _, err := fr.Follow(ctx, ani.ID, budi.ID); err == nil || err.Error() != "user blocked" {
+		t.Errorf("Follow() blocker = %v, want user blocked", err)
+	}
+	if _, err := fr.Follow(ctx, budi.ID, ani.ID); err == nil || err.Error() != "user blocked" {
+		t.Errorf("Follow() blocked user = %v, want user blocked", err)
+	}
+
+	blocks, _, err := br.GetBlocks(ctx, budi.ID, 10, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(blocks) != 1 || blocks[0].Id != ani.ID {
+		t.Errorf("GetBlocks() = %+v", blocks)
+	}
+
+	// unblock tidak mengembalikan follow, tapi follow baru diperbolehkan lagi
+	if err := br.Unblock(ctx, budi.ID, ani.ID); err != nil {
+		t.Fatal(err)
+	}
+	if followers, _ := followCounts(t, db, budi.ID); followers != 0 {
+		t.Errorf("follower_count after unblock = %d, want 0", followers)
+	}
+	if _, err := fr.Follow(ctx, ani.ID, budi.ID); err != nil {
+		t.Errorf("Follow() after unblock: %v", err)
+	}
+}
+
+func TestBlockHidesContent(t *testing.T) {
+	db, rdb := newTestStores(t)
+	ctx := context.Background()
+	br := NewBlockRepository(db, rdb)
+	pr := NewPostRepository(db, rdb)
+	cr := NewCommentRepository(db, rdb)
+
+	budi := createTestUser(t, db, rdb, "budi")
+	ani := createTestUser(t, db, rdb, "ani")
+	cici := createTestUser(t, db, rdb, "cici")
+	post := createTestPost(t, db, rdb, budi.ID, "hello")
+	if _, err := cr.CreateComment(ctx, &models.Comment{UserId: ani.ID, PostId: post.Id, Content: "hi"}); err != nil {
+		t.Fatal(err)
+	}
+	// isi cache halaman pertama komentar
+	if _, _, err := cr.GetPostComments(ctx, post.Id, cici.ID, 10, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := br.Block(ctx, budi.ID, ani.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	// post milik user yang memblokir tidak bisa dilihat, di-like, di-bookmark atau dikomentari
+	if _, err := pr.GetPostByID(ctx, post.Id, ani.ID); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("GetPostByID() by blocked user = %v, want not found", err)
+	}
+	if err := pr.BookmarkPost(ctx, ani.ID, post.Id); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("BookmarkPost() by blocked user = %v, want not found", err)
+	}
+	if err := NewLikeRepository(db, rdb).LikePost(ctx, ani.ID, post.Id); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("LikePost() by blocked user = %v, want not found", err)
+	}
+	if _, err := cr.CreateComment(ctx, &models.Comment{UserId: ani.ID, PostId: post.Id, Content: "hi again"}); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("CreateComment() by blocked user = %v, want not found", err)
+	}
+
+	// komentar user yang diblokir disembunyikan dari pemblokir, user lain tetap melihatnya
+	comments, _, err := cr.GetPostComments(ctx, post.Id, budi.ID, 10, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(comments) != 0 {
+		t.Errorf("blocker sees %d comments from blocked user", len(comments))
+	}
+	comments, _, err = cr.GetPostComments(ctx, post.Id, cici.ID, 10, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(comments) != 1 {
+		t.Errorf("other user sees %d comments, want 1", len(comments))
+	}
+}
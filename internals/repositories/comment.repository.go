@@ -3,11 +3,16 @@ package repositories
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"strconv"
 	"time"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/raihaninkam/finalPhase3/internals/models"
+	"github.com/raihaninkam/finalPhase3/internals/utils"
+	"github.com/raihaninkam/finalPhase3/pkg"
 	"github.com/redis/go-redis/v9"
 )
 
@@ -24,30 +29,81 @@ func NewCommentRepository(db *pgxpool.Pool, rdb *redis.Client) *CommentRepositor
 }
 
 func (cr *CommentRepository) CreateComment(ctx context.Context, comment *models.Comment) (*models.Comment, error) {
+	if err := cr.checkPostVisible(ctx, comment.PostId, comment.UserId); err != nil {
+		return nil, err
+	}
+
+	tx, err := cr.db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
 	sql := `INSERT INTO comments (user_id, post_id, content, created_at, updated_at) 
 	        VALUES ($1, $2, $3, now(), now()) 
 	        RETURNING id, created_at, updated_at`
 
-	err := cr.db.QueryRow(ctx, sql, comment.UserId, comment.PostId, comment.Content).
+	err = tx.QueryRow(ctx, sql, comment.UserId, comment.PostId, comment.Content).
 		Scan(&comment.Id, &comment.CreatedAt, &comment.UpdatedAt)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create comment: %w", err)
 	}
 
+	// notifikasi ke pemilik post dan user yang di-mention di komentar
+	var authorID string
+	if err := tx.QueryRow(ctx, `SELECT user_id FROM posts WHERE id = $1`, comment.PostId).Scan(&authorID); err != nil {
+		return nil, fmt.Errorf("failed to get post: %w", err)
+	}
+	if err := notify(ctx, tx, notificationEvent{
+		recipientID: authorID,
+		actorID:     comment.UserId,
+		kind:        models.NotificationComment,
+		postID:      &comment.PostId,
+		commentID:   &comment.Id,
+	}); err != nil {
+		return nil, err
+	}
+	if err := notifyMentions(ctx, tx, comment.UserId, comment.PostId, &comment.Id, comment.Content); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+
 	// Invalidate cache
-	cr.rdb.Del(ctx, fmt.Sprintf("comments:post:%s", comment.PostId))
+	cr.rdb.Del(ctx, fmt.Sprintf("comments:post:%s", comment.PostId), fmt.Sprintf("post:%s", comment.PostId))
 
 	return comment, nil
 }
 
-func (cr *CommentRepository) GetPostComments(ctx context.Context, postID string) ([]models.CommentWithUser, error) {
-	// Try cache first
+// GetPostComments mengambil komentar post dari yang terlama (keyset created_at + id).
+// Mengembalikan cursor halaman berikutnya, nil jika sudah habis
+func (cr *CommentRepository) GetPostComments(ctx context.Context, postID, viewerID string, limit int, cursor *pkg.Cursor) ([]models.CommentWithUser, *pkg.Cursor, error) {
+	if err := cr.checkPostVisible(ctx, postID, viewerID); err != nil {
+		return nil, nil, err
+	}
+
+	// komentar dari user yang diblokir / memblokir / di-mute viewer disembunyikan
+	hidden, err := hiddenUserIDs(ctx, cr.db, viewerID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if hidden == nil {
+		hidden = []string{}
+	}
+
+	// Hanya halaman pertama yang di-cache (dan hanya tanpa filter per viewer), disimpan per limit di dalam satu hash
 	cacheKey := fmt.Sprintf("comments:post:%s", postID)
-	cached, err := cr.rdb.Get(ctx, cacheKey).Result()
-	if err == nil {
-		var comments []models.CommentWithUser
-		if err := json.Unmarshal([]byte(cached), &comments); err == nil {
-			return comments, nil
+	cacheField := strconv.Itoa(limit)
+	cacheable := cursor == nil && len(hidden) == 0
+	if cacheable {
+		cached, err := cr.rdb.HGet(ctx, cacheKey, cacheField).Result()
+		if err == nil {
+			var page commentPage
+			if err := json.Unmarshal([]byte(cached), &page); err == nil {
+				return page.Comments, page.Next, nil
+			}
 		}
 	}
 
@@ -64,16 +120,26 @@ func (cr *CommentRepository) GetPostComments(ctx context.Context, postID string)
 		FROM comments c
 		JOIN users u ON c.user_id = u.id
 		WHERE c.post_id = $1
-		ORDER BY c.created_at ASC
+		AND ($2::timestamptz IS NULL OR (c.created_at, c.id) > ($2, $3::uuid))
+		AND c.user_id <> ALL($5::uuid[])
+		ORDER BY c.created_at ASC, c.id ASC
+		LIMIT $4
 	`
 
-	rows, err := cr.db.Query(ctx, sql, postID)
+	var after *time.Time
+	var afterID *string
+	if cursor != nil {
+		after, afterID = &cursor.CreatedAt, &cursor.ID
+	}
+
+	// ambil satu baris lebih untuk mengetahui apakah masih ada halaman berikutnya
+	rows, err := cr.db.Query(ctx, sql, postID, after, afterID, limit+1, hidden)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get comments: %w", err)
+		return nil, nil, fmt.Errorf("failed to get comments: %w", err)
 	}
 	defer rows.Close()
 
-	var comments []models.CommentWithUser
+	comments := []models.CommentWithUser{}
 	for rows.Next() {
 		var comment models.CommentWithUser
 		if err := rows.Scan(
@@ -85,31 +151,97 @@ func (cr *CommentRepository) GetPostComments(ctx context.Context, postID string)
 			&comment.UserName,
 			&comment.UserAvatar,
 		); err != nil {
-			return nil, fmt.Errorf("failed to scan comment: %w", err)
+			return nil, nil, fmt.Errorf("failed to scan comment: %w", err)
 		}
 		comments = append(comments, comment)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	var next *pkg.Cursor
+	if len(comments) > limit {
+		comments = comments[:limit]
+		last := comments[limit-1]
+		next = pkg.NewCursor(utils.CursorComments, last.CreatedAt, last.Id)
+	}
 
 	// Cache the result
-	if len(comments) > 0 {
-		commentsJSON, _ := json.Marshal(comments)
-		cr.rdb.Set(ctx, cacheKey, commentsJSON, 5*time.Minute)
+	if cacheable && len(comments) > 0 {
+		pageJSON, _ := json.Marshal(commentPage{Comments: comments, Next: next})
+		cr.rdb.HSet(ctx, cacheKey, cacheField, pageJSON)
+		cr.rdb.Expire(ctx, cacheKey, 5*time.Minute)
 	}
 
-	return comments, nil
+	return comments, next, nil
+}
+
+// checkPostVisible mengembalikan "post not found" jika post tidak ada atau milik akun private
+// yang belum di-follow viewer
+func (cr *CommentRepository) checkPostVisible(ctx context.Context, postID, viewerID string) error {
+	var visible bool
+	sql := `SELECT EXISTS(SELECT 1 FROM posts p WHERE p.id = $1 AND ` + visibleToViewer("p.user_id", "$2::uuid") + `)`
+	if err := cr.db.QueryRow(ctx, sql, postID, viewerID).Scan(&visible); err != nil {
+		return fmt.Errorf("failed to get post: %w", err)
+	}
+	if !visible {
+		return errors.New("post not found")
+	}
+	return nil
+}
+
+// commentPage adalah bentuk cache halaman pertama komentar
+type commentPage struct {
+	Comments []models.CommentWithUser `json:"comments"`
+	Next     *pkg.Cursor              `json:"next"`
 }
 
 func (cr *CommentRepository) DeleteComment(ctx context.Context, commentID, userID string) error {
-	sql := `DELETE FROM comments WHERE id = $1 AND user_id = $2`
+	tx, err := cr.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
 
-	result, err := cr.db.Exec(ctx, sql, commentID, userID)
+	sql := `DELETE FROM comments WHERE id = $1 AND user_id = $2
+	        RETURNING post_id, (SELECT user_id FROM posts WHERE id = comments.post_id)`
+
+	var postID, authorID string
+	err = tx.QueryRow(ctx, sql, commentID, userID).Scan(&postID, &authorID)
+	if err == pgx.ErrNoRows {
+		return fmt.Errorf("comment not found or unauthorized")
+	}
 	if err != nil {
 		return fmt.Errorf("failed to delete comment: %w", err)
 	}
 
-	if result.RowsAffected() == 0 {
-		return fmt.Errorf("comment not found or unauthorized")
+	// mention di komentar yang dihapus tidak relevan lagi
+	mention := notificationEvent{kind: models.NotificationMention, commentID: &commentID}
+	if _, err := tx.Exec(ctx, `DELETE FROM notifications WHERE group_key = $1`, mention.groupKey()); err != nil {
+		return fmt.Errorf("failed to delete notifications: %w", err)
+	}
+
+	// user dihapus dari notifikasi komentar pemilik post jika tidak punya komentar lain di post tsb
+	var hasOther bool
+	if err := tx.QueryRow(ctx, `SELECT EXISTS(SELECT 1 FROM comments WHERE post_id = $1 AND user_id = $2)`, postID, userID).Scan(&hasOther); err != nil {
+		return fmt.Errorf("failed to delete comment: %w", err)
 	}
+	if !hasOther {
+		if err := retractNotification(ctx, tx, notificationEvent{
+			recipientID: authorID,
+			actorID:     userID,
+			kind:        models.NotificationComment,
+			postID:      &postID,
+		}); err != nil {
+			return err
+		}
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return err
+	}
+
+	// Invalidate cache
+	cr.rdb.Del(ctx, fmt.Sprintf("comments:post:%s", postID), fmt.Sprintf("post:%s", postID))
 
 	return nil
 }
@@ -0,0 +1,92 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/raihaninkam/finalPhase3/internals/models"
+	"github.com/raihaninkam/finalPhase3/internals/utils"
+	"github.com/raihaninkam/finalPhase3/pkg"
+)
+
+func TestCommentRoundTrip(t *testing.T) {
+	db, rdb := newTestStores(t)
+	ctx := context.Background()
+	cr := NewCommentRepository(db, rdb)
+
+	author := createTestUser(t, db, rdb, "budi")
+	commenter := createTestUser(t, db, rdb, "ani")
+	post := createTestPost(t, db, rdb, author.ID, "hello")
+
+	var created []string
+	for i := range 3 {
+		comment, err := cr.CreateComment(ctx, &models.Comment{UserId: commenter.ID, PostId: post.Id, Content: fmt.Sprintf("comment %d", i)})
+		if err != nil {
+			t.Fatalf("CreateComment() error: %v", err)
+		}
+		created = append(created, comment.Id)
+	}
+
+	// komentar diurutkan dari yang terlama, dua halaman dengan limit 2
+	first, next, err := cr.GetPostComments(ctx, post.Id, author.ID, 2, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(first) != 2 || next == nil {
+		t.Fatalf("first page = %d comments, next = %v", len(first), next)
+	}
+	cursor, err := pkg.DecodeCursor(next.Encode(), utils.CursorComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, next, err := cr.GetPostComments(ctx, post.Id, author.ID, 2, cursor)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(second) != 1 || next != nil {
+		t.Fatalf("second page = %d comments, next = %v", len(second), next)
+	}
+	got := []string{first[0].Id, first[1].Id, second[0].Id}
+	for i := range created {
+		if got[i] != created[i] {
+			t.Errorf("comment %d = %s, want %s", i, got[i], created[i])
+		}
+	}
+
+	if err := cr.DeleteComment(ctx, created[0], author.ID); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("DeleteComment() by other user = %v, want not found", err)
+	}
+	if err := cr.DeleteComment(ctx, created[0], commenter.ID); err != nil {
+		t.Fatalf("DeleteComment() error: %v", err)
+	}
+	// halaman pertama yang di-cache harus ikut dihapus
+	comments, _, err := cr.GetPostComments(ctx, post.Id, author.ID, 2, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(comments) != 2 || comments[0].Id != created[1] {
+		t.Errorf("comments after delete = %+v", comments)
+	}
+}
+
+func TestCommentOnPrivatePost(t *testing.T) {
+	db, rdb := newTestStores(t)
+	ctx := context.Background()
+	cr := NewCommentRepository(db, rdb)
+
+	author := createTestUser(t, db, rdb, "budi")
+	stranger := createTestUser(t, db, rdb, "ani")
+	if _, err := NewFollowsRepository(db, rdb).SetPrivacy(ctx, author.ID, true); err != nil {
+		t.Fatal(err)
+	}
+	post := createTestPost(t, db, rdb, author.ID, "private")
+
+	if _, err := cr.CreateComment(ctx, &models.Comment{UserId: stranger.ID, PostId: post.Id, Content: "hi"}); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("CreateComment() on private post = %v, want not found", err)
+	}
+	if _, _, err := cr.GetPostComments(ctx, post.Id, stranger.ID, 10, nil); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("GetPostComments() on private post = %v, want not found", err)
+	}
+}
@@ -0,0 +1,260 @@
+package repositories
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/raihaninkam/finalPhase3/internals/models"
+	"github.com/redis/go-redis/v9"
+)
+
+type ExportRepository struct {
+	db  *pgxpool.Pool
+	rdb *redis.Client
+}
+
+func NewExportRepository(db *pgxpool.Pool, rdb *redis.Client) *ExportRepository {
+	return &ExportRepository{
+		db:  db,
+		rdb: rdb,
+	}
+}
+
+// exportSections adalah query untuk setiap file json di dalam arsip export, $1 adalah id user
+var exportSections = map[string]string{
+	"profile": `
+		SELECT row_to_json(t) FROM (
+			SELECT id, username, email, name, avatar_url, bio, role, is_private, email_verified_at, mfa_enabled, created_at, updated_at
+			FROM users WHERE id = $1
+		) t`,
+	"posts": `
+		SELECT COALESCE(json_agg(t ORDER BY t.created_at), '[]') FROM (
+			SELECT id, content_text, image_url, created_at, updated_at, edited_at FROM posts WHERE user_id = $1
+		) t`,
+	"comments": `
+		SELECT COALESCE(json_agg(t ORDER BY t.created_at), '[]') FROM (
+			SELECT id, post_id, content, created_at, updated_at FROM comments WHERE user_id = $1
+		) t`,
+	"likes": `
+		SELECT COALESCE(json_agg(t ORDER BY t.created_at), '[]') FROM (
+			SELECT post_id, created_at FROM likes WHERE user_id = $1
+		) t`,
+	"bookmarks": `
+		SELECT COALESCE(json_agg(t ORDER BY t.created_at), '[]') FROM (
+			SELECT post_id, created_at FROM bookmarks WHERE user_id = $1
+		) t`,
+	"following": `
+		SELECT COALESCE(json_agg(t ORDER BY t.created_at), '[]') FROM (
+			SELECT u.id, u.username, f.created_at FROM follows f JOIN users u ON u.id = f.following_id
+			WHERE f.follower_id = $1
+		) t`,
+	"followers": `
+		SELECT COALESCE(json_agg(t ORDER BY t.created_at), '[]') FROM (
+			SELECT u.id, u.username, f.created_at FROM follows f JOIN users u ON u.id = f.follower_id
+			WHERE f.following_id = $1
+		) t`,
+	"follow_requests": `
+		SELECT COALESCE(json_agg(t ORDER BY t.created_at), '[]') FROM (
+			SELECT u.id, u.username, r.created_at FROM follow_requests r JOIN users u ON u.id = r.target_id
+			WHERE r.requester_id = $1
+		) t`,
+	"blocks": `
+		SELECT COALESCE(json_agg(t ORDER BY t.created_at), '[]') FROM (
+			SELECT u.id, u.username, b.created_at FROM blocks b JOIN users u ON u.id = b.blocked_id
+			WHERE b.blocker_id = $1
+		) t`,
+	"mutes": `
+		SELECT COALESCE(json_agg(t ORDER BY t.created_at), '[]') FROM (
+			SELECT u.id, u.username, m.created_at FROM mutes m JOIN users u ON u.id = m.muted_id
+			WHERE m.muter_id = $1
+		) t`,
+	"notifications": `
+		SELECT COALESCE(json_agg(t ORDER BY t.created_at), '[]') FROM (
+			SELECT id, type, post_id, comment_id, read_at, created_at, updated_at FROM notifications WHERE user_id = $1
+		) t`,
+	"sessions": `
+		SELECT COALESCE(json_agg(t ORDER BY t.created_at), '[]') FROM (
+			SELECT id, device_name, user_agent, ip_address, created_at, last_seen_at, revoked_at FROM sessions WHERE user_id = $1
+		) t`,
+}
+
+// CreateExport membuat job export baru. Hanya boleh ada satu export yang sedang berjalan per user
+func (er *ExportRepository) CreateExport(ctx context.Context, userID string) (*models.DataExport, error) {
+	tx, err := er.db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	// lock baris user agar dua request bersamaan tidak membuat dua export
+	if _, err := tx.Exec(ctx, `SELECT 1 FROM users WHERE id = $1 FOR UPDATE`, userID); err != nil {
+		return nil, err
+	}
+
+	var running bool
+	if err := tx.QueryRow(ctx, `SELECT EXISTS(SELECT 1 FROM data_exports WHERE user_id = $1 AND status IN ('pending', 'processing'))`, userID).Scan(&running); err != nil {
+		return nil, err
+	}
+	if running {
+		return nil, errors.New("export already in progress")
+	}
+
+	export := models.DataExport{UserId: userID, Status: models.ExportPending}
+	if err := tx.QueryRow(ctx, `INSERT INTO data_exports (user_id) VALUES ($1) RETURNING id, created_at`, userID).
+		Scan(&export.Id, &export.CreatedAt); err != nil {
+		log.Println("Failed to create data export.\nCause: ", err.Error())
+		return nil, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return &export, nil
+}
+
+// GetExport mengambil export milik user
+func (er *ExportRepository) GetExport(ctx context.Context, userID, exportID string) (*models.DataExport, error) {
+	sql := `SELECT id, user_id, status, file_path, error, created_at, completed_at, expires_at FROM data_exports WHERE id = $1 AND user_id = $2`
+
+	var export models.DataExport
+	if err := er.db.QueryRow(ctx, sql, exportID, userID).Scan(
+		&export.Id,
+		&export.UserId,
+		&export.Status,
+		&export.FilePath,
+		&export.Error,
+		&export.CreatedAt,
+		&export.CompletedAt,
+		&export.ExpiresAt,
+	); err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, errors.New("export not found")
+		}
+		log.Println("Failed to get data export.\nCause: ", err.Error())
+		return nil, err
+	}
+	return &export, nil
+}
+
+// ClaimPendingExport mengambil satu export pending dan menandainya processing.
+// SKIP LOCKED membuat beberapa instance worker tidak memproses export yang sama
+func (er *ExportRepository) ClaimPendingExport(ctx context.Context) (*models.DataExport, error) {
+	sql := `
+		UPDATE data_exports SET status = 'processing', started_at = now()
+		WHERE id = (
+			SELECT id FROM data_exports WHERE status = 'pending'
+			ORDER BY created_at
+			FOR UPDATE SKIP LOCKED
+			LIMIT 1
+		)
+		RETURNING id, user_id, status, created_at
+	`
+
+	var export models.DataExport
+	if err := er.db.QueryRow(ctx, sql).Scan(&export.Id, &export.UserId, &export.Status, &export.CreatedAt); err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to claim data export: %w", err)
+	}
+	return &export, nil
+}
+
+// RequeueStaleExports mengembalikan export yang macet di status processing (misal server mati saat export) ke pending
+func (er *ExportRepository) RequeueStaleExports(ctx context.Context, staleAfter time.Duration) error {
+	sql := `UPDATE data_exports SET status = 'pending', started_at = NULL WHERE status = 'processing' AND started_at < now() - make_interval(secs => $1)`
+
+	if _, err := er.db.Exec(ctx, sql, staleAfter.Seconds()); err != nil {
+		return fmt.Errorf("failed to requeue stale data exports: %w", err)
+	}
+	return nil
+}
+
+// GetExportData mengumpulkan semua data milik user untuk dimasukkan ke arsip export
+func (er *ExportRepository) GetExportData(ctx context.Context, userID string) (*models.ExportData, error) {
+	data := models.ExportData{Sections: map[string]json.RawMessage{}}
+	for name, sql := range exportSections {
+		var section []byte
+		if err := er.db.QueryRow(ctx, sql, userID).Scan(&section); err != nil {
+			return nil, fmt.Errorf("failed to export %s: %w", name, err)
+		}
+		data.Sections[name] = section
+	}
+
+	rows, err := er.db.Query(ctx, `
+		SELECT avatar_url FROM users WHERE id = $1 AND avatar_url IS NOT NULL
+		UNION ALL
+		SELECT image_url FROM posts WHERE user_id = $1 AND image_url IS NOT NULL
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to export media: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var url string
+		if err := rows.Scan(&url); err != nil {
+			return nil, fmt.Errorf("failed to scan media: %w", err)
+		}
+		data.Media = append(data.Media, url)
+	}
+	return &data, rows.Err()
+}
+
+// CompleteExport menandai export selesai dan menyimpan lokasi file arsipnya
+func (er *ExportRepository) CompleteExport(ctx context.Context, exportID, filePath string, ttl time.Duration) error {
+	sql := `UPDATE data_exports SET status = 'completed', file_path = $1, completed_at = now(), expires_at = $2 WHERE id = $3`
+
+	if _, err := er.db.Exec(ctx, sql, filePath, time.Now().Add(ttl), exportID); err != nil {
+		return fmt.Errorf("failed to complete data export: %w", err)
+	}
+	return nil
+}
+
+// FailExport menandai export gagal beserta alasannya
+func (er *ExportRepository) FailExport(ctx context.Context, exportID, reason string) error {
+	sql := `UPDATE data_exports SET status = 'failed', error = $1, completed_at = now() WHERE id = $2`
+
+	if _, err := er.db.Exec(ctx, sql, reason, exportID); err != nil {
+		return fmt.Errorf("failed to mark data export as failed: %w", err)
+	}
+	return nil
+}
+
+// ExpireExports menandai export yang sudah lewat masa berlakunya sebagai expired
+// dan mengembalikan file arsip yang harus dihapus
+func (er *ExportRepository) ExpireExports(ctx context.Context) ([]string, error) {
+	sql := `
+		WITH expired AS (
+			SELECT id, file_path FROM data_exports
+			WHERE status = 'completed' AND expires_at <= now()
+			FOR UPDATE
+		)
+		UPDATE data_exports d SET status = 'expired', file_path = NULL
+		FROM expired e
+		WHERE d.id = e.id
+		RETURNING e.file_path
+	`
+
+	rows, err := er.db.Query(ctx, sql)
+	if err != nil {
+		return nil, fmt.Errorf("failed to expire data exports: %w", err)
+	}
+	defer rows.Close()
+
+	var files []string
+	for rows.Next() {
+		var file *string
+		if err := rows.Scan(&file); err != nil {
+			return nil, fmt.Errorf("failed to scan data export: %w", err)
+		}
+		if file != nil {
+			files = append(files, *file)
+		}
+	}
+	return files, rows.Err()
+}
@@ -3,12 +3,18 @@ package repositories
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/raihaninkam/finalPhase3/internals/models"
+	"github.com/raihaninkam/finalPhase3/internals/utils"
+	"github.com/raihaninkam/finalPhase3/pkg"
 	"github.com/redis/go-redis/v9"
 )
 
@@ -24,69 +30,487 @@ func NewFollowsRepository(db *pgxpool.Pool, rdb *redis.Client) *FollowRepository
 	}
 }
 
-func (fr *FollowRepository) GetFollowing(ctx context.Context, userID string) ([]models.UserProfile, error) {
-	// Try cache first
-	cacheKey := fmt.Sprintf("following:%s", userID)
-	cached, err := fr.rdb.Get(ctx, cacheKey).Result()
-	if err == nil {
-		var users []models.UserProfile
-		if err := json.Unmarshal([]byte(cached), &users); err == nil {
-			return users, nil
-		}
+// GetUserID mengambil id user berdasarkan username atau id
+func (fr *FollowRepository) GetUserID(ctx context.Context, usernameOrID string) (string, error) {
+	column := "username"
+	if utils.IsUUID(usernameOrID) {
+		column = "id"
+	}
+	var id string
+	err := fr.db.QueryRow(ctx, `SELECT id FROM users WHERE `+column+` = $1`, usernameOrID).Scan(&id)
+	if err == pgx.ErrNoRows {
+		return "", errors.New("user not found")
 	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get user: %w", err)
+	}
+	return id, nil
+}
 
-	// Get from database
-	sql := `
-		SELECT u.id, u.name, u.avatar_url, u.bio
+// GetFollowing mengambil daftar user yang di-follow, yang terakhir di-follow lebih dulu (keyset created_at + id).
+// Mengembalikan cursor halaman berikutnya, nil jika sudah habis
+func (fr *FollowRepository) GetFollowing(ctx context.Context, userID string, limit int, cursor *pkg.Cursor) ([]models.UserProfile, *pkg.Cursor, error) {
+	return fr.listFollows(ctx, fmt.Sprintf("following:%s", userID), `
+		SELECT u.id, u.username, u.name, u.avatar_url, u.bio, f.created_at
 		FROM follows f
 		JOIN users u ON f.following_id = u.id
 		WHERE f.follower_id = $1
-		ORDER BY f.created_at DESC
-	`
+		AND ($2::timestamptz IS NULL OR (f.created_at, f.following_id) < ($2, $3::uuid))
+		ORDER BY f.created_at DESC, f.following_id DESC
+		LIMIT $4
+	`, userID, limit, cursor, utils.CursorFollowing)
+}
 
-	rows, err := fr.db.Query(ctx, sql, userID)
+// GetFollowers mengambil daftar follower user, follower terbaru lebih dulu (keyset created_at + id).
+// Mengembalikan cursor halaman berikutnya, nil jika sudah habis
+func (fr *FollowRepository) GetFollowers(ctx context.Context, userID string, limit int, cursor *pkg.Cursor) ([]models.UserProfile, *pkg.Cursor, error) {
+	return fr.listFollows(ctx, fmt.Sprintf("followers:%s", userID), `
+		SELECT u.id, u.username, u.name, u.avatar_url, u.bio, f.created_at
+		FROM follows f
+		JOIN users u ON f.follower_id = u.id
+		WHERE f.following_id = $1
+		AND ($2::timestamptz IS NULL OR (f.created_at, f.follower_id) < ($2, $3::uuid))
+		ORDER BY f.created_at DESC, f.follower_id DESC
+		LIMIT $4
+	`, userID, limit, cursor, utils.CursorFollowers)
+}
+
+// listFollows menjalankan query daftar following / followers. Hanya halaman pertama yang di-cache,
+// disimpan per limit di dalam satu hash
+func (fr *FollowRepository) listFollows(ctx context.Context, cacheKey, sql, userID string, limit int, cursor *pkg.Cursor, kind string) ([]models.UserProfile, *pkg.Cursor, error) {
+	cacheField := strconv.Itoa(limit)
+	if cursor == nil {
+		cached, err := fr.rdb.HGet(ctx, cacheKey, cacheField).Result()
+		if err == nil {
+			var page userPage
+			if err := json.Unmarshal([]byte(cached), &page); err == nil {
+				return page.Users, page.Next, nil
+			}
+		}
+	}
+
+	var after *time.Time
+	var afterID *string
+	if cursor != nil {
+		after, afterID = &cursor.CreatedAt, &cursor.ID
+	}
+
+	// ambil satu baris lebih untuk mengetahui apakah masih ada halaman berikutnya
+	rows, err := fr.db.Query(ctx, sql, userID, after, afterID, limit+1)
 	if err != nil {
 		log.Printf("Query error: %v", err) // Log error
-		return nil, fmt.Errorf("failed to get following: %w", err)
+		return nil, nil, fmt.Errorf("failed to get follows: %w", err)
 	}
 	defer rows.Close()
 
-	var users []models.UserProfile
+	users := []models.UserProfile{}
+	var followedAt []time.Time
 	for rows.Next() {
 		var user models.UserProfile
-		if err := rows.Scan(&user.Id, &user.Name, &user.Avatar, &user.Bio); err != nil {
+		var createdAt time.Time
+		if err := rows.Scan(&user.Id, &user.Username, &user.Name, &user.Avatar, &user.Bio, &createdAt); err != nil {
 			log.Printf("Scan error: %v", err) // Log error
-			return nil, fmt.Errorf("failed to scan user: %w", err)
+			return nil, nil, fmt.Errorf("failed to scan user: %w", err)
 		}
 		users = append(users, user)
+		followedAt = append(followedAt, createdAt)
 	}
 
 	if err := rows.Err(); err != nil {
 		log.Printf("Rows error: %v", err) // Log error
-		return nil, err
+		return nil, nil, err
+	}
+
+	var next *pkg.Cursor
+	if len(users) > limit {
+		users = users[:limit]
+		next = pkg.NewCursor(kind, followedAt[limit-1], users[limit-1].Id)
 	}
 
 	// Cache the result
-	if len(users) > 0 {
-		usersJSON, _ := json.Marshal(users)
-		fr.rdb.Set(ctx, cacheKey, usersJSON, 5*time.Minute)
+	if cursor == nil && len(users) > 0 {
+		pageJSON, _ := json.Marshal(userPage{Users: users, Next: next})
+		fr.rdb.HSet(ctx, cacheKey, cacheField, pageJSON)
+		fr.rdb.Expire(ctx, cacheKey, 5*time.Minute)
 	}
 
-	return users, nil
+	return users, next, nil
 }
 
-func (fr *FollowRepository) Follow(ctx context.Context, followerID, followingID string) error {
+// userPage adalah bentuk cache halaman pertama daftar user
+type userPage struct {
+	Users []models.UserProfile `json:"users"`
+	Next  *pkg.Cursor          `json:"next"`
+}
+
+// lockFollowPair mengunci baris kedua user dengan urutan id yang tetap, sehingga follow / unfollow
+// yang berjalan bersamaan (misalnya A follow B dan B follow A) tidak deadlock saat mengubah counter
+func lockFollowPair(ctx context.Context, tx pgx.Tx, followerID, followingID string) error {
+	rows, err := tx.Query(ctx, `
+		SELECT id FROM users WHERE id IN ($1, $2) ORDER BY id FOR NO KEY UPDATE
+	`, followerID, followingID)
+	if err != nil {
+		return fmt.Errorf("failed to lock users: %w", err)
+	}
+	ids, err := collectIDs(rows)
+	if err != nil {
+		return fmt.Errorf("failed to lock users: %w", err)
+	}
+	if len(ids) != 2 {
+		return errors.New("user not found")
+	}
+	return nil
+}
+
+// Follow membuat relasi follow dan menaikkan follower_count / following_count dalam satu transaksi.
+// Jika akun tujuan private, yang dibuat adalah follow request (requested = true)
+func (fr *FollowRepository) Follow(ctx context.Context, followerID, followingID string) (requested bool, err error) {
+	tx, err := fr.db.Begin(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := lockFollowPair(ctx, tx, followerID, followingID); err != nil {
+		return false, err
+	}
+
+	blocked, err := isBlocked(ctx, tx, followerID, followingID)
+	if err != nil {
+		return false, err
+	}
+	if blocked {
+		return false, errors.New("user blocked")
+	}
+
+	var isPrivate, following bool
+	if err := tx.QueryRow(ctx, `
+		SELECT is_private, EXISTS(SELECT 1 FROM follows WHERE follower_id = $1 AND following_id = $2)
+		FROM users WHERE id = $2
+	`, followerID, followingID).Scan(&isPrivate, &following); err != nil {
+		return false, fmt.Errorf("failed to get user: %w", err)
+	}
+	if following {
+		return false, errors.New("already following")
+	}
+
+	if isPrivate {
+		tag, err := tx.Exec(ctx, `
+			INSERT INTO follow_requests (requester_id, target_id, created_at) VALUES ($1, $2, now())
+			ON CONFLICT (requester_id, target_id) DO NOTHING
+		`, followerID, followingID)
+		if err != nil {
+			return false, fmt.Errorf("failed to create follow request: %w", err)
+		}
+		if tag.RowsAffected() == 0 {
+			return false, errors.New("follow request already sent")
+		}
+		if err := notify(ctx, tx, notificationEvent{
+			recipientID: followingID,
+			actorID:     followerID,
+			kind:        models.NotificationFollowRequest,
+		}); err != nil {
+			return false, err
+		}
+		return true, tx.Commit(ctx)
+	}
+
+	if err := insertFollow(ctx, tx, followerID, followingID); err != nil {
+		return false, err
+	}
+	if err := notify(ctx, tx, notificationEvent{
+		recipientID: followingID,
+		actorID:     followerID,
+		kind:        models.NotificationFollow,
+	}); err != nil {
+		return false, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return false, err
+	}
+
+	fr.invalidateFollowCaches(ctx, followerID, followingID)
+	return false, nil
+}
+
+// insertFollow membuat relasi follow dan menaikkan counter, dipanggil di dalam transaksi setelah lockFollowPair
+func insertFollow(ctx context.Context, tx pgx.Tx, followerID, followingID string) error {
 	sql := `INSERT INTO follows (follower_id, following_id, created_at) 
-	        VALUES ($1, $2, now())`
+	        VALUES ($1, $2, now())
+	        ON CONFLICT (follower_id, following_id) DO NOTHING`
 
-	_, err := fr.db.Exec(ctx, sql, followerID, followingID)
+	tag, err := tx.Exec(ctx, sql, followerID, followingID)
 	if err != nil {
 		return fmt.Errorf("failed to follow user: %w", err)
 	}
+	if tag.RowsAffected() == 0 {
+		return errors.New("already following")
+	}
+	return updateFollowCounts(ctx, tx, followerID, followingID, 1)
+}
+
+// Unfollow menghapus relasi follow dan menurunkan follower_count / following_count dalam satu transaksi.
+// Jika belum di-follow tapi masih ada follow request, request tersebut yang dibatalkan
+func (fr *FollowRepository) Unfollow(ctx context.Context, followerID, followingID string) error {
+	tx, err := fr.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := lockFollowPair(ctx, tx, followerID, followingID); err != nil {
+		return err
+	}
+
+	tag, err := tx.Exec(ctx, `DELETE FROM follows WHERE follower_id = $1 AND following_id = $2`, followerID, followingID)
+	if err != nil {
+		return fmt.Errorf("failed to unfollow user: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		tag, err := tx.Exec(ctx, `DELETE FROM follow_requests WHERE requester_id = $1 AND target_id = $2`, followerID, followingID)
+		if err != nil {
+			return fmt.Errorf("failed to cancel follow request: %w", err)
+		}
+		if tag.RowsAffected() == 0 {
+			return errors.New("not following")
+		}
+		if err := retractNotification(ctx, tx, notificationEvent{
+			recipientID: followingID,
+			actorID:     followerID,
+			kind:        models.NotificationFollowRequest,
+		}); err != nil {
+			return err
+		}
+		return tx.Commit(ctx)
+	}
+
+	if err := updateFollowCounts(ctx, tx, followerID, followingID, -1); err != nil {
+		return err
+	}
+	if err := retractNotification(ctx, tx, notificationEvent{
+		recipientID: followingID,
+		actorID:     followerID,
+		kind:        models.NotificationFollow,
+	}); err != nil {
+		return err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return err
+	}
+
+	fr.invalidateFollowCaches(ctx, followerID, followingID)
+	return nil
+}
+
+// GetFollowRequests mengambil follow request yang masuk ke user, terbaru lebih dulu (keyset created_at + id)
+func (fr *FollowRepository) GetFollowRequests(ctx context.Context, userID string, limit int, cursor *pkg.Cursor) ([]models.FollowRequest, *pkg.Cursor, error) {
+	sql := `
+		SELECT u.id, u.username, u.name, u.avatar_url, u.bio, r.created_at
+		FROM follow_requests r
+		JOIN users u ON r.requester_id = u.id
+		WHERE r.target_id = $1
+		AND ($2::timestamptz IS NULL OR (r.created_at, r.requester_id) < ($2, $3::uuid))
+		ORDER BY r.created_at DESC, r.requester_id DESC
+		LIMIT $4
+	`
+
+	var after *time.Time
+	var afterID *string
+	if cursor != nil {
+		after, afterID = &cursor.CreatedAt, &cursor.ID
+	}
+
+	rows, err := fr.db.Query(ctx, sql, userID, after, afterID, limit+1)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get follow requests: %w", err)
+	}
+	defer rows.Close()
+
+	requests := []models.FollowRequest{}
+	for rows.Next() {
+		var request models.FollowRequest
+		if err := rows.Scan(&request.Id, &request.Username, &request.Name, &request.Avatar, &request.Bio, &request.RequestedAt); err != nil {
+			return nil, nil, fmt.Errorf("failed to scan follow request: %w", err)
+		}
+		requests = append(requests, request)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	var next *pkg.Cursor
+	if len(requests) > limit {
+		requests = requests[:limit]
+		last := requests[limit-1]
+		next = pkg.NewCursor(utils.CursorFollowRequests, last.RequestedAt, last.Id)
+	}
+	return requests, next, nil
+}
+
+// ApproveFollowRequest menyetujui follow request dari requester, requester langsung menjadi follower
+func (fr *FollowRepository) ApproveFollowRequest(ctx context.Context, userID, requesterID string) error {
+	tx, err := fr.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
 
-	// Invalidate cache
-	fr.rdb.Del(ctx, fmt.Sprintf("following:%s", followerID))
-	fr.rdb.Del(ctx, fmt.Sprintf("followers:%s", followingID))
+	if err := lockFollowPair(ctx, tx, requesterID, userID); err != nil {
+		return err
+	}
+
+	tag, err := tx.Exec(ctx, `DELETE FROM follow_requests WHERE requester_id = $1 AND target_id = $2`, requesterID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to approve follow request: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return errors.New("follow request not found")
+	}
+	if err := insertFollow(ctx, tx, requesterID, userID); err != nil && !strings.Contains(err.Error(), "already following") {
+		return err
+	}
+
+	// request sudah diproses: hapus dari notifikasi pemilik akun, beri tahu requester
+	if err := retractNotification(ctx, tx, notificationEvent{
+		recipientID: userID,
+		actorID:     requesterID,
+		kind:        models.NotificationFollowRequest,
+	}); err != nil {
+		return err
+	}
+	if err := notify(ctx, tx, notificationEvent{
+		recipientID: requesterID,
+		actorID:     userID,
+		kind:        models.NotificationFollowAccepted,
+	}); err != nil {
+		return err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return err
+	}
 
+	fr.invalidateFollowCaches(ctx, requesterID, userID)
+	return nil
+}
+
+// RejectFollowRequest menolak (menghapus) follow request dari requester
+func (fr *FollowRepository) RejectFollowRequest(ctx context.Context, userID, requesterID string) error {
+	tx, err := fr.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx, `DELETE FROM follow_requests WHERE requester_id = $1 AND target_id = $2`, requesterID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to reject follow request: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return errors.New("follow request not found")
+	}
+	if err := retractNotification(ctx, tx, notificationEvent{
+		recipientID: userID,
+		actorID:     requesterID,
+		kind:        models.NotificationFollowRequest,
+	}); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// SetPrivacy mengubah akun menjadi private / publik. Saat menjadi publik semua follow request yang
+// masih pending otomatis disetujui, id requester dikembalikan agar timeline-nya bisa diisi
+func (fr *FollowRepository) SetPrivacy(ctx context.Context, userID string, isPrivate bool) ([]string, error) {
+	tx, err := fr.db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx, `UPDATE users SET is_private = $1, updated_at = now() WHERE id = $2`, isPrivate, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update privacy: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return nil, errors.New("user not found")
+	}
+	if isPrivate {
+		return nil, tx.Commit(ctx)
+	}
+
+	rows, err := tx.Query(ctx, `
+		WITH approved AS (
+			DELETE FROM follow_requests WHERE target_id = $1 RETURNING requester_id
+		)
+		INSERT INTO follows (follower_id, following_id, created_at)
+		SELECT requester_id, $1, now() FROM approved
+		ON CONFLICT (follower_id, following_id) DO NOTHING
+		RETURNING follower_id
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to approve follow requests: %w", err)
+	}
+	approved, err := collectIDs(rows)
+	if err != nil {
+		return nil, fmt.Errorf("failed to approve follow requests: %w", err)
+	}
+	if len(approved) > 0 {
+		if _, err := tx.Exec(ctx, `UPDATE users SET following_count = following_count + 1 WHERE id = ANY($1)`, approved); err != nil {
+			return nil, fmt.Errorf("failed to update follow counts: %w", err)
+		}
+		if _, err := tx.Exec(ctx, `UPDATE users SET follower_count = follower_count + $1 WHERE id = $2`, len(approved), userID); err != nil {
+			return nil, fmt.Errorf("failed to update follow counts: %w", err)
+		}
+	}
+	// semua request sudah disetujui, notifikasinya tidak perlu ditindaklanjuti lagi
+	if _, err := tx.Exec(ctx, `DELETE FROM notifications WHERE user_id = $1 AND type = $2`, userID, models.NotificationFollowRequest); err != nil {
+		return nil, fmt.Errorf("failed to delete notifications: %w", err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+
+	for _, requesterID := range approved {
+		fr.invalidateFollowCaches(ctx, requesterID, userID)
+	}
+	return approved, nil
+}
+
+// CanView mengecek apakah viewer boleh melihat konten (post, komentar, follower) milik user
+func (fr *FollowRepository) CanView(ctx context.Context, viewerID, userID string) (bool, error) {
+	return canViewUser(ctx, fr.db, viewerID, userID)
+}
+
+// visibleToViewer adalah kondisi SQL bahwa konten milik author (kolom authorColumn) boleh dilihat
+// viewer (parameter viewerParam): tidak ada block di antara keduanya, dan akun publik, milik viewer sendiri,
+// atau viewer sudah menjadi follower
+func visibleToViewer(authorColumn, viewerParam string) string {
+	return fmt.Sprintf(`(NOT EXISTS (SELECT 1 FROM blocks
+			WHERE (blocker_id = %[1]s AND blocked_id = %[2]s) OR (blocker_id = %[2]s AND blocked_id = %[1]s))
+		AND (%[1]s = %[2]s
+			OR NOT EXISTS (SELECT 1 FROM users WHERE id = %[1]s AND is_private)
+			OR EXISTS (SELECT 1 FROM follows WHERE follower_id = %[2]s AND following_id = %[1]s)))`, authorColumn, viewerParam)
+}
+
+func canViewUser(ctx context.Context, db *pgxpool.Pool, viewerID, userID string) (bool, error) {
+	var visible bool
+	sql := `SELECT ` + visibleToViewer("$2::uuid", "$1::uuid")
+	if err := db.QueryRow(ctx, sql, viewerID, userID).Scan(&visible); err != nil {
+		return false, fmt.Errorf("failed to check visibility: %w", err)
+	}
+	return visible, nil
+}
+
+func (fr *FollowRepository) invalidateFollowCaches(ctx context.Context, followerID, followingID string) {
+	fr.rdb.Del(ctx, fmt.Sprintf("following:%s", followerID), fmt.Sprintf("followers:%s", followingID))
+}
+
+func updateFollowCounts(ctx context.Context, tx pgx.Tx, followerID, followingID string, delta int) error {
+	if _, err := tx.Exec(ctx, `UPDATE users SET following_count = following_count + $1 WHERE id = $2`, delta, followerID); err != nil {
+		return fmt.Errorf("failed to update follow counts: %w", err)
+	}
+	if _, err := tx.Exec(ctx, `UPDATE users SET follower_count = follower_count + $1 WHERE id = $2`, delta, followingID); err != nil {
+		return fmt.Errorf("failed to update follow counts: %w", err)
+	}
 	return nil
 }
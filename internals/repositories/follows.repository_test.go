@@ -0,0 +1,215 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/raihaninkam/finalPhase3/internals/utils"
+	"github.com/raihaninkam/finalPhase3/pkg"
+)
+
+// followCounts membaca follower_count dan following_count user
+func followCounts(t *testing.T, db *pgxpool.Pool, userID string) (followers, following int) {
+	t.Helper()
+	if err := db.QueryRow(context.Background(), `SELECT follower_count, following_count FROM users WHERE id = $1`, userID).Scan(&followers, &following); err != nil {
+		t.Fatal(err)
+	}
+	return followers, following
+}
+
+func TestFollowAndUnfollow(t *testing.T) {
+	db, rdb := newTestStores(t)
+	ctx := context.Background()
+	fr := NewFollowsRepository(db, rdb)
+
+	budi := createTestUser(t, db, rdb, "budi")
+	ani := createTestUser(t, db, rdb, "ani")
+
+	requested, err := fr.Follow(ctx, ani.ID, budi.ID)
+	if err != nil || requested {
+		t.Fatalf("Follow() = %v, %v", requested, err)
+	}
+	if _, err := fr.Follow(ctx, ani.ID, budi.ID); err == nil || err.Error() != "already following" {
+		t.Errorf("Follow() twice = %v, want already following", err)
+	}
+	if followers, _ := followCounts(t, db, budi.ID); followers != 1 {
+		t.Errorf("follower_count = %d, want 1", followers)
+	}
+	if _, following := followCounts(t, db, ani.ID); following != 1 {
+		t.Errorf("following_count = %d, want 1", following)
+	}
+
+	followers, _, err := fr.GetFollowers(ctx, budi.ID, 10, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(followers) != 1 || followers[0].Id != ani.ID {
+		t.Errorf("GetFollowers() = %+v", followers)
+	}
+	following, _, err := fr.GetFollowing(ctx, ani.ID, 10, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(following) != 1 || following[0].Id != budi.ID || following[0].Username != "budi" {
+		t.Errorf("GetFollowing() = %+v", following)
+	}
+
+	if err := fr.Unfollow(ctx, ani.ID, budi.ID); err != nil {
+		t.Fatalf("Unfollow() error: %v", err)
+	}
+	if err := fr.Unfollow(ctx, ani.ID, budi.ID); err == nil || err.Error() != "not following" {
+		t.Errorf("Unfollow() twice = %v, want not following", err)
+	}
+	if followers, _ := followCounts(t, db, budi.ID); followers != 0 {
+		t.Errorf("follower_count after unfollow = %d, want 0", followers)
+	}
+	// cache follower harus ikut dihapus
+	followers, _, err = fr.GetFollowers(ctx, budi.ID, 10, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(followers) != 0 {
+		t.Errorf("GetFollowers() after unfollow = %+v", followers)
+	}
+}
+
+func TestFollowPrivateAccount(t *testing.T) {
+	db, rdb := newTestStores(t)
+	ctx := context.Background()
+	fr := NewFollowsRepository(db, rdb)
+
+	budi := createTestUser(t, db, rdb, "budi")
+	ani := createTestUser(t, db, rdb, "ani")
+	if _, err := fr.SetPrivacy(ctx, budi.ID, true); err != nil {
+		t.Fatal(err)
+	}
+
+	requested, err := fr.Follow(ctx, ani.ID, budi.ID)
+	if err != nil || !requested {
+		t.Fatalf("Follow() private account = %v, %v, want requested", requested, err)
+	}
+	if followers, _ := followCounts(t, db, budi.ID); followers != 0 {
+		t.Errorf("follower_count before approval = %d, want 0", followers)
+	}
+	if _, err := fr.Follow(ctx, ani.ID, budi.ID); err == nil || err.Error() != "follow request already sent" {
+		t.Errorf("Follow() twice = %v", err)
+	}
+
+	if err := fr.ApproveFollowRequest(ctx, budi.ID, ani.ID); err != nil {
+		t.Fatalf("ApproveFollowRequest() error: %v", err)
+	}
+	if followers, _ := followCounts(t, db, budi.ID); followers != 1 {
+		t.Errorf("follower_count after approval = %d, want 1", followers)
+	}
+	if err := fr.ApproveFollowRequest(ctx, budi.ID, ani.ID); err == nil || err.Error() != "follow request not found" {
+		t.Errorf("ApproveFollowRequest() twice = %v", err)
+	}
+
+	// akun menjadi publik: request yang masih pending otomatis disetujui
+	cici := createTestUser(t, db, rdb, "cici")
+	if _, err := fr.Follow(ctx, cici.ID, budi.ID); err != nil {
+		t.Fatal(err)
+	}
+	approved, err := fr.SetPrivacy(ctx, budi.ID, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(approved) != 1 || approved[0] != cici.ID {
+		t.Errorf("SetPrivacy(false) approved = %v, want [%s]", approved, cici.ID)
+	}
+	if followers, _ := followCounts(t, db, budi.ID); followers != 2 {
+		t.Errorf("follower_count after going public = %d, want 2", followers)
+	}
+}
+
+func TestGetFollowingPagination(t *testing.T) {
+	db, rdb := newTestStores(t)
+	ctx := context.Background()
+	fr := NewFollowsRepository(db, rdb)
+
+	budi := createTestUser(t, db, rdb, "budi")
+	want := map[string]bool{}
+	for i := range 5 {
+		followed := createTestUser(t, db, rdb, fmt.Sprintf("followed%d", i))
+		if _, err := fr.Follow(ctx, budi.ID, followed.ID); err != nil {
+			t.Fatal(err)
+		}
+		want[followed.ID] = true
+	}
+
+	seen := map[string]bool{}
+	var cursor *pkg.Cursor
+	for pages := 0; ; pages++ {
+		if pages > 3 {
+			t.Fatal("pagination did not end")
+		}
+		following, next, err := fr.GetFollowing(ctx, budi.ID, 2, cursor)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, f := range following {
+			if seen[f.Id] {
+				t.Errorf("user %s returned twice", f.Id)
+			}
+			seen[f.Id] = true
+		}
+		if next == nil {
+			break
+		}
+		// cursor dikirim ke client dalam bentuk opaque lalu dibaca lagi seperti di handler
+		cursor, err = pkg.DecodeCursor(next.Encode(), utils.CursorFollowing)
+		if err != nil {
+			t.Fatalf("DecodeCursor() error: %v", err)
+		}
+	}
+	if len(seen) != len(want) {
+		t.Errorf("paginated following = %d, want %d", len(seen), len(want))
+	}
+}
+
+func TestGetFollowersPagination(t *testing.T) {
+	db, rdb := newTestStores(t)
+	ctx := context.Background()
+	fr := NewFollowsRepository(db, rdb)
+
+	budi := createTestUser(t, db, rdb, "budi")
+	want := map[string]bool{}
+	for i := range 5 {
+		follower := createTestUser(t, db, rdb, fmt.Sprintf("follower%d", i))
+		if _, err := fr.Follow(ctx, follower.ID, budi.ID); err != nil {
+			t.Fatal(err)
+		}
+		want[follower.ID] = true
+	}
+
+	seen := map[string]bool{}
+	var cursor *pkg.Cursor
+	for pages := 0; ; pages++ {
+		if pages > 3 {
+			t.Fatal("pagination did not end")
+		}
+		followers, next, err := fr.GetFollowers(ctx, budi.ID, 2, cursor)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, f := range followers {
+			if seen[f.Id] {
+				t.Errorf("follower %s returned twice", f.Id)
+			}
+			seen[f.Id] = true
+		}
+		if next == nil {
+			break
+		}
+		// cursor dikirim ke client dalam bentuk opaque lalu dibaca lagi seperti di handler
+		cursor, err = pkg.DecodeCursor(next.Encode(), utils.CursorFollowers)
+		if err != nil {
+			t.Fatalf("DecodeCursor() error: %v", err)
+		}
+	}
+	if len(seen) != len(want) {
+		t.Errorf("paginated followers = %d, want %d", len(seen), len(want))
+	}
+}
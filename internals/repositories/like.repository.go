@@ -6,7 +6,9 @@ import (
 	"fmt"
 	"log"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/raihaninkam/finalPhase3/internals/models"
 	"github.com/redis/go-redis/v9"
 )
 
@@ -46,6 +48,17 @@ func (lr *LikeRepository) LikePost(ctx context.Context, userID, postID string) e
 	}
 	defer tx.Rollback(ctx)
 
+	// post harus ada dan boleh dilihat user (bukan akun private yang belum di-follow / tidak ada block)
+	var authorID string
+	sql := `SELECT p.user_id FROM posts p WHERE p.id = $1 AND ` + visibleToViewer("p.user_id", "$2::uuid")
+	if err := tx.QueryRow(ctx, sql, postID, userID).Scan(&authorID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return errors.New("post not found")
+		}
+		log.Println("Failed to get post:", err.Error())
+		return err
+	}
+
 	// Insert like
 	query := `INSERT INTO likes (user_id, post_id) VALUES ($1, $2)`
 	_, err = tx.Exec(ctx, query, userID, postID)
@@ -54,12 +67,24 @@ func (lr *LikeRepository) LikePost(ctx context.Context, userID, postID string) e
 		return err
 	}
 
+	if err := notify(ctx, tx, notificationEvent{
+		recipientID: authorID,
+		actorID:     userID,
+		kind:        models.NotificationLike,
+		postID:      &postID,
+	}); err != nil {
+		return err
+	}
+
 	// Commit transaction
 	if err := tx.Commit(ctx); err != nil {
 		log.Println("Failed to commit transaction:", err.Error())
 		return err
 	}
 
+	// Invalidate cache detail post (like_count berubah)
+	lr.rdb.Del(ctx, fmt.Sprintf("post:%s", postID))
+
 	return nil
 }
 
@@ -86,11 +111,29 @@ func (lr *LikeRepository) UnlikePost(ctx context.Context, userID, postID string)
 		return errors.New("like not found")
 	}
 
+	// like dibatalkan, hapus juga dari notifikasi pemilik post
+	var authorID string
+	if err := tx.QueryRow(ctx, `SELECT user_id FROM posts WHERE id = $1`, postID).Scan(&authorID); err != nil {
+		log.Println("Failed to get post:", err.Error())
+		return err
+	}
+	if err := retractNotification(ctx, tx, notificationEvent{
+		recipientID: authorID,
+		actorID:     userID,
+		kind:        models.NotificationLike,
+		postID:      &postID,
+	}); err != nil {
+		return err
+	}
+
 	// Commit transaction
 	if err := tx.Commit(ctx); err != nil {
 		log.Println("Failed to commit transaction:", err.Error())
 		return err
 	}
 
+	// Invalidate cache detail post (like_count berubah)
+	lr.rdb.Del(ctx, fmt.Sprintf("post:%s", postID))
+
 	return nil
 }
@@ -0,0 +1,112 @@
+package repositories
+
+import (
+	"context"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/raihaninkam/finalPhase3/internals/utils"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// jumlah gagal login sebelum akun / IP dikunci sementara
+	accountFailThreshold = 5
+	ipFailThreshold      = 20
+	// durasi kunci pertama, berlipat dua setiap gagal berikutnya sampai loginLockMax
+	loginLockBase = 30 * time.Second
+	loginLockMax  = time.Hour
+	// counter gagal login di-reset jika tidak ada percobaan gagal selama window ini
+	loginFailWindow = time.Hour
+)
+
+type LoginAttemptRepository struct {
+	rdb *redis.Client
+}
+
+func NewLoginAttemptRepository(rdb *redis.Client) *LoginAttemptRepository {
+	return &LoginAttemptRepository{rdb: rdb}
+}
+
+// GetLockout mengecek apakah IP atau akun sedang dikunci. Mengembalikan sisa waktu kunci IP dan akun
+func (lr *LoginAttemptRepository) GetLockout(ctx context.Context, email, ip string) (time.Duration, time.Duration, error) {
+	pipe := lr.rdb.Pipeline()
+	ipTTL := pipe.TTL(ctx, utils.RedisKey("login-lock", "ip", ip))
+	accountTTL := pipe.TTL(ctx, utils.RedisKey("login-lock", "account", normalizeEmail(email)))
+	if _, err := pipe.Exec(ctx); err != nil {
+		log.Println("Failed to check login lockout.\nCause: ", err.Error())
+		return 0, 0, err
+	}
+	return max(ipTTL.Val(), 0), max(accountTTL.Val(), 0), nil
+}
+
+// RegisterFailure mencatat gagal login untuk akun dan IP. Jika melewati batas, akun / IP dikunci dengan
+// durasi yang berlipat (exponential backoff). Mengembalikan durasi kunci akun jika akun baru saja dikunci
+func (lr *LoginAttemptRepository) RegisterFailure(ctx context.Context, email, ip string) (time.Duration, error) {
+	email = normalizeEmail(email)
+
+	accountFails, err := lr.incrFailure(ctx, utils.RedisKey("login-fail", "account", email))
+	if err != nil {
+		return 0, err
+	}
+	ipFails, err := lr.incrFailure(ctx, utils.RedisKey("login-fail", "ip", ip))
+	if err != nil {
+		return 0, err
+	}
+
+	if ipFails >= ipFailThreshold {
+		if err := lr.rdb.Set(ctx, utils.RedisKey("login-lock", "ip", ip), "1", lockDuration(ipFails-ipFailThreshold)).Err(); err != nil {
+			log.Println("Failed to lock ip.\nCause: ", err.Error())
+			return 0, err
+		}
+	}
+
+	if accountFails >= accountFailThreshold {
+		duration := lockDuration(accountFails - accountFailThreshold)
+		if err := lr.rdb.Set(ctx, utils.RedisKey("login-lock", "account", email), "1", duration).Err(); err != nil {
+			log.Println("Failed to lock account.\nCause: ", err.Error())
+			return 0, err
+		}
+		return duration, nil
+	}
+	return 0, nil
+}
+
+// ResetAccount menghapus counter gagal login dan kunci akun (login sukses / unlock).
+// Counter IP sengaja tidak di-reset agar penyerang tidak bisa me-reset-nya dengan login ke akun sendiri
+func (lr *LoginAttemptRepository) ResetAccount(ctx context.Context, email string) error {
+	email = normalizeEmail(email)
+	if err := lr.rdb.Del(ctx,
+		utils.RedisKey("login-fail", "account", email),
+		utils.RedisKey("login-lock", "account", email),
+	).Err(); err != nil {
+		log.Println("Failed to reset login attempts.\nCause: ", err.Error())
+		return err
+	}
+	return nil
+}
+
+func (lr *LoginAttemptRepository) incrFailure(ctx context.Context, key string) (int64, error) {
+	pipe := lr.rdb.TxPipeline()
+	count := pipe.Incr(ctx, key)
+	pipe.Expire(ctx, key, loginFailWindow)
+	if _, err := pipe.Exec(ctx); err != nil {
+		log.Println("Failed to register login failure.\nCause: ", err.Error())
+		return 0, err
+	}
+	return count.Val(), nil
+}
+
+// lockDuration menghitung durasi kunci: loginLockBase * 2^n, maksimal loginLockMax
+func lockDuration(n int64) time.Duration {
+	duration := loginLockBase
+	for i := int64(0); i < n && duration < loginLockMax; i++ {
+		duration *= 2
+	}
+	return min(duration, loginLockMax)
+}
+
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
@@ -0,0 +1,113 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/raihaninkam/finalPhase3/internals/configs"
+	"github.com/raihaninkam/finalPhase3/internals/models"
+	"github.com/raihaninkam/finalPhase3/pkg"
+	"github.com/redis/go-redis/v9"
+)
+
+// testSchema adalah schema postgres khusus test package ini, agar tidak bentrok dengan test package lain
+const testSchema = "repositories_test"
+
+func TestMain(m *testing.M) {
+	if os.Getenv("CURSOR_SECRET") == "" {
+		os.Setenv("CURSOR_SECRET", "test-cursor-secret")
+	}
+	os.Exit(m.Run())
+}
+
+// newTestStores menyiapkan database yang baru dimigrasi dan redis yang kosong untuk satu test.
+// Test di-skip jika TEST_DATABASE_URL atau TEST_REDIS_URL tidak diisi.
+// TEST_REDIS_URL harus menunjuk ke database redis khusus test karena isinya di-FLUSHDB
+func newTestStores(t *testing.T) (*pgxpool.Pool, *redis.Client) {
+	t.Helper()
+	dbURL, redisURL := os.Getenv("TEST_DATABASE_URL"), os.Getenv("TEST_REDIS_URL")
+	if dbURL == "" || redisURL == "" {
+		t.Skip("TEST_DATABASE_URL and TEST_REDIS_URL are not set")
+	}
+	ctx := context.Background()
+
+	config, err := pgxpool.ParseConfig(dbURL)
+	if err != nil {
+		t.Fatalf("invalid TEST_DATABASE_URL: %v", err)
+	}
+	config.ConnConfig.RuntimeParams["search_path"] = testSchema
+	db, err := pgxpool.NewWithConfig(ctx, config)
+	if err != nil {
+		t.Fatalf("failed to connect to test database: %v", err)
+	}
+	t.Cleanup(db.Close)
+
+	if _, err := db.Exec(ctx, `DROP SCHEMA IF EXISTS `+testSchema+` CASCADE; CREATE SCHEMA `+testSchema); err != nil {
+		t.Fatalf("failed to reset test schema: %v", err)
+	}
+	if _, err := configs.MigrateUp(ctx, db); err != nil {
+		t.Fatalf("failed to migrate test database: %v", err)
+	}
+	if err := configs.CheckSchema(db); err != nil {
+		t.Fatalf("test database is not usable: %v", err)
+	}
+
+	return db, newTestRedis(t)
+}
+
+// newTestRedis menyiapkan redis yang kosong untuk test yang tidak butuh database.
+// Test di-skip jika TEST_REDIS_URL tidak diisi
+func newTestRedis(t *testing.T) *redis.Client {
+	t.Helper()
+	redisURL := os.Getenv("TEST_REDIS_URL")
+	if redisURL == "" {
+		t.Skip("TEST_REDIS_URL is not set")
+	}
+	opt, err := redis.ParseURL(redisURL)
+	if err != nil {
+		t.Fatalf("invalid TEST_REDIS_URL: %v", err)
+	}
+	rdb := redis.NewClient(opt)
+	t.Cleanup(func() { rdb.Close() })
+	if err := rdb.FlushDB(context.Background()).Err(); err != nil {
+		t.Fatalf("failed to flush test redis: %v", err)
+	}
+	return rdb
+}
+
+const testPassword = "Secret123!"
+
+// createTestUser mendaftarkan user seperti handler register: password di-hash lalu disimpan lewat CreateAccount
+func createTestUser(t *testing.T, db *pgxpool.Pool, rdb *redis.Client, username string) *models.User {
+	t.Helper()
+	hashCfg := pkg.NewHashConfig()
+	hashCfg.UseRecommended()
+	hash, err := hashCfg.GenHash(testPassword)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	user := &models.User{
+		Username: username,
+		Email:    fmt.Sprintf("%s@example.com", username),
+		Password: hash,
+		Name:     &username,
+	}
+	if err := NewAuthRepository(db, rdb).CreateAccount(context.Background(), user); err != nil {
+		t.Fatalf("failed to create user %s: %v", username, err)
+	}
+	return user
+}
+
+// createTestPost membuat post milik userID
+func createTestPost(t *testing.T, db *pgxpool.Pool, rdb *redis.Client, userID, content string) *models.Posts {
+	t.Helper()
+	post, err := NewPostRepository(db, rdb).CreatePost(context.Background(), &models.Posts{UserId: userID, Content: content})
+	if err != nil {
+		t.Fatalf("failed to create post: %v", err)
+	}
+	return post
+}
@@ -0,0 +1,150 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/raihaninkam/finalPhase3/internals/utils"
+	"github.com/raihaninkam/finalPhase3/pkg"
+	"github.com/redis/go-redis/v9"
+)
+
+type MfaRepository struct {
+	db  *pgxpool.Pool
+	rdb *redis.Client
+}
+
+func NewMfaRepository(db *pgxpool.Pool, rdb *redis.Client) *MfaRepository {
+	return &MfaRepository{
+		db:  db,
+		rdb: rdb,
+	}
+}
+
+// GetSecret mengambil secret TOTP user beserta status aktif 2FA
+func (mr *MfaRepository) GetSecret(ctx context.Context, userID string) (string, bool, error) {
+	sql := `SELECT COALESCE(mfa_secret, ''), mfa_enabled FROM users WHERE id = $1`
+
+	var secret string
+	var enabled bool
+	if err := mr.db.QueryRow(ctx, sql, userID).Scan(&secret, &enabled); err != nil {
+		if err == pgx.ErrNoRows {
+			return "", false, errors.New("user not found")
+		}
+		log.Println("Failed to get mfa secret.\nCause: ", err.Error())
+		return "", false, err
+	}
+	return secret, enabled, nil
+}
+
+// SetPendingSecret menyimpan secret TOTP baru yang belum aktif sampai dikonfirmasi dengan kode pertama
+func (mr *MfaRepository) SetPendingSecret(ctx context.Context, userID, secret string) error {
+	sql := `UPDATE users SET mfa_secret = $1, updated_at = now() WHERE id = $2 AND mfa_enabled = FALSE`
+
+	result, err := mr.db.Exec(ctx, sql, secret, userID)
+	if err != nil {
+		log.Println("Failed to set mfa secret.\nCause: ", err.Error())
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return errors.New("mfa already enabled")
+	}
+	return nil
+}
+
+// Enable mengaktifkan 2FA dan mengganti recovery code dengan yang baru (disimpan dalam bentuk hash)
+func (mr *MfaRepository) Enable(ctx context.Context, userID string, recoveryCodes []string) error {
+	tx, err := mr.db.Begin(ctx)
+	if err != nil {
+		log.Println("Failed to begin transaction:", err.Error())
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	result, err := tx.Exec(ctx, `UPDATE users SET mfa_enabled = TRUE, updated_at = now() WHERE id = $1 AND mfa_secret IS NOT NULL`, userID)
+	if err != nil {
+		log.Println("Failed to enable mfa.\nCause: ", err.Error())
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return errors.New("mfa not enrolled")
+	}
+
+	if err := replaceRecoveryCodes(ctx, tx, userID, recoveryCodes); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		log.Println("Failed to commit transaction:", err.Error())
+		return err
+	}
+	return nil
+}
+
+// Disable menonaktifkan 2FA, menghapus secret dan semua recovery code
+func (mr *MfaRepository) Disable(ctx context.Context, userID string) error {
+	tx, err := mr.db.Begin(ctx)
+	if err != nil {
+		log.Println("Failed to begin transaction:", err.Error())
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `UPDATE users SET mfa_enabled = FALSE, mfa_secret = NULL, updated_at = now() WHERE id = $1`, userID); err != nil {
+		log.Println("Failed to disable mfa.\nCause: ", err.Error())
+		return err
+	}
+	if err := replaceRecoveryCodes(ctx, tx, userID, nil); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		log.Println("Failed to commit transaction:", err.Error())
+		return err
+	}
+	return nil
+}
+
+// UseRecoveryCode memakai satu recovery code, mengembalikan false jika tidak cocok atau sudah dipakai
+func (mr *MfaRepository) UseRecoveryCode(ctx context.Context, userID, code string) (bool, error) {
+	sql := `UPDATE mfa_recovery_codes SET used_at = now()
+	        WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL`
+
+	result, err := mr.db.Exec(ctx, sql, userID, pkg.HashToken(code))
+	if err != nil {
+		log.Println("Failed to use recovery code.\nCause: ", err.Error())
+		return false, err
+	}
+	return result.RowsAffected() > 0, nil
+}
+
+// MarkStepUsed mencegah kode TOTP yang sama dipakai dua kali (replay).
+// Mengembalikan false jika time step tersebut sudah pernah dipakai
+func (mr *MfaRepository) MarkStepUsed(ctx context.Context, userID string, step int64) (bool, error) {
+	key := utils.RedisKey("mfa-step", userID, fmt.Sprint(step))
+	ok, err := mr.rdb.SetNX(ctx, key, "1", 2*time.Minute).Result()
+	if err != nil {
+		log.Println("Failed to mark totp step.\nCause: ", err.Error())
+		return false, err
+	}
+	return ok, nil
+}
+
+func replaceRecoveryCodes(ctx context.Context, tx pgx.Tx, userID string, codes []string) error {
+	if _, err := tx.Exec(ctx, `DELETE FROM mfa_recovery_codes WHERE user_id = $1`, userID); err != nil {
+		log.Println("Failed to delete recovery codes.\nCause: ", err.Error())
+		return err
+	}
+	for _, code := range codes {
+		if _, err := tx.Exec(ctx, `INSERT INTO mfa_recovery_codes (user_id, code_hash) VALUES ($1, $2)`, userID, pkg.HashToken(code)); err != nil {
+			log.Println("Failed to insert recovery code.\nCause: ", err.Error())
+			return err
+		}
+	}
+	return nil
+}
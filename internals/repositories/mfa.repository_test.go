@@ -0,0 +1,44 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/raihaninkam/finalPhase3/internals/utils"
+	"github.com/raihaninkam/finalPhase3/pkg"
+)
+
+func TestMarkStepUsedRejectsReplay(t *testing.T) {
+	rdb := newTestRedis(t)
+	ctx := context.Background()
+	mr := NewMfaRepository(nil, rdb)
+
+	// secret dan kode dari RFC 6238 appendix B, kode "287082" berlaku pada detik 59
+	secret, code, at := "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ", "287082", time.Unix(59, 0)
+
+	// kode kedua kalinya tetap valid secara TOTP, replay hanya ditolak oleh MarkStepUsed
+	for i, want := range []bool{true, false} {
+		step, ok := pkg.ValidateTOTP(secret, code, at)
+		if !ok {
+			t.Fatalf("use %d: ValidateTOTP() = false", i+1)
+		}
+		used, err := mr.MarkStepUsed(ctx, "user-1", step)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if used != want {
+			t.Errorf("use %d: MarkStepUsed() = %t, want %t", i+1, used, want)
+		}
+	}
+
+	// step yang sama milik user lain tidak terpengaruh
+	if used, err := mr.MarkStepUsed(ctx, "user-2", at.Unix()/30); err != nil || !used {
+		t.Errorf("MarkStepUsed() for another user = %t, %v, want true", used, err)
+	}
+	// penanda step dihapus sendiri setelah kode pasti kedaluwarsa
+	if ttl := rdb.TTL(ctx, utils.RedisKey("mfa-step", "user-1", fmt.Sprint(at.Unix()/30))).Val(); ttl <= 0 {
+		t.Errorf("step key ttl = %v, want > 0", ttl)
+	}
+}
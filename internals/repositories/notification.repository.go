@@ -0,0 +1,274 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/raihaninkam/finalPhase3/internals/models"
+	"github.com/raihaninkam/finalPhase3/internals/utils"
+	"github.com/raihaninkam/finalPhase3/pkg"
+	"github.com/redis/go-redis/v9"
+)
+
+type NotificationRepository struct {
+	db  *pgxpool.Pool
+	rdb *redis.Client
+}
+
+func NewNotificationRepository(db *pgxpool.Pool, rdb *redis.Client) *NotificationRepository {
+	return &NotificationRepository{
+		db:  db,
+		rdb: rdb,
+	}
+}
+
+// notificationEvent adalah satu kejadian yang dicatat ke notifikasi penerima
+type notificationEvent struct {
+	recipientID string
+	actorID     string
+	kind        string
+	postID      *string
+	commentID   *string
+}
+
+// groupKey menentukan notifikasi mana yang digabung: like / comment per post, follow per penerima,
+// mention tidak digabung (satu notifikasi per post / komentar)
+func (e notificationEvent) groupKey() string {
+	switch e.kind {
+	case models.NotificationLike, models.NotificationComment:
+		return e.kind + ":" + *e.postID
+	case models.NotificationMention:
+		if e.commentID != nil {
+			return e.kind + ":comment:" + *e.commentID
+		}
+		return e.kind + ":post:" + *e.postID
+	default:
+		return e.kind
+	}
+}
+
+// notify mencatat event ke notifikasi penerima di dalam transaksi yang memicu event tsb. Event digabung ke
+// notifikasi yang belum dibaca dengan group yang sama. Event dari diri sendiri atau dari user yang di-mute
+// penerima diabaikan
+func notify(ctx context.Context, tx pgx.Tx, event notificationEvent) error {
+	sql := `
+		WITH n AS (
+			INSERT INTO notifications (user_id, type, group_key, post_id, comment_id)
+			SELECT $1, $3, $4, $5, $6
+			WHERE $1::uuid <> $2::uuid
+			AND NOT EXISTS (SELECT 1 FROM mutes WHERE muter_id = $1 AND muted_id = $2)
+			ON CONFLICT (user_id, group_key) WHERE read_at IS NULL
+			DO UPDATE SET comment_id = COALESCE(EXCLUDED.comment_id, notifications.comment_id), updated_at = now()
+			RETURNING id
+		)
+		INSERT INTO notification_actors (notification_id, actor_id, created_at)
+		SELECT id, $2, now() FROM n
+		ON CONFLICT (notification_id, actor_id) DO UPDATE SET created_at = now()
+	`
+
+	if _, err := tx.Exec(ctx, sql, event.recipientID, event.actorID, event.kind, event.groupKey(), event.postID, event.commentID); err != nil {
+		return fmt.Errorf("failed to create notification: %w", err)
+	}
+	return nil
+}
+
+// notifyMentions mencatat notifikasi mention untuk setiap user yang di-mention di content dan boleh melihat post tsb
+func notifyMentions(ctx context.Context, tx pgx.Tx, actorID, postID string, commentID *string, content string) error {
+	usernames := utils.ExtractMentions(content)
+	if len(usernames) == 0 {
+		return nil
+	}
+
+	rows, err := tx.Query(ctx, `
+		SELECT u.id FROM users u
+		JOIN posts p ON p.id = $1
+		WHERE u.username = ANY($2)
+		AND `+visibleToViewer("p.user_id", "u.id"), postID, usernames)
+	if err != nil {
+		return fmt.Errorf("failed to get mentioned users: %w", err)
+	}
+	recipientIDs, err := collectIDs(rows)
+	if err != nil {
+		return err
+	}
+
+	for _, recipientID := range recipientIDs {
+		if err := notify(ctx, tx, notificationEvent{
+			recipientID: recipientID,
+			actorID:     actorID,
+			kind:        models.NotificationMention,
+			postID:      &postID,
+			commentID:   commentID,
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// retractNotification menghapus actor dari notifikasi (misalnya setelah unlike / unfollow).
+// Notifikasi yang tidak punya actor lagi ikut dihapus
+func retractNotification(ctx context.Context, tx pgx.Tx, event notificationEvent) error {
+	if _, err := tx.Exec(ctx, `
+		DELETE FROM notification_actors a USING notifications n
+		WHERE a.notification_id = n.id AND n.user_id = $1 AND n.group_key = $2 AND a.actor_id = $3
+	`, event.recipientID, event.groupKey(), event.actorID); err != nil {
+		return fmt.Errorf("failed to retract notification: %w", err)
+	}
+	return deleteEmptyNotifications(ctx, tx, event.recipientID)
+}
+
+// deleteEmptyNotifications menghapus notifikasi milik user yang sudah tidak punya actor
+func deleteEmptyNotifications(ctx context.Context, tx pgx.Tx, userIDs ...string) error {
+	if _, err := tx.Exec(ctx, `
+		DELETE FROM notifications n
+		WHERE n.user_id = ANY($1)
+		AND NOT EXISTS (SELECT 1 FROM notification_actors a WHERE a.notification_id = n.id)
+	`, userIDs); err != nil {
+		return fmt.Errorf("failed to delete notifications: %w", err)
+	}
+	return nil
+}
+
+// GetNotifications mengambil notifikasi user, yang terakhir diperbarui lebih dulu (keyset updated_at + id).
+// Mengembalikan cursor halaman berikutnya, nil jika sudah habis
+func (nr *NotificationRepository) GetNotifications(ctx context.Context, userID string, limit int, cursor *pkg.Cursor) ([]models.Notification, *pkg.Cursor, error) {
+	sql := `
+		SELECT n.id, n.type, n.post_id, n.comment_id, n.read_at IS NOT NULL, n.created_at, n.updated_at,
+			a.actor_count, u.id, u.username, u.name, u.avatar_url, u.bio
+		FROM notifications n
+		JOIN LATERAL (
+			SELECT actor_id, COUNT(*) OVER () AS actor_count
+			FROM notification_actors
+			WHERE notification_id = n.id
+			ORDER BY created_at DESC
+			LIMIT 1
+		) a ON true
+		JOIN users u ON u.id = a.actor_id
+		WHERE n.user_id = $1
+		AND ($2::timestamptz IS NULL OR (n.updated_at, n.id) < ($2, $3::uuid))
+		ORDER BY n.updated_at DESC, n.id DESC
+		LIMIT $4
+	`
+
+	var after *time.Time
+	var afterID *string
+	if cursor != nil {
+		after, afterID = &cursor.CreatedAt, &cursor.ID
+	}
+
+	// ambil satu baris lebih untuk mengetahui apakah masih ada halaman berikutnya
+	rows, err := nr.db.Query(ctx, sql, userID, after, afterID, limit+1)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get notifications: %w", err)
+	}
+	defer rows.Close()
+
+	notifications := []models.Notification{}
+	for rows.Next() {
+		var n models.Notification
+		if err := rows.Scan(
+			&n.Id,
+			&n.Type,
+			&n.PostId,
+			&n.CommentId,
+			&n.IsRead,
+			&n.CreatedAt,
+			&n.UpdatedAt,
+			&n.ActorCount,
+			&n.Actor.Id,
+			&n.Actor.Username,
+			&n.Actor.Name,
+			&n.Actor.Avatar,
+			&n.Actor.Bio,
+		); err != nil {
+			return nil, nil, fmt.Errorf("failed to scan notification: %w", err)
+		}
+		n.Message = notificationMessage(&n)
+		notifications = append(notifications, n)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	var next *pkg.Cursor
+	if len(notifications) > limit {
+		notifications = notifications[:limit]
+		last := notifications[limit-1]
+		next = pkg.NewCursor(utils.CursorNotifications, last.UpdatedAt, last.Id)
+	}
+	return notifications, next, nil
+}
+
+// notificationMessage menyusun teks notifikasi, misalnya "budi and 4 others liked your post"
+func notificationMessage(n *models.Notification) string {
+	actors := andOthers(n.Actor.Username, n.ActorCount)
+	switch n.Type {
+	case models.NotificationLike:
+		return actors + " liked your post"
+	case models.NotificationComment:
+		return actors + " commented on your post"
+	case models.NotificationFollow:
+		return actors + " started following you"
+	case models.NotificationFollowRequest:
+		return actors + " requested to follow you"
+	case models.NotificationFollowAccepted:
+		return actors + " accepted your follow request"
+	case models.NotificationMention:
+		if n.CommentId != nil {
+			return actors + " mentioned you in a comment"
+		}
+		return actors + " mentioned you in a post"
+	default:
+		return actors
+	}
+}
+
+// andOthers menyingkat daftar user menjadi "budi", "budi and 1 other" atau "budi and 3 others"
+func andOthers(name string, total int) string {
+	switch {
+	case total <= 1:
+		return name
+	case total == 2:
+		return name + " and 1 other"
+	default:
+		return fmt.Sprintf("%s and %d others", name, total-1)
+	}
+}
+
+// GetUnreadCount menghitung notifikasi (yang sudah digabung) yang belum dibaca
+func (nr *NotificationRepository) GetUnreadCount(ctx context.Context, userID string) (int, error) {
+	sql := `
+		SELECT COUNT(*) FROM notifications n
+		WHERE n.user_id = $1 AND n.read_at IS NULL
+		AND EXISTS (SELECT 1 FROM notification_actors a WHERE a.notification_id = n.id)
+	`
+
+	var count int
+	if err := nr.db.QueryRow(ctx, sql, userID).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count unread notifications: %w", err)
+	}
+	return count, nil
+}
+
+// MarkAsRead menandai notifikasi user sudah dibaca. ids kosong berarti semua notifikasi yang belum dibaca.
+// Mengembalikan jumlah notifikasi yang cocok (untuk ids, termasuk yang sudah dibaca sebelumnya)
+func (nr *NotificationRepository) MarkAsRead(ctx context.Context, userID string, ids []string) (int64, error) {
+	sql := `
+		UPDATE notifications SET read_at = COALESCE(read_at, now())
+		WHERE user_id = $1
+		AND (($2::uuid[] IS NULL AND read_at IS NULL) OR id = ANY($2))
+	`
+	if len(ids) == 0 {
+		ids = nil
+	}
+
+	tag, err := nr.db.Exec(ctx, sql, userID, ids)
+	if err != nil {
+		return 0, fmt.Errorf("failed to mark notifications as read: %w", err)
+	}
+	return tag.RowsAffected(), nil
+}
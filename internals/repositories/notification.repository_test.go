@@ -0,0 +1,153 @@
+package repositories
+
+import (
+	"context"
+	"testing"
+
+	"github.com/raihaninkam/finalPhase3/internals/models"
+)
+
+func TestLikeNotificationsAreGrouped(t *testing.T) {
+	db, rdb := newTestStores(t)
+	ctx := context.Background()
+	lr := NewLikeRepository(db, rdb)
+	nr := NewNotificationRepository(db, rdb)
+
+	author := createTestUser(t, db, rdb, "budi")
+	ani := createTestUser(t, db, rdb, "ani")
+	cici := createTestUser(t, db, rdb, "cici")
+	post := createTestPost(t, db, rdb, author.ID, "hello")
+
+	// like dari diri sendiri tidak dicatat
+	for _, userID := range []string{author.ID, ani.ID, cici.ID} {
+		if err := lr.LikePost(ctx, userID, post.Id); err != nil {
+			t.Fatalf("LikePost() error: %v", err)
+		}
+	}
+
+	notifications, next, err := nr.GetNotifications(ctx, author.ID, 10, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(notifications) != 1 || next != nil {
+		t.Fatalf("GetNotifications() = %+v, want one grouped notification", notifications)
+	}
+	n := notifications[0]
+	if n.Type != models.NotificationLike || n.ActorCount != 2 || n.Actor.Id != cici.ID {
+		t.Errorf("notification = %+v", n)
+	}
+	if n.Message != "cici and 1 other liked your post" {
+		t.Errorf("message = %q", n.Message)
+	}
+
+	// unlike menghapus actor dari notifikasi, notifikasi tanpa actor ikut dihapus
+	if err := lr.UnlikePost(ctx, cici.ID, post.Id); err != nil {
+		t.Fatal(err)
+	}
+	notifications, _, err = nr.GetNotifications(ctx, author.ID, 10, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(notifications) != 1 || notifications[0].Message != "ani liked your post" {
+		t.Errorf("notifications after unlike = %+v", notifications)
+	}
+	if err := lr.UnlikePost(ctx, ani.ID, post.Id); err != nil {
+		t.Fatal(err)
+	}
+	if count, err := nr.GetUnreadCount(ctx, author.ID); err != nil || count != 0 {
+		t.Errorf("GetUnreadCount() after all unlikes = %d, %v", count, err)
+	}
+}
+
+func TestMarkNotificationsAsRead(t *testing.T) {
+	db, rdb := newTestStores(t)
+	ctx := context.Background()
+	nr := NewNotificationRepository(db, rdb)
+	fr := NewFollowsRepository(db, rdb)
+
+	budi := createTestUser(t, db, rdb, "budi")
+	ani := createTestUser(t, db, rdb, "ani")
+	post := createTestPost(t, db, rdb, budi.ID, "hello")
+	if _, err := fr.Follow(ctx, ani.ID, budi.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := NewLikeRepository(db, rdb).LikePost(ctx, ani.ID, post.Id); err != nil {
+		t.Fatal(err)
+	}
+
+	if count, err := nr.GetUnreadCount(ctx, budi.ID); err != nil || count != 2 {
+		t.Fatalf("GetUnreadCount() = %d, %v, want 2", count, err)
+	}
+	notifications, _, err := nr.GetNotifications(ctx, budi.ID, 10, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// hanya notifikasi milik user yang bisa ditandai
+	if marked, err := nr.MarkAsRead(ctx, ani.ID, []string{notifications[0].Id}); err != nil || marked != 0 {
+		t.Errorf("MarkAsRead() other user's notification = %d, %v", marked, err)
+	}
+	if marked, err := nr.MarkAsRead(ctx, budi.ID, []string{notifications[0].Id}); err != nil || marked != 1 {
+		t.Errorf("MarkAsRead() = %d, %v, want 1", marked, err)
+	}
+	if count, _ := nr.GetUnreadCount(ctx, budi.ID); count != 1 {
+		t.Errorf("GetUnreadCount() after marking one = %d, want 1", count)
+	}
+	if marked, err := nr.MarkAsRead(ctx, budi.ID, nil); err != nil || marked != 1 {
+		t.Errorf("MarkAsRead() all = %d, %v, want 1", marked, err)
+	}
+	if count, _ := nr.GetUnreadCount(ctx, budi.ID); count != 0 {
+		t.Errorf("GetUnreadCount() after marking all = %d, want 0", count)
+	}
+
+	// event baru setelah notifikasi dibaca membuat notifikasi baru
+	cici := createTestUser(t, db, rdb, "cici")
+	if err := NewLikeRepository(db, rdb).LikePost(ctx, cici.ID, post.Id); err != nil {
+		t.Fatal(err)
+	}
+	if count, _ := nr.GetUnreadCount(ctx, budi.ID); count != 1 {
+		t.Errorf("GetUnreadCount() after new like = %d, want 1", count)
+	}
+}
+
+func TestDeleteCommentRetractsNotification(t *testing.T) {
+	db, rdb := newTestStores(t)
+	ctx := context.Background()
+	cr := NewCommentRepository(db, rdb)
+	nr := NewNotificationRepository(db, rdb)
+
+	author := createTestUser(t, db, rdb, "budi")
+	ani := createTestUser(t, db, rdb, "ani")
+	cici := createTestUser(t, db, rdb, "cici")
+	post := createTestPost(t, db, rdb, author.ID, "hello")
+
+	first, err := cr.CreateComment(ctx, &models.Comment{UserId: ani.ID, PostId: post.Id, Content: "first @cici"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := cr.CreateComment(ctx, &models.Comment{UserId: ani.ID, PostId: post.Id, Content: "second"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count, _ := nr.GetUnreadCount(ctx, cici.ID); count != 1 {
+		t.Fatalf("mention notifications = %d, want 1", count)
+	}
+
+	// komentar dengan mention dihapus: mention-nya ikut hilang, notifikasi komentar tetap karena masih ada komentar lain
+	if err := cr.DeleteComment(ctx, first.Id, ani.ID); err != nil {
+		t.Fatal(err)
+	}
+	if count, _ := nr.GetUnreadCount(ctx, cici.ID); count != 0 {
+		t.Errorf("mention notifications after delete = %d, want 0", count)
+	}
+	if count, _ := nr.GetUnreadCount(ctx, author.ID); count != 1 {
+		t.Errorf("comment notifications with a remaining comment = %d, want 1", count)
+	}
+
+	if err := cr.DeleteComment(ctx, second.Id, ani.ID); err != nil {
+		t.Fatal(err)
+	}
+	if count, _ := nr.GetUnreadCount(ctx, author.ID); count != 0 {
+		t.Errorf("comment notifications after deleting the last comment = %d, want 0", count)
+	}
+}
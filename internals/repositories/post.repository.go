@@ -3,12 +3,16 @@ package repositories
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"time"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/raihaninkam/finalPhase3/internals/models"
+	"github.com/raihaninkam/finalPhase3/internals/utils"
+	"github.com/raihaninkam/finalPhase3/pkg"
 	"github.com/redis/go-redis/v9"
 )
 
@@ -29,10 +33,23 @@ func (pr *PostRepository) CreatePost(ctx context.Context, post *models.Posts) (*
 	        VALUES ($1, $2, $3, now()) 
 	        RETURNING id, created_at`
 
-	err := pr.db.QueryRow(ctx, sql, post.UserId, post.Content, post.ImageUrl).Scan(&post.Id, &post.CreatedAt)
+	tx, err := pr.db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	err = tx.QueryRow(ctx, sql, post.UserId, post.Content, post.ImageUrl).Scan(&post.Id, &post.CreatedAt)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create post: %w", err)
 	}
+	// notifikasi ke user yang di-mention di post
+	if err := notifyMentions(ctx, tx, post.UserId, post.Id, nil, post.Content); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
 
 	// Invalidate cache after creating new post
 	pr.rdb.Del(ctx, "posts:all")
@@ -40,31 +57,112 @@ func (pr *PostRepository) CreatePost(ctx context.Context, post *models.Posts) (*
 	return post, nil
 }
 
-func (pr *PostRepository) GetPostByID(ctx context.Context, id int) (*models.Posts, error) {
+// GetPostByID mengambil detail post beserta author dan jumlah like / comment.
+// Bagian yang sama untuk semua user di-cache per post, is_liked dan is_bookmarked dihitung per viewer
+func (pr *PostRepository) GetPostByID(ctx context.Context, id, viewerID string) (*models.Posting, error) {
 	// Try to get from cache first
-	cacheKey := fmt.Sprintf("post:%d", id)
+	cacheKey := fmt.Sprintf("post:%s", id)
+	var post models.Posting
 	cached, err := pr.rdb.Get(ctx, cacheKey).Result()
-	if err == nil {
-		var post models.Posts
-		if err := json.Unmarshal([]byte(cached), &post); err == nil {
-			return &post, nil
+	if err != nil || json.Unmarshal([]byte(cached), &post) != nil {
+		// If not in cache, get from database
+		sql := `
+			SELECT
+				p.id, p.user_id, COALESCE(p.content_text, ''), COALESCE(p.image_url, ''), p.created_at, p.updated_at, p.edited_at,
+				u.username, u.name, u.avatar_url,
+				(SELECT COUNT(*) FROM likes WHERE post_id = p.id),
+				(SELECT COUNT(*) FROM comments WHERE post_id = p.id)
+			FROM posts p
+			JOIN users u ON p.user_id = u.id
+			WHERE p.id = $1
+		`
+
+		post = models.Posting{}
+		if err := pr.db.QueryRow(ctx, sql, id).Scan(
+			&post.ID,
+			&post.UserID,
+			&post.Content,
+			&post.ImageUrl,
+			&post.CreatedAt,
+			&post.UpdatedAt,
+			&post.EditedAt,
+			&post.Username,
+			&post.UserName,
+			&post.UserAvatarUrl,
+			&post.LikeCount,
+			&post.CommentCount,
+		); err != nil {
+			if err == pgx.ErrNoRows {
+				return nil, errors.New("post not found")
+			}
+			return nil, fmt.Errorf("failed to get post: %w", err)
 		}
+		post.IsEdited = post.EditedAt != nil
+
+		// Store in cache, dihapus saat post di-like / di-comment
+		postJSON, _ := json.Marshal(post)
+		pr.rdb.Set(ctx, cacheKey, postJSON, 10*time.Minute)
 	}
 
-	// If not in cache, get from database
-	sql := `SELECT id, content_text, image_url, created_at FROM posts WHERE id = $1`
+	// post dari akun private hanya untuk follower yang sudah disetujui, tidak di-cache karena berbeda per viewer.
+	// follower_count juga tidak di-cache karena berubah saat follow / unfollow
+	sql := `
+		SELECT
+			` + visibleToViewer("$3::uuid", "$2::uuid") + `,
+			EXISTS(SELECT 1 FROM likes WHERE post_id = $1 AND user_id = $2),
+			EXISTS(SELECT 1 FROM bookmarks WHERE post_id = $1 AND user_id = $2),
+			(SELECT follower_count FROM users WHERE id = $3)
+	`
+	var visible bool
+	if err := pr.db.QueryRow(ctx, sql, id, viewerID, post.UserID).Scan(&visible, &post.IsLiked, &post.IsBookmarked, &post.FollowerCount); err != nil {
+		return nil, fmt.Errorf("failed to get post viewer state: %w", err)
+	}
+	if !visible {
+		return nil, errors.New("post not found")
+	}
 
-	var post models.Posts
-	err = pr.db.QueryRow(ctx, sql, id).Scan(&post.Id, &post.Content, &post.ImageUrl, &post.CreatedAt)
+	return &post, nil
+}
+
+// BookmarkPost menyimpan post ke bookmark user, bookmark yang sudah ada diabaikan.
+// Post yang tidak boleh dilihat user (akun private / block) dianggap tidak ada
+func (pr *PostRepository) BookmarkPost(ctx context.Context, userID, postID string) error {
+	sql := `
+		INSERT INTO bookmarks (user_id, post_id)
+		SELECT $1, p.id FROM posts p WHERE p.id = $2 AND ` + visibleToViewer("p.user_id", "$1::uuid") + `
+		ON CONFLICT (user_id, post_id) DO NOTHING
+		RETURNING post_id
+	`
+
+	var bookmarked string
+	err := pr.db.QueryRow(ctx, sql, userID, postID).Scan(&bookmarked)
+	if err == pgx.ErrNoRows {
+		var exists bool
+		existsSQL := `SELECT EXISTS(SELECT 1 FROM posts p WHERE p.id = $2 AND ` + visibleToViewer("p.user_id", "$1::uuid") + `)`
+		if err := pr.db.QueryRow(ctx, existsSQL, userID, postID).Scan(&exists); err != nil {
+			return fmt.Errorf("failed to bookmark post: %w", err)
+		}
+		if !exists {
+			return errors.New("post not found")
+		}
+		return nil
+	}
 	if err != nil {
-		return nil, fmt.Errorf("failed to get post: %w", err)
+		return fmt.Errorf("failed to bookmark post: %w", err)
 	}
+	return nil
+}
 
-	// Store in cache
-	postJSON, _ := json.Marshal(post)
-	pr.rdb.Set(ctx, cacheKey, postJSON, 1*time.Hour)
-
-	return &post, nil
+// UnbookmarkPost menghapus post dari bookmark user
+func (pr *PostRepository) UnbookmarkPost(ctx context.Context, userID, postID string) error {
+	result, err := pr.db.Exec(ctx, `DELETE FROM bookmarks WHERE user_id = $1 AND post_id = $2`, userID, postID)
+	if err != nil {
+		return fmt.Errorf("failed to unbookmark post: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return errors.New("bookmark not found")
+	}
+	return nil
 }
 
 func (pr *PostRepository) GetAllPosts(ctx context.Context) ([]models.Posts, error) {
@@ -79,7 +177,7 @@ func (pr *PostRepository) GetAllPosts(ctx context.Context) ([]models.Posts, erro
 	}
 
 	// If not in cache, get from database
-	sql := `SELECT id, content_text, image_url, created_at FROM posts ORDER BY created_at DESC`
+	sql := `SELECT id, COALESCE(content_text, ''), COALESCE(image_url, ''), created_at, edited_at FROM posts ORDER BY created_at DESC`
 
 	rows, err := pr.db.Query(ctx, sql)
 	if err != nil {
@@ -90,9 +188,10 @@ func (pr *PostRepository) GetAllPosts(ctx context.Context) ([]models.Posts, erro
 	var posts []models.Posts
 	for rows.Next() {
 		var post models.Posts
-		if err := rows.Scan(&post.Id, &post.Content, &post.ImageUrl, &post.CreatedAt); err != nil {
+		if err := rows.Scan(&post.Id, &post.Content, &post.ImageUrl, &post.CreatedAt, &post.EditedAt); err != nil {
 			return nil, fmt.Errorf("failed to scan post: %w", err)
 		}
+		post.IsEdited = post.EditedAt != nil
 		posts = append(posts, post)
 	}
 
@@ -107,138 +206,209 @@ func (pr *PostRepository) GetAllPosts(ctx context.Context) ([]models.Posts, erro
 	return posts, nil
 }
 
-func (pr *PostRepository) UpdatePost(ctx context.Context, id int, post *models.Posts) (*models.Posts, error) {
-	sql := `UPDATE posts SET content_text = $1, image_url = $2 WHERE id = $3 RETURNING id, content_text, image_url, created_at`
-
-	err := pr.db.QueryRow(ctx, sql, post.Content, post.ImageUrl, id).Scan(&post.Id, &post.Content, &post.ImageUrl, &post.CreatedAt)
+// UpdatePost mengubah post milik user (atau siapa saja jika canModerate). Versi sebelumnya disimpan ke post_revisions
+func (pr *PostRepository) UpdatePost(ctx context.Context, id, editorID string, canModerate bool, update *models.PostsRequest) (*models.Posts, error) {
+	tx, err := pr.db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	var authorID string
+	var content, imageUrl *string
+	err = tx.QueryRow(ctx, `SELECT user_id, content_text, image_url FROM posts WHERE id = $1 FOR UPDATE`, id).
+		Scan(&authorID, &content, &imageUrl)
+	if err == pgx.ErrNoRows {
+		return nil, errors.New("post not found")
+	}
 	if err != nil {
+		return nil, fmt.Errorf("failed to get post: %w", err)
+	}
+	if authorID != editorID && !canModerate {
+		return nil, errors.New("not the post author")
+	}
+
+	newContent, newImageUrl := content, imageUrl
+	if update.Content != nil {
+		newContent = update.Content
+	}
+	if update.ImageUrl != nil {
+		newImageUrl = update.ImageUrl
+	}
+	if (newContent == nil || *newContent == "") && (newImageUrl == nil || *newImageUrl == "") {
+		return nil, errors.New("content or image is required")
+	}
+
+	if _, err := tx.Exec(ctx, `INSERT INTO post_revisions (post_id, edited_by, content_text, image_url) VALUES ($1, $2, $3, $4)`,
+		id, editorID, content, imageUrl); err != nil {
+		return nil, fmt.Errorf("failed to save post revision: %w", err)
+	}
+
+	sql := `
+		UPDATE posts SET content_text = $1, image_url = NULLIF($2, ''), updated_at = now(), edited_at = now()
+		WHERE id = $3
+		RETURNING id, user_id, COALESCE(content_text, ''), COALESCE(image_url, ''), created_at, updated_at, edited_at
+	`
+	var post models.Posts
+	if err := tx.QueryRow(ctx, sql, newContent, newImageUrl, id).Scan(
+		&post.Id, &post.UserId, &post.Content, &post.ImageUrl, &post.CreatedAt, &post.UpdatedAt, &post.EditedAt,
+	); err != nil {
 		return nil, fmt.Errorf("failed to update post: %w", err)
 	}
+	post.IsEdited = true
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
 
 	// Invalidate cache
-	pr.rdb.Del(ctx, fmt.Sprintf("post:%d", id))
-	pr.rdb.Del(ctx, "posts:all")
+	pr.rdb.Del(ctx, fmt.Sprintf("post:%s", id), "posts:all")
 
-	return post, nil
+	return &post, nil
 }
 
-func (pr *PostRepository) DeletePost(ctx context.Context, id int) error {
-	sql := `DELETE FROM posts WHERE id = $1`
+// DeletePost menghapus post milik user (atau siapa saja jika canModerate).
+// Mengembalikan url gambar post beserta revisinya agar file-nya bisa dihapus
+func (pr *PostRepository) DeletePost(ctx context.Context, id, userID string, canModerate bool) ([]string, error) {
+	tx, err := pr.db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
 
-	result, err := pr.db.Exec(ctx, sql, id)
+	var authorID string
+	err = tx.QueryRow(ctx, `SELECT user_id FROM posts WHERE id = $1 FOR UPDATE`, id).Scan(&authorID)
+	if err == pgx.ErrNoRows {
+		return nil, errors.New("post not found")
+	}
 	if err != nil {
-		return fmt.Errorf("failed to delete post: %w", err)
+		return nil, fmt.Errorf("failed to get post: %w", err)
+	}
+	if authorID != userID && !canModerate {
+		return nil, errors.New("not the post author")
 	}
 
-	if result.RowsAffected() == 0 {
-		return fmt.Errorf("post not found")
+	rows, err := tx.Query(ctx, `
+		SELECT image_url FROM posts WHERE id = $1 AND image_url IS NOT NULL
+		UNION
+		SELECT image_url FROM post_revisions WHERE post_id = $1 AND image_url IS NOT NULL
+	`, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get post images: %w", err)
+	}
+	var images []string
+	for rows.Next() {
+		var image string
+		if err := rows.Scan(&image); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan post image: %w", err)
+		}
+		images = append(images, image)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if _, err := tx.Exec(ctx, `DELETE FROM posts WHERE id = $1`, id); err != nil {
+		return nil, fmt.Errorf("failed to delete post: %w", err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
 	}
 
 	// Invalidate cache
-	pr.rdb.Del(ctx, fmt.Sprintf("post:%d", id))
-	pr.rdb.Del(ctx, "posts:all")
+	pr.rdb.Del(ctx, fmt.Sprintf("post:%s", id), fmt.Sprintf("comments:post:%s", id), "posts:all")
 
-	return nil
+	return images, nil
 }
 
-func (pr *PostRepository) GetFollowingPosts(ctx context.Context, userID string, limit, offset int) ([]models.PostWithUser, error) {
-	// Try cache first
-	cacheKey := fmt.Sprintf("feed:%s:%d:%d", userID, limit, offset)
-	cached, err := pr.rdb.Get(ctx, cacheKey).Result()
-	if err == nil {
-		var posts []models.PostWithUser
-		if err := json.Unmarshal([]byte(cached), &posts); err == nil {
-			return posts, nil
-		}
+// GetPostHistory mengambil versi-versi sebelumnya dari post, terbaru lebih dulu
+func (pr *PostRepository) GetPostHistory(ctx context.Context, id, viewerID string) ([]models.PostRevision, error) {
+	var exists bool
+	sql := `SELECT EXISTS(SELECT 1 FROM posts p WHERE p.id = $1 AND ` + visibleToViewer("p.user_id", "$2::uuid") + `)`
+	if err := pr.db.QueryRow(ctx, sql, id, viewerID).Scan(&exists); err != nil {
+		return nil, fmt.Errorf("failed to get post: %w", err)
+	}
+	if !exists {
+		return nil, errors.New("post not found")
 	}
 
-	// Get from database
-	sql := `
-		SELECT 
-			p.id, 
-			p.user_id, 
-			p.content_text, 
-			p.image_url, 
-			p.created_at,
-			u.name as user_name,
-			COALESCE(u.avatar_url, '') as user_avatar
-		FROM posts p
-		JOIN users u ON p.user_id = u.id
-		WHERE p.user_id IN (
-			SELECT following_id 
-			FROM follows 
-			WHERE follower_id = $1
-		)
-		ORDER BY p.created_at DESC
-		LIMIT $2 OFFSET $3
+	sql = `
+		SELECT id, post_id, edited_by, content_text, image_url, created_at
+		FROM post_revisions
+		WHERE post_id = $1
+		ORDER BY created_at DESC
 	`
-
-	rows, err := pr.db.Query(ctx, sql, userID, limit, offset)
+	rows, err := pr.db.Query(ctx, sql, id)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get following posts: %w", err)
+		return nil, fmt.Errorf("failed to get post history: %w", err)
 	}
 	defer rows.Close()
 
-	var posts []models.PostWithUser
+	revisions := []models.PostRevision{}
 	for rows.Next() {
-		var post models.PostWithUser
-		if err := rows.Scan(
-			&post.Id,
-			&post.UserId,
-			&post.Content,
-			&post.ImageUrl,
-			&post.CreatedAt,
-			&post.UserName,
-			&post.UserAvatar,
-		); err != nil {
-			return nil, fmt.Errorf("failed to scan post: %w", err)
+		var revision models.PostRevision
+		if err := rows.Scan(&revision.Id, &revision.PostId, &revision.EditedBy, &revision.Content, &revision.ImageUrl, &revision.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan post revision: %w", err)
 		}
-		posts = append(posts, post)
+		revisions = append(revisions, revision)
 	}
-
-	if err := rows.Err(); err != nil {
-		return nil, err
-	}
-
-	// Cache the result
-	if len(posts) > 0 {
-		postsJSON, _ := json.Marshal(posts)
-		pr.rdb.Set(ctx, cacheKey, postsJSON, 2*time.Minute)
-	}
-
-	return posts, nil
+	return revisions, rows.Err()
 }
 
-// GetPopularPosts mendapatkan postingan dengan interaksi tinggi
-func (pr *PostRepository) GetPopularPosts(ctx context.Context, limit, offset int) ([]*models.Posting, error) {
+// GetPopularPosts mendapatkan postingan dengan interaksi tinggi.
+// Skor dihitung per waktu as_of yang dibawa cursor: post, like dan comment yang dibuat setelah halaman pertama
+// tidak menggeser urutan halaman berikutnya. Unlike, comment yang dihapus dan perubahan jumlah follower tetap
+// langsung berlaku, sehingga untuk perubahan tsb pagination bersifat best-effort (post bisa terlewat / muncul dua kali)
+func (pr *PostRepository) GetPopularPosts(ctx context.Context, viewerID string, limit int, cursor *pkg.Cursor) ([]*models.Posting, *pkg.Cursor, error) {
 	query := `
-		SELECT 
-			p.id, p.user_id, p.content_text, p.image_url, p.created_at, p.updated_at,
-			u.name as user_name, u.avatar_url as user_avatar_url,
-			COUNT(DISTINCT l.id) as like_count,
-			COUNT(DISTINCT c.id) as comment_count,
-			COUNT(DISTINCT f.follower_id) as follower_count,
-			(COUNT(DISTINCT l.id) * 1.0 + COUNT(DISTINCT c.id) * 2.0 + COUNT(DISTINCT f.follower_id) * 0.5) as popularity_score
-		FROM posts p
-		INNER JOIN users u ON p.user_id = u.id
-		LEFT JOIN likes l ON p.id = l.post_id
-		LEFT JOIN comments c ON p.id = c.post_id
-		LEFT JOIN follows f ON p.user_id = f.following_id
-		WHERE p.created_at >= NOW() - INTERVAL '7 days'
-		GROUP BY p.id, u.id
-		HAVING COUNT(DISTINCT l.id) + COUNT(DISTINCT c.id) > 0
-		ORDER BY popularity_score DESC, p.created_at DESC
-		LIMIT $1 OFFSET $2
+		WITH ranked AS (
+			SELECT 
+				p.id, p.user_id, COALESCE(p.content_text, '') AS content_text, COALESCE(p.image_url, '') AS image_url,
+				p.created_at, p.updated_at, p.edited_at,
+				u.name as user_name, u.avatar_url as user_avatar_url,
+				lc.like_count,
+				cc.comment_count,
+				u.follower_count,
+				(lc.like_count * 1.0 + cc.comment_count * 2.0 + u.follower_count * 0.5)::float8 as popularity_score
+			FROM posts p
+			INNER JOIN users u ON p.user_id = u.id
+			CROSS JOIN LATERAL (SELECT COUNT(*) AS like_count FROM likes WHERE post_id = p.id AND created_at <= $6) lc
+			CROSS JOIN LATERAL (SELECT COUNT(*) AS comment_count FROM comments WHERE post_id = p.id AND created_at <= $6) cc
+			WHERE p.created_at >= $6::timestamptz - INTERVAL '7 days' AND p.created_at <= $6
+			AND ` + visibleToViewer("p.user_id", "$5::uuid") + `
+			AND lc.like_count + cc.comment_count > 0
+		)
+		SELECT id, user_id, content_text, image_url, created_at, updated_at, edited_at,
+			user_name, user_avatar_url, like_count, comment_count, follower_count, popularity_score
+		FROM ranked
+		WHERE $1::float8 IS NULL OR (popularity_score, created_at, id) < ($1, $2::timestamptz, $3::uuid)
+		ORDER BY popularity_score DESC, created_at DESC, id DESC
+		LIMIT $4
 	`
 
-	rows, err := pr.db.Query(ctx, query, limit, offset)
+	var afterScore *float64
+	var after *time.Time
+	var afterID *string
+	asOf := time.Now()
+	if cursor != nil {
+		afterScore, after, afterID = &cursor.Score, &cursor.CreatedAt, &cursor.ID
+		// cursor lama tanpa as_of memakai waktu sekarang
+		if !cursor.AsOf.IsZero() {
+			asOf = cursor.AsOf
+		}
+	}
+
+	rows, err := pr.db.Query(ctx, query, afterScore, after, afterID, limit+1, viewerID, asOf)
 	if err != nil {
 		log.Println("Failed to get popular posts:", err.Error())
-		return nil, err
+		return nil, nil, err
 	}
 	defer rows.Close()
 
-	var posts []*models.Posting
+	posts := []*models.Posting{}
+	scores := []float64{}
 	for rows.Next() {
 		var post models.Posting
 		var popularityScore float64
@@ -250,6 +420,7 @@ func (pr *PostRepository) GetPopularPosts(ctx context.Context, limit, offset int
 			&post.ImageUrl,
 			&post.CreatedAt,
 			&post.UpdatedAt,
+			&post.EditedAt,
 			&post.UserName,
 			&post.UserAvatarUrl,
 			&post.LikeCount,
@@ -259,10 +430,24 @@ func (pr *PostRepository) GetPopularPosts(ctx context.Context, limit, offset int
 		)
 		if err != nil {
 			log.Println("Failed to scan post:", err.Error())
-			continue
+			return nil, nil, err
 		}
+		post.IsEdited = post.EditedAt != nil
 		posts = append(posts, &post)
+		scores = append(scores, popularityScore)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
 	}
 
-	return posts, nil
+	var next *pkg.Cursor
+	if len(posts) > limit {
+		posts = posts[:limit]
+		last := posts[limit-1]
+		next = pkg.NewCursor(utils.CursorPopular, last.CreatedAt, last.ID)
+		next.Score = scores[limit-1]
+		next.AsOf = asOf
+	}
+
+	return posts, next, nil
 }
@@ -0,0 +1,241 @@
+package repositories
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/raihaninkam/finalPhase3/internals/models"
+)
+
+func TestCreateAndGetPost(t *testing.T) {
+	db, rdb := newTestStores(t)
+	ctx := context.Background()
+	pr := NewPostRepository(db, rdb)
+
+	author := createTestUser(t, db, rdb, "budi")
+	viewer := createTestUser(t, db, rdb, "ani")
+	created := createTestPost(t, db, rdb, author.ID, "hello world")
+	if created.Id == "" || created.CreatedAt == nil {
+		t.Fatalf("CreatePost() = %+v", created)
+	}
+
+	post, err := pr.GetPostByID(ctx, created.Id, viewer.ID)
+	if err != nil {
+		t.Fatalf("GetPostByID() error: %v", err)
+	}
+	if post.Content != "hello world" || post.UserID != author.ID || post.Username != "budi" {
+		t.Errorf("GetPostByID() = %+v", post)
+	}
+	if post.IsBookmarked || post.IsLiked || post.LikeCount != 0 || post.CommentCount != 0 {
+		t.Errorf("new post has engagement: %+v", post)
+	}
+
+	posts, err := pr.GetAllPosts(ctx)
+	if err != nil {
+		t.Fatalf("GetAllPosts() error: %v", err)
+	}
+	if len(posts) != 1 || posts[0].Id != created.Id {
+		t.Errorf("GetAllPosts() = %+v", posts)
+	}
+
+	// detail post kedua diambil dari cache, state per viewer dan counter yang berubah tetap terbaru
+	if err := pr.BookmarkPost(ctx, viewer.ID, created.Id); err != nil {
+		t.Fatalf("BookmarkPost() error: %v", err)
+	}
+	if err := pr.BookmarkPost(ctx, viewer.ID, created.Id); err != nil {
+		t.Errorf("BookmarkPost() twice: %v", err)
+	}
+	if err := NewLikeRepository(db, rdb).LikePost(ctx, viewer.ID, created.Id); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewCommentRepository(db, rdb).CreateComment(ctx, &models.Comment{UserId: viewer.ID, PostId: created.Id, Content: "hi"}); err != nil {
+		t.Fatal(err)
+	}
+	post, err = pr.GetPostByID(ctx, created.Id, viewer.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !post.IsBookmarked || !post.IsLiked || post.LikeCount != 1 || post.CommentCount != 1 {
+		t.Errorf("GetPostByID() after bookmark, like and comment = %+v", post)
+	}
+	post, err = pr.GetPostByID(ctx, created.Id, author.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if post.IsBookmarked || post.IsLiked {
+		t.Errorf("viewer state leaked to another user: %+v", post)
+	}
+
+	if err := pr.UnbookmarkPost(ctx, viewer.ID, created.Id); err != nil {
+		t.Fatalf("UnbookmarkPost() error: %v", err)
+	}
+	if err := pr.UnbookmarkPost(ctx, viewer.ID, created.Id); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("UnbookmarkPost() twice = %v, want not found", err)
+	}
+
+	unknown := "00000000-0000-0000-0000-000000000000"
+	if _, err := pr.GetPostByID(ctx, unknown, viewer.ID); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("GetPostByID() unknown post = %v, want not found", err)
+	}
+	if err := pr.BookmarkPost(ctx, viewer.ID, unknown); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("BookmarkPost() unknown post = %v, want not found", err)
+	}
+}
+
+func TestPrivatePostVisibility(t *testing.T) {
+	db, rdb := newTestStores(t)
+	ctx := context.Background()
+	pr := NewPostRepository(db, rdb)
+	fr := NewFollowsRepository(db, rdb)
+
+	author := createTestUser(t, db, rdb, "budi")
+	viewer := createTestUser(t, db, rdb, "ani")
+	if _, err := fr.SetPrivacy(ctx, author.ID, true); err != nil {
+		t.Fatal(err)
+	}
+	post := createTestPost(t, db, rdb, author.ID, "private post")
+
+	if _, err := pr.GetPostByID(ctx, post.Id, viewer.ID); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("GetPostByID() private post = %v, want not found", err)
+	}
+	if err := pr.BookmarkPost(ctx, viewer.ID, post.Id); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("BookmarkPost() private post = %v, want not found", err)
+	}
+	if _, err := pr.GetPostByID(ctx, post.Id, author.ID); err != nil {
+		t.Errorf("author cannot see own private post: %v", err)
+	}
+
+	// setelah follow request disetujui, post private bisa dilihat
+	if _, err := fr.Follow(ctx, viewer.ID, author.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := fr.ApproveFollowRequest(ctx, author.ID, viewer.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := pr.GetPostByID(ctx, post.Id, viewer.ID); err != nil {
+		t.Errorf("approved follower cannot see private post: %v", err)
+	}
+	if err := pr.BookmarkPost(ctx, viewer.ID, post.Id); err != nil {
+		t.Errorf("approved follower cannot bookmark private post: %v", err)
+	}
+}
+
+func TestPostFollowerCount(t *testing.T) {
+	db, rdb := newTestStores(t)
+	ctx := context.Background()
+	pr := NewPostRepository(db, rdb)
+	fr := NewFollowsRepository(db, rdb)
+
+	author := createTestUser(t, db, rdb, "budi")
+	post := createTestPost(t, db, rdb, author.ID, "hello")
+	// post populer harus punya interaksi
+	if err := NewLikeRepository(db, rdb).LikePost(ctx, createTestUser(t, db, rdb, "dodi").ID, post.Id); err != nil {
+		t.Fatal(err)
+	}
+	// detail post sudah ada di cache sebelum ada follower baru
+	if _, err := pr.GetPostByID(ctx, post.Id, author.ID); err != nil {
+		t.Fatal(err)
+	}
+	for _, username := range []string{"ani", "cici"} {
+		follower := createTestUser(t, db, rdb, username)
+		if _, err := fr.Follow(ctx, follower.ID, author.ID); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	detail, err := pr.GetPostByID(ctx, post.Id, author.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if detail.FollowerCount != 2 {
+		t.Errorf("GetPostByID() follower_count = %d, want 2", detail.FollowerCount)
+	}
+
+	popular, _, err := pr.GetPopularPosts(ctx, author.ID, 10, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(popular) != 1 || popular[0].FollowerCount != 2 {
+		t.Errorf("GetPopularPosts() = %+v, want one post with follower_count 2", popular)
+	}
+}
+
+func TestGetPopularPostsSnapshot(t *testing.T) {
+	db, rdb := newTestStores(t)
+	ctx := context.Background()
+	pr := NewPostRepository(db, rdb)
+	lr := NewLikeRepository(db, rdb)
+
+	author := createTestUser(t, db, rdb, "budi")
+	likers := []*models.User{
+		createTestUser(t, db, rdb, "ani"),
+		createTestUser(t, db, rdb, "cici"),
+		createTestUser(t, db, rdb, "dodi"),
+		createTestUser(t, db, rdb, "eka"),
+	}
+	like := func(post *models.Posts, n int, from int) {
+		t.Helper()
+		for _, liker := range likers[from : from+n] {
+			if err := lr.LikePost(ctx, liker.ID, post.Id); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	// skor: first 3, tieOld 2, tieNew 2 (lebih baru, jadi di atas tieOld), last 1
+	first := createTestPost(t, db, rdb, author.ID, "first")
+	tieOld := createTestPost(t, db, rdb, author.ID, "tie old")
+	tieNew := createTestPost(t, db, rdb, author.ID, "tie new")
+	last := createTestPost(t, db, rdb, author.ID, "last")
+	like(first, 3, 0)
+	like(tieOld, 2, 0)
+	like(tieNew, 2, 0)
+	like(last, 1, 0)
+
+	// batas halaman pertama jatuh di antara dua post dengan skor yang sama
+	page, next, err := pr.GetPopularPosts(ctx, author.ID, 2, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ids := postIDs(page); len(ids) != 2 || ids[0] != first.Id || ids[1] != tieNew.Id {
+		t.Fatalf("page 1 = %v, want [%s %s]", ids, first.Id, tieNew.Id)
+	}
+	if next == nil || next.AsOf.IsZero() {
+		t.Fatalf("next cursor = %+v, want a cursor with as_of", next)
+	}
+
+	// setelah halaman pertama: last mendapat like baru (skor live 4) dan ada post populer baru
+	like(last, 3, 1)
+	fresh := createTestPost(t, db, rdb, author.ID, "fresh")
+	like(fresh, 4, 0)
+
+	// halaman berikutnya tetap memakai skor saat halaman pertama: tidak ada post yang terlewat atau muncul dua kali
+	page, next, err = pr.GetPopularPosts(ctx, author.ID, 2, next)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ids := postIDs(page); len(ids) != 2 || ids[0] != tieOld.Id || ids[1] != last.Id {
+		t.Errorf("page 2 = %v, want [%s %s]", ids, tieOld.Id, last.Id)
+	}
+	if next != nil {
+		t.Errorf("page 2 next cursor = %+v, want nil", next)
+	}
+
+	// listing baru memakai skor terbaru
+	page, _, err = pr.GetPopularPosts(ctx, author.ID, 2, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ids := postIDs(page); len(ids) != 2 || ids[0] != fresh.Id || ids[1] != last.Id {
+		t.Errorf("new listing = %v, want [%s %s]", ids, fresh.Id, last.Id)
+	}
+}
+
+func postIDs(posts []*models.Posting) []string {
+	ids := make([]string, 0, len(posts))
+	for _, post := range posts {
+		ids = append(ids, post.ID)
+	}
+	return ids
+}
@@ -0,0 +1,189 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/raihaninkam/finalPhase3/internals/models"
+	"github.com/raihaninkam/finalPhase3/internals/utils"
+	"github.com/raihaninkam/finalPhase3/pkg"
+	"github.com/redis/go-redis/v9"
+)
+
+type SessionRepository struct {
+	db  *pgxpool.Pool
+	rdb *redis.Client
+}
+
+func NewSessionRepository(db *pgxpool.Pool, rdb *redis.Client) *SessionRepository {
+	return &SessionRepository{
+		db:  db,
+		rdb: rdb,
+	}
+}
+
+func (sr *SessionRepository) CreateSession(ctx context.Context, session *models.Session) error {
+	sql := `INSERT INTO sessions (user_id, device_name, user_agent, ip_address)
+	        VALUES ($1, $2, $3, $4)
+	        RETURNING id, created_at, last_seen_at`
+
+	if err := sr.db.QueryRow(ctx, sql, session.UserId, session.DeviceName, session.UserAgent, session.IpAddress).
+		Scan(&session.Id, &session.CreatedAt, &session.LastSeenAt); err != nil {
+		log.Println("Failed to create session.\nCause: ", err.Error())
+		return err
+	}
+	return nil
+}
+
+// GetUserSessions mengambil semua sesi aktif milik user. last_seen_at diambil dari redis
+// (di-update oleh middleware setiap request) jika lebih baru dari yang ada di database
+func (sr *SessionRepository) GetUserSessions(ctx context.Context, userID string) ([]models.Session, error) {
+	sql := `
+		SELECT id, user_id, device_name, user_agent, ip_address, created_at, last_seen_at
+		FROM sessions
+		WHERE user_id = $1 AND revoked_at IS NULL
+		ORDER BY last_seen_at DESC
+	`
+
+	rows, err := sr.db.Query(ctx, sql, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get sessions: %w", err)
+	}
+	defer rows.Close()
+
+	sessions := []models.Session{}
+	for rows.Next() {
+		var session models.Session
+		if err := rows.Scan(
+			&session.Id,
+			&session.UserId,
+			&session.DeviceName,
+			&session.UserAgent,
+			&session.IpAddress,
+			&session.CreatedAt,
+			&session.LastSeenAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan session: %w", err)
+		}
+
+		lastSeen, err := sr.rdb.Get(ctx, utils.RedisKey("session-last-seen", session.Id)).Int64()
+		if err == nil && lastSeen > session.LastSeenAt.Unix() {
+			session.LastSeenAt = time.Unix(lastSeen, 0)
+		}
+		sessions = append(sessions, session)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return sessions, nil
+}
+
+// RevokeSession mencabut satu sesi milik user beserta refresh token-nya
+func (sr *SessionRepository) RevokeSession(ctx context.Context, userID, sessionID string) error {
+	tx, err := sr.db.Begin(ctx)
+	if err != nil {
+		log.Println("Failed to begin transaction:", err.Error())
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	result, err := tx.Exec(ctx, `UPDATE sessions SET revoked_at = now() WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL`, sessionID, userID)
+	if err != nil {
+		log.Println("Failed to revoke session.\nCause: ", err.Error())
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return errors.New("session not found")
+	}
+
+	if _, err := tx.Exec(ctx, `UPDATE refresh_tokens SET revoked_at = now() WHERE session_id = $1 AND revoked_at IS NULL`, sessionID); err != nil {
+		log.Println("Failed to revoke refresh tokens.\nCause: ", err.Error())
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		log.Println("Failed to commit transaction:", err.Error())
+		return err
+	}
+
+	return markSessionsRevoked(ctx, sr.rdb, sessionID)
+}
+
+// RevokeUserSessions mencabut semua sesi aktif user kecuali exceptID (kosongkan untuk mencabut semuanya)
+func (sr *SessionRepository) RevokeUserSessions(ctx context.Context, userID, exceptID string) error {
+	tx, err := sr.db.Begin(ctx)
+	if err != nil {
+		log.Println("Failed to begin transaction:", err.Error())
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	sessionIDs, err := revokeUserSessions(ctx, tx, userID, exceptID)
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		log.Println("Failed to commit transaction:", err.Error())
+		return err
+	}
+
+	return markSessionsRevoked(ctx, sr.rdb, sessionIDs...)
+}
+
+// revokeUserSessions mencabut sesi beserta refresh token user di dalam tx dan mengembalikan id sesi yang dicabut.
+// Pemanggil tetap harus memanggil markSessionsRevoked agar access token-nya langsung ditolak
+func revokeUserSessions(ctx context.Context, tx pgx.Tx, userID, exceptID string) ([]string, error) {
+	sql := `UPDATE sessions SET revoked_at = now()
+	        WHERE user_id = $1 AND revoked_at IS NULL AND id::text <> $2
+	        RETURNING id`
+
+	rows, err := tx.Query(ctx, sql, userID, exceptID)
+	if err != nil {
+		log.Println("Failed to revoke sessions.\nCause: ", err.Error())
+		return nil, err
+	}
+	var sessionIDs []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan session: %w", err)
+		}
+		sessionIDs = append(sessionIDs, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if _, err := tx.Exec(ctx, `UPDATE refresh_tokens SET revoked_at = now() WHERE session_id = ANY($1) AND revoked_at IS NULL`, sessionIDs); err != nil {
+		log.Println("Failed to revoke refresh tokens.\nCause: ", err.Error())
+		return nil, err
+	}
+	return sessionIDs, nil
+}
+
+// markSessionsRevoked menandai sesi sebagai dicabut di redis agar middleware VerifyToken langsung
+// menolak access token-nya. Key cukup disimpan selama masa berlaku access token
+func markSessionsRevoked(ctx context.Context, rdb *redis.Client, sessionIDs ...string) error {
+	if len(sessionIDs) == 0 {
+		return nil
+	}
+	pipe := rdb.Pipeline()
+	for _, id := range sessionIDs {
+		pipe.Set(ctx, utils.RedisKey("session-revoked", id), "true", pkg.AccessTokenTTL)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		log.Println("Failed to mark sessions revoked on redis.\nCause: ", err.Error())
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,244 @@
+package repositories
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/raihaninkam/finalPhase3/internals/models"
+	"github.com/raihaninkam/finalPhase3/internals/utils"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// suggestionListSize adalah jumlah rekomendasi yang disimpan per user
+	suggestionListSize = 50
+	// suggestionTTL lebih lama dari interval job agar rekomendasi tidak kosong di antara dua kali jalan.
+	// Rekomendasi user yang sudah tidak aktif dibiarkan expired
+	suggestionTTL = 3 * time.Hour
+)
+
+type SuggestionRepository struct {
+	db  *pgxpool.Pool
+	rdb *redis.Client
+}
+
+func NewSuggestionRepository(db *pgxpool.Pool, rdb *redis.Client) *SuggestionRepository {
+	return &SuggestionRepository{
+		db:  db,
+		rdb: rdb,
+	}
+}
+
+func suggestionKey(userID string) string {
+	return utils.RedisKey("suggestions", userID)
+}
+
+// GetActiveUserIDs mengambil id user yang login dalam 7 hari terakhir, urut berdasarkan id (keyset afterID).
+// Hanya user ini yang rekomendasinya dihitung ulang secara berkala
+func (sr *SuggestionRepository) GetActiveUserIDs(ctx context.Context, afterID *string, limit int) ([]string, error) {
+	rows, err := sr.db.Query(ctx, `
+		SELECT u.id FROM users u
+		WHERE ($1::uuid IS NULL OR u.id > $1)
+		AND u.deletion_scheduled_at IS NULL
+		AND EXISTS (
+			SELECT 1 FROM sessions s
+			WHERE s.user_id = u.id AND s.revoked_at IS NULL AND s.last_seen_at > now() - interval '7 days'
+		)
+		ORDER BY u.id
+		LIMIT $2
+	`, afterID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get active users: %w", err)
+	}
+	return collectIDs(rows)
+}
+
+// ComputeSuggestions menghitung rekomendasi akun untuk user. Kandidat berasal dari friends-of-friends
+// (akun yang di-follow oleh akun yang user follow), follower yang belum di-follow balik, dan akun yang
+// paling aktif posting dalam 14 hari terakhir. Akun yang sudah di-follow / di-request, diblokir (dua arah),
+// di-mute, dirinya sendiri dan akun yang dijadwalkan dihapus tidak direkomendasikan
+func (sr *SuggestionRepository) ComputeSuggestions(ctx context.Context, userID string, limit int) ([]models.UserSuggestion, error) {
+	sql := `
+		WITH excluded AS (
+			SELECT $1::uuid AS id
+			UNION SELECT following_id FROM follows WHERE follower_id = $1
+			UNION SELECT target_id FROM follow_requests WHERE requester_id = $1
+			UNION SELECT blocked_id FROM blocks WHERE blocker_id = $1
+			UNION SELECT blocker_id FROM blocks WHERE blocked_id = $1
+			UNION SELECT muted_id FROM mutes WHERE muter_id = $1
+		),
+		mutual AS (
+			SELECT f2.following_id AS id, COUNT(*) AS mutual_count,
+				(array_agg(mu.username ORDER BY mu.follower_count DESC, mu.id))[1] AS mutual_username
+			FROM follows f1
+			JOIN follows f2 ON f2.follower_id = f1.following_id
+			JOIN users mu ON mu.id = f1.following_id
+			WHERE f1.follower_id = $1
+			GROUP BY f2.following_id
+		),
+		followers AS (
+			SELECT follower_id AS id FROM follows WHERE following_id = $1
+		),
+		trending AS (
+			SELECT user_id AS id FROM posts
+			WHERE created_at > now() - interval '14 days'
+			GROUP BY user_id
+			ORDER BY COUNT(*) DESC
+			LIMIT 50
+		),
+		candidates AS (
+			SELECT id FROM mutual
+			UNION SELECT id FROM followers
+			UNION SELECT id FROM trending
+		),
+		activity AS (
+			SELECT p.user_id AS id, COUNT(*) AS recent_posts
+			FROM posts p
+			JOIN candidates c ON c.id = p.user_id
+			WHERE p.created_at > now() - interval '14 days'
+			GROUP BY p.user_id
+		)
+		SELECT u.id, u.username, u.name, u.avatar_url, u.bio, u.is_private,
+			COALESCE(m.mutual_count, 0), m.mutual_username, fl.id IS NOT NULL
+		FROM candidates c
+		JOIN users u ON u.id = c.id
+		LEFT JOIN mutual m ON m.id = c.id
+		LEFT JOIN followers fl ON fl.id = c.id
+		LEFT JOIN activity a ON a.id = c.id
+		WHERE c.id NOT IN (SELECT id FROM excluded)
+		AND u.deletion_scheduled_at IS NULL
+		ORDER BY COALESCE(m.mutual_count, 0) * 3
+			+ CASE WHEN fl.id IS NULL THEN 0 ELSE 5 END
+			+ LEAST(COALESCE(a.recent_posts, 0), 10) * 0.5 DESC,
+			u.follower_count DESC, u.id
+		LIMIT $2
+	`
+
+	rows, err := sr.db.Query(ctx, sql, userID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute suggestions: %w", err)
+	}
+	defer rows.Close()
+
+	suggestions := []models.UserSuggestion{}
+	for rows.Next() {
+		var s models.UserSuggestion
+		var mutualUsername *string
+		if err := rows.Scan(&s.Id, &s.Username, &s.Name, &s.Avatar, &s.Bio, &s.IsPrivate, &s.MutualCount, &mutualUsername, &s.FollowsYou); err != nil {
+			return nil, fmt.Errorf("failed to scan suggestion: %w", err)
+		}
+		s.Reason = suggestionReason(s.MutualCount, mutualUsername, s.FollowsYou)
+		suggestions = append(suggestions, s)
+	}
+	return suggestions, rows.Err()
+}
+
+// suggestionReason menyusun alasan rekomendasi, misalnya "followed by budi and 3 others"
+func suggestionReason(mutualCount int, mutualUsername *string, followsYou bool) string {
+	switch {
+	case mutualCount > 0 && mutualUsername != nil:
+		return "followed by " + andOthers(*mutualUsername, mutualCount)
+	case followsYou:
+		return "follows you"
+	default:
+		return "active recently"
+	}
+}
+
+// RefreshSuggestions menghitung ulang rekomendasi user lalu menyimpannya ke redis
+func (sr *SuggestionRepository) RefreshSuggestions(ctx context.Context, userID string) ([]models.UserSuggestion, error) {
+	suggestions, err := sr.ComputeSuggestions(ctx, userID, suggestionListSize)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := json.Marshal(suggestions)
+	if err != nil {
+		return nil, err
+	}
+	if err := sr.rdb.Set(ctx, suggestionKey(userID), data, suggestionTTL).Err(); err != nil {
+		return nil, fmt.Errorf("failed to store suggestions: %w", err)
+	}
+	return suggestions, nil
+}
+
+// GetSuggestions mengambil rekomendasi yang sudah dihitung dari redis. Jika belum ada (user baru aktif),
+// rekomendasi dihitung saat itu juga. Akun yang sejak perhitungan terakhir sudah di-follow, diblokir
+// atau di-mute dibuang dari hasil
+func (sr *SuggestionRepository) GetSuggestions(ctx context.Context, userID string, limit int) ([]models.UserSuggestion, error) {
+	var suggestions []models.UserSuggestion
+	cached, err := sr.rdb.Get(ctx, suggestionKey(userID)).Bytes()
+	if err == nil {
+		if err := json.Unmarshal(cached, &suggestions); err != nil {
+			log.Println("Failed to decode cached suggestions.\nCause: ", err.Error())
+			suggestions = nil
+		}
+	} else if err != redis.Nil {
+		log.Println("Failed to get cached suggestions.\nCause: ", err.Error())
+	}
+
+	if suggestions == nil {
+		suggestions, err = sr.RefreshSuggestions(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	suggestions, err = sr.dropStale(ctx, userID, suggestions)
+	if err != nil {
+		return nil, err
+	}
+	if len(suggestions) > limit {
+		suggestions = suggestions[:limit]
+	}
+	return suggestions, nil
+}
+
+// dropStale membuang rekomendasi yang tidak berlaku lagi sejak disimpan di redis
+func (sr *SuggestionRepository) dropStale(ctx context.Context, userID string, suggestions []models.UserSuggestion) ([]models.UserSuggestion, error) {
+	if len(suggestions) == 0 {
+		return suggestions, nil
+	}
+
+	ids := make([]string, len(suggestions))
+	for i, s := range suggestions {
+		ids[i] = s.Id
+	}
+	rows, err := sr.db.Query(ctx, `
+		SELECT c.id FROM unnest($2::uuid[]) AS c(id)
+		WHERE EXISTS (SELECT 1 FROM follows WHERE follower_id = $1 AND following_id = c.id)
+		OR EXISTS (SELECT 1 FROM follow_requests WHERE requester_id = $1 AND target_id = c.id)
+		OR EXISTS (
+			SELECT 1 FROM blocks
+			WHERE (blocker_id = $1 AND blocked_id = c.id) OR (blocker_id = c.id AND blocked_id = $1)
+		)
+		OR EXISTS (SELECT 1 FROM mutes WHERE muter_id = $1 AND muted_id = c.id)
+		OR NOT EXISTS (SELECT 1 FROM users WHERE id = c.id AND deletion_scheduled_at IS NULL)
+	`, userID, ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to filter suggestions: %w", err)
+	}
+	staleIDs, err := collectIDs(rows)
+	if err != nil {
+		return nil, err
+	}
+	if len(staleIDs) == 0 {
+		return suggestions, nil
+	}
+
+	stale := make(map[string]bool, len(staleIDs))
+	for _, id := range staleIDs {
+		stale[id] = true
+	}
+	fresh := make([]models.UserSuggestion, 0, len(suggestions))
+	for _, s := range suggestions {
+		if !stale[s.Id] {
+			fresh = append(fresh, s)
+		}
+	}
+	return fresh, nil
+}
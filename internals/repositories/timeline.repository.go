@@ -0,0 +1,446 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/raihaninkam/finalPhase3/internals/configs"
+	"github.com/raihaninkam/finalPhase3/internals/models"
+	"github.com/raihaninkam/finalPhase3/internals/utils"
+	"github.com/raihaninkam/finalPhase3/pkg"
+	"github.com/redis/go-redis/v9"
+)
+
+// timelineTTL adalah masa hidup timeline yang tidak pernah dibaca, setelah itu dibangun ulang dari database
+const timelineTTL = 7 * 24 * time.Hour
+
+// timelineFanOutBatch adalah jumlah follower yang diproses per pipeline redis saat fan-out
+const timelineFanOutBatch = 500
+
+// timelinePushScript menambahkan post ke timeline yang sudah ada saja. Timeline yang belum ada
+// (atau sudah expired) dibiarkan kosong agar dibangun ulang lengkap dari database saat dibaca
+var timelinePushScript = redis.NewScript(`
+if redis.call("EXISTS", KEYS[1]) == 0 then
+	return 0
+end
+redis.call("ZADD", KEYS[1], ARGV[1], ARGV[2])
+redis.call("ZREMRANGEBYRANK", KEYS[1], 0, -(tonumber(ARGV[3]) + 1))
+return 1
+`)
+
+// TimelineRepository mengelola home timeline (feed) tiap user sebagai redis sorted set
+// berisi id post dengan score waktu post dibuat (unix mikrodetik).
+// Post user dengan follower >= CelebrityThreshold tidak di-fan-out, tapi diambil dari database saat feed dibaca
+type TimelineRepository struct {
+	db                 *pgxpool.Pool
+	rdb                *redis.Client
+	maxLength          int
+	celebrityThreshold int
+}
+
+func NewTimelineRepository(db *pgxpool.Pool, rdb *redis.Client) *TimelineRepository {
+	return &TimelineRepository{
+		db:                 db,
+		rdb:                rdb,
+		maxLength:          configs.TimelineMaxLength(),
+		celebrityThreshold: configs.CelebrityThreshold(),
+	}
+}
+
+func timelineKey(userID string) string {
+	return utils.RedisKey("timeline", userID)
+}
+
+func timelineScore(t time.Time) float64 {
+	return float64(t.UnixMicro())
+}
+
+// timelineEntry adalah satu post di timeline sebelum dilengkapi datanya dari database
+type timelineEntry struct {
+	id        string
+	createdAt time.Time
+}
+
+// FanOut memasukkan post baru ke timeline semua follower author. Author selebriti dilewati
+func (tr *TimelineRepository) FanOut(ctx context.Context, authorID, postID string, createdAt time.Time) error {
+	celebrity, err := tr.isCelebrity(ctx, authorID)
+	if err != nil {
+		return err
+	}
+	if celebrity {
+		return nil
+	}
+
+	rows, err := tr.db.Query(ctx, `SELECT follower_id FROM follows WHERE following_id = $1`, authorID)
+	if err != nil {
+		return fmt.Errorf("failed to get followers: %w", err)
+	}
+	followerIDs, err := collectIDs(rows)
+	if err != nil {
+		return fmt.Errorf("failed to get followers: %w", err)
+	}
+
+	score := strconv.FormatFloat(timelineScore(createdAt), 'f', -1, 64)
+	for start := 0; start < len(followerIDs); start += timelineFanOutBatch {
+		end := min(start+timelineFanOutBatch, len(followerIDs))
+		pipe := tr.rdb.Pipeline()
+		for _, followerID := range followerIDs[start:end] {
+			timelinePushScript.Run(ctx, pipe, []string{timelineKey(followerID)}, score, postID, tr.maxLength)
+		}
+		if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+			return fmt.Errorf("failed to fan out post: %w", err)
+		}
+	}
+	return nil
+}
+
+// Backfill memasukkan post terbaru dari user yang baru di-follow ke timeline follower
+func (tr *TimelineRepository) Backfill(ctx context.Context, followerID, followingID string) error {
+	key := timelineKey(followerID)
+	exists, err := tr.rdb.Exists(ctx, key).Result()
+	if err != nil {
+		return err
+	}
+	// timeline yang belum ada akan dibangun lengkap saat dibaca
+	if exists == 0 {
+		return nil
+	}
+	celebrity, err := tr.isCelebrity(ctx, followingID)
+	if err != nil || celebrity {
+		return err
+	}
+
+	rows, err := tr.db.Query(ctx, `
+		SELECT id, created_at FROM posts
+		WHERE user_id = $1
+		ORDER BY created_at DESC, id DESC
+		LIMIT $2
+	`, followingID, tr.maxLength)
+	if err != nil {
+		return fmt.Errorf("failed to get posts: %w", err)
+	}
+	entries, err := collectTimelineEntries(rows)
+	if err != nil {
+		return err
+	}
+	return tr.push(ctx, key, entries)
+}
+
+// RemoveAuthor menghapus post milik author dari timeline follower (dipakai saat unfollow)
+func (tr *TimelineRepository) RemoveAuthor(ctx context.Context, followerID, authorID string) error {
+	key := timelineKey(followerID)
+	exists, err := tr.rdb.Exists(ctx, key).Result()
+	if err != nil || exists == 0 {
+		return err
+	}
+
+	rows, err := tr.db.Query(ctx, `
+		SELECT id FROM posts
+		WHERE user_id = $1
+		ORDER BY created_at DESC
+		LIMIT $2
+	`, authorID, tr.maxLength)
+	if err != nil {
+		return fmt.Errorf("failed to get posts: %w", err)
+	}
+	postIDs, err := collectIDs(rows)
+	if err != nil || len(postIDs) == 0 {
+		return err
+	}
+	members := make([]any, len(postIDs))
+	for i, id := range postIDs {
+		members[i] = id
+	}
+	return tr.rdb.ZRem(ctx, key, members...).Err()
+}
+
+// GetTimeline mengambil feed user, terbaru lebih dulu. Timeline redis dibangun ulang dari database
+// jika belum ada, lalu digabung dengan post terbaru dari akun selebriti yang di-follow.
+// Mengembalikan cursor halaman berikutnya, nil jika sudah habis
+func (tr *TimelineRepository) GetTimeline(ctx context.Context, userID string, limit int, cursor *pkg.Cursor) ([]models.PostWithUser, *pkg.Cursor, error) {
+	key := timelineKey(userID)
+	exists, err := tr.rdb.Exists(ctx, key).Result()
+	if err != nil {
+		return nil, nil, err
+	}
+	if exists == 0 {
+		if err := tr.rebuild(ctx, userID); err != nil {
+			return nil, nil, err
+		}
+	}
+	tr.rdb.Expire(ctx, key, timelineTTL)
+
+	// ambil satu entry lebih untuk mengetahui apakah masih ada halaman berikutnya
+	pushed, err := tr.pushedEntries(ctx, key, limit+1, cursor)
+	if err != nil {
+		return nil, nil, err
+	}
+	pulled, err := tr.celebrityEntries(ctx, userID, limit+1, cursor)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// gabungkan, post yang sama bisa ada di keduanya jika author baru saja menjadi selebriti
+	seen := make(map[string]bool, len(pushed)+len(pulled))
+	entries := make([]timelineEntry, 0, len(pushed)+len(pulled))
+	for _, entry := range append(pushed, pulled...) {
+		if !seen[entry.id] {
+			seen[entry.id] = true
+			entries = append(entries, entry)
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if !entries[i].createdAt.Equal(entries[j].createdAt) {
+			return entries[i].createdAt.After(entries[j].createdAt)
+		}
+		return entries[i].id > entries[j].id
+	})
+
+	var next *pkg.Cursor
+	if len(entries) > limit {
+		entries = entries[:limit]
+		last := entries[limit-1]
+		next = pkg.NewCursor(utils.CursorFeed, last.createdAt, last.id)
+	}
+
+	posts, err := tr.hydrate(ctx, key, entries)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// post dari user yang di-mute / ada block tetap di timeline redis agar muncul lagi setelah unmute,
+	// tapi tidak ditampilkan
+	hidden, err := hiddenUserIDs(ctx, tr.db, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(hidden) > 0 {
+		isHidden := make(map[string]bool, len(hidden))
+		for _, id := range hidden {
+			isHidden[id] = true
+		}
+		visible := posts[:0]
+		for _, post := range posts {
+			if !isHidden[post.UserId] {
+				visible = append(visible, post)
+			}
+		}
+		posts = visible
+	}
+	return posts, next, nil
+}
+
+// rebuild membangun timeline user dari post user yang di-follow (kecuali selebriti)
+func (tr *TimelineRepository) rebuild(ctx context.Context, userID string) error {
+	rows, err := tr.db.Query(ctx, `
+		SELECT p.id, p.created_at
+		FROM posts p
+		JOIN follows f ON f.following_id = p.user_id
+		JOIN users u ON u.id = p.user_id
+		WHERE f.follower_id = $1
+		AND u.follower_count < $2
+		ORDER BY p.created_at DESC, p.id DESC
+		LIMIT $3
+	`, userID, tr.celebrityThreshold, tr.maxLength)
+	if err != nil {
+		return fmt.Errorf("failed to rebuild timeline: %w", err)
+	}
+	entries, err := collectTimelineEntries(rows)
+	if err != nil {
+		return fmt.Errorf("failed to rebuild timeline: %w", err)
+	}
+	return tr.push(ctx, timelineKey(userID), entries)
+}
+
+// push menambahkan entry ke timeline lalu memotongnya ke panjang maksimal
+func (tr *TimelineRepository) push(ctx context.Context, key string, entries []timelineEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	members := make([]redis.Z, len(entries))
+	for i, entry := range entries {
+		members[i] = redis.Z{Score: timelineScore(entry.createdAt), Member: entry.id}
+	}
+	pipe := tr.rdb.TxPipeline()
+	pipe.ZAdd(ctx, key, members...)
+	pipe.ZRemRangeByRank(ctx, key, 0, int64(-(tr.maxLength + 1)))
+	pipe.Expire(ctx, key, timelineTTL)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// pushedEntries membaca timeline redis setelah posisi cursor. Post dengan score yang sama
+// diurutkan berdasarkan id (urutan member sorted set), sehingga cocok dengan keyset created_at + id
+func (tr *TimelineRepository) pushedEntries(ctx context.Context, key string, count int, cursor *pkg.Cursor) ([]timelineEntry, error) {
+	max := "+inf"
+	var skip int64
+	if cursor != nil {
+		score := timelineScore(cursor.CreatedAt)
+		max = strconv.FormatFloat(score, 'f', -1, 64)
+		// entry dengan score sama dengan cursor ikut terambil, lewati sampai melewati id cursor
+		tied, err := tr.rdb.ZRangeByScore(ctx, key, &redis.ZRangeBy{Min: max, Max: max}).Result()
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range tied {
+			if id >= cursor.ID {
+				skip++
+			}
+		}
+	}
+
+	members, err := tr.rdb.ZRevRangeByScoreWithScores(ctx, key, &redis.ZRangeBy{
+		Min:    "-inf",
+		Max:    max,
+		Offset: skip,
+		Count:  int64(count),
+	}).Result()
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]timelineEntry, 0, len(members))
+	for _, member := range members {
+		entries = append(entries, timelineEntry{
+			id:        member.Member.(string),
+			createdAt: time.UnixMicro(int64(member.Score)),
+		})
+	}
+	return entries, nil
+}
+
+// celebrityEntries mengambil post terbaru dari akun selebriti yang di-follow user (pull saat dibaca)
+func (tr *TimelineRepository) celebrityEntries(ctx context.Context, userID string, count int, cursor *pkg.Cursor) ([]timelineEntry, error) {
+	var after *time.Time
+	var afterID *string
+	if cursor != nil {
+		after, afterID = &cursor.CreatedAt, &cursor.ID
+	}
+	rows, err := tr.db.Query(ctx, `
+		SELECT p.id, p.created_at
+		FROM posts p
+		WHERE p.user_id IN (
+			SELECT f.following_id FROM follows f
+			JOIN users u ON u.id = f.following_id
+			WHERE f.follower_id = $1
+			AND u.follower_count >= $2
+		)
+		AND ($3::timestamptz IS NULL OR (p.created_at, p.id) < ($3, $4::uuid))
+		ORDER BY p.created_at DESC, p.id DESC
+		LIMIT $5
+	`, userID, tr.celebrityThreshold, after, afterID, count)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get celebrity posts: %w", err)
+	}
+	return collectTimelineEntries(rows)
+}
+
+// hydrate melengkapi entry timeline dengan isi post dan author, urutan entry dipertahankan.
+// Post yang sudah dihapus dibuang dari hasil dan dari timeline redis
+func (tr *TimelineRepository) hydrate(ctx context.Context, key string, entries []timelineEntry) ([]models.PostWithUser, error) {
+	posts := []models.PostWithUser{}
+	if len(entries) == 0 {
+		return posts, nil
+	}
+	ids := make([]string, len(entries))
+	for i, entry := range entries {
+		ids[i] = entry.id
+	}
+
+	sql := `
+		SELECT
+			p.id,
+			p.user_id,
+			COALESCE(p.content_text, ''),
+			COALESCE(p.image_url, ''),
+			p.created_at,
+			p.edited_at,
+			u.name as user_name,
+			COALESCE(u.avatar_url, '') as user_avatar
+		FROM posts p
+		JOIN users u ON p.user_id = u.id
+		WHERE p.id = ANY($1::uuid[])
+	`
+	rows, err := tr.db.Query(ctx, sql, ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get timeline posts: %w", err)
+	}
+	defer rows.Close()
+
+	byID := make(map[string]models.PostWithUser, len(entries))
+	for rows.Next() {
+		var post models.PostWithUser
+		if err := rows.Scan(
+			&post.Id,
+			&post.UserId,
+			&post.Content,
+			&post.ImageUrl,
+			&post.CreatedAt,
+			&post.EditedAt,
+			&post.UserName,
+			&post.UserAvatar,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan post: %w", err)
+		}
+		post.IsEdited = post.EditedAt != nil
+		byID[post.Id] = post
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	var deleted []any
+	for _, entry := range entries {
+		post, ok := byID[entry.id]
+		if !ok {
+			deleted = append(deleted, entry.id)
+			continue
+		}
+		posts = append(posts, post)
+	}
+	if len(deleted) > 0 {
+		if err := tr.rdb.ZRem(ctx, key, deleted...).Err(); err != nil {
+			log.Println("Failed to remove deleted posts from timeline.\nCause: ", err.Error())
+		}
+	}
+	return posts, nil
+}
+
+func (tr *TimelineRepository) isCelebrity(ctx context.Context, userID string) (bool, error) {
+	var followers int
+	if err := tr.db.QueryRow(ctx, `SELECT follower_count FROM users WHERE id = $1`, userID).Scan(&followers); err != nil {
+		return false, fmt.Errorf("failed to count followers: %w", err)
+	}
+	return followers >= tr.celebrityThreshold, nil
+}
+
+func collectIDs(rows pgx.Rows) ([]string, error) {
+	defer rows.Close()
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+func collectTimelineEntries(rows pgx.Rows) ([]timelineEntry, error) {
+	defer rows.Close()
+	var entries []timelineEntry
+	for rows.Next() {
+		var entry timelineEntry
+		if err := rows.Scan(&entry.id, &entry.createdAt); err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
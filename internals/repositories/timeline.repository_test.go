@@ -0,0 +1,157 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/raihaninkam/finalPhase3/internals/utils"
+	"github.com/raihaninkam/finalPhase3/pkg"
+)
+
+// timelineIDs mengambil id post dari satu halaman timeline
+func timelineIDs(t *testing.T, tr *TimelineRepository, userID string, limit int, cursor *pkg.Cursor) ([]string, *pkg.Cursor) {
+	t.Helper()
+	posts, next, err := tr.GetTimeline(context.Background(), userID, limit, cursor)
+	if err != nil {
+		t.Fatalf("GetTimeline() error: %v", err)
+	}
+	ids := make([]string, len(posts))
+	for i, post := range posts {
+		ids[i] = post.Id
+	}
+	return ids, next
+}
+
+func TestTimelineFanOut(t *testing.T) {
+	db, rdb := newTestStores(t)
+	ctx := context.Background()
+	tr := NewTimelineRepository(db, rdb)
+
+	author := createTestUser(t, db, rdb, "budi")
+	reader := createTestUser(t, db, rdb, "ani")
+	if _, err := NewFollowsRepository(db, rdb).Follow(ctx, reader.ID, author.ID); err != nil {
+		t.Fatal(err)
+	}
+	old := createTestPost(t, db, rdb, author.ID, "before the timeline existed")
+
+	// timeline pertama kali dibaca dibangun dari database
+	ids, _ := timelineIDs(t, tr, reader.ID, 10, nil)
+	if len(ids) != 1 || ids[0] != old.Id {
+		t.Fatalf("rebuilt timeline = %v, want [%s]", ids, old.Id)
+	}
+
+	post := createTestPost(t, db, rdb, author.ID, "new post")
+	if err := tr.FanOut(ctx, author.ID, post.Id, *post.CreatedAt); err != nil {
+		t.Fatalf("FanOut() error: %v", err)
+	}
+	if n, _ := rdb.ZCard(ctx, timelineKey(reader.ID)).Result(); n != 2 {
+		t.Errorf("redis timeline has %d posts, want 2", n)
+	}
+	ids, _ = timelineIDs(t, tr, reader.ID, 10, nil)
+	if len(ids) != 2 || ids[0] != post.Id || ids[1] != old.Id {
+		t.Errorf("timeline after fan-out = %v, want [%s %s]", ids, post.Id, old.Id)
+	}
+
+	// post dari user yang di-mute disembunyikan, tapi muncul lagi setelah unmute
+	br := NewBlockRepository(db, rdb)
+	if err := br.Mute(ctx, reader.ID, author.ID); err != nil {
+		t.Fatal(err)
+	}
+	if ids, _ := timelineIDs(t, tr, reader.ID, 10, nil); len(ids) != 0 {
+		t.Errorf("timeline with muted author = %v, want empty", ids)
+	}
+	if err := br.Unmute(ctx, reader.ID, author.ID); err != nil {
+		t.Fatal(err)
+	}
+	if ids, _ := timelineIDs(t, tr, reader.ID, 10, nil); len(ids) != 2 {
+		t.Errorf("timeline after unmute = %v, want 2 posts", ids)
+	}
+
+	// follow baru mengisi timeline dengan post lama author tsb, unfollow menghapusnya
+	other := createTestUser(t, db, rdb, "cici")
+	otherPost := createTestPost(t, db, rdb, other.ID, "hello from cici")
+	if _, err := NewFollowsRepository(db, rdb).Follow(ctx, reader.ID, other.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := tr.Backfill(ctx, reader.ID, other.ID); err != nil {
+		t.Fatalf("Backfill() error: %v", err)
+	}
+	if ids, _ := timelineIDs(t, tr, reader.ID, 10, nil); len(ids) != 3 || ids[0] != otherPost.Id {
+		t.Errorf("timeline after backfill = %v, want %s first", ids, otherPost.Id)
+	}
+	if err := tr.RemoveAuthor(ctx, reader.ID, other.ID); err != nil {
+		t.Fatalf("RemoveAuthor() error: %v", err)
+	}
+	if ids, _ := timelineIDs(t, tr, reader.ID, 10, nil); len(ids) != 2 {
+		t.Errorf("timeline after RemoveAuthor = %v, want 2 posts", ids)
+	}
+
+	// post yang dihapus dibuang dari timeline
+	if _, err := NewPostRepository(db, rdb).DeletePost(ctx, old.Id, author.ID, false); err != nil {
+		t.Fatal(err)
+	}
+	if ids, _ := timelineIDs(t, tr, reader.ID, 10, nil); len(ids) != 1 || ids[0] != post.Id {
+		t.Errorf("timeline after delete = %v, want [%s]", ids, post.Id)
+	}
+}
+
+func TestTimelineMergesCelebrityPosts(t *testing.T) {
+	// author dengan 2 follower atau lebih dianggap selebriti
+	t.Setenv("TIMELINE_CELEBRITY_THRESHOLD", "2")
+	db, rdb := newTestStores(t)
+	ctx := context.Background()
+	tr := NewTimelineRepository(db, rdb)
+	fr := NewFollowsRepository(db, rdb)
+
+	celebrity := createTestUser(t, db, rdb, "artis")
+	friend := createTestUser(t, db, rdb, "budi")
+	reader := createTestUser(t, db, rdb, "ani")
+	other := createTestUser(t, db, rdb, "cici")
+	for _, follow := range [][2]string{{reader.ID, celebrity.ID}, {other.ID, celebrity.ID}, {reader.ID, friend.ID}} {
+		if _, err := fr.Follow(ctx, follow[0], follow[1]); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// post bergantian dari selebriti (pull) dan teman (push), urutan terbaru lebih dulu
+	var want []string
+	for i := range 5 {
+		author := friend.ID
+		if i%2 == 0 {
+			author = celebrity.ID
+		}
+		post := createTestPost(t, db, rdb, author, fmt.Sprintf("post %d", i))
+		if err := tr.FanOut(ctx, author, post.Id, *post.CreatedAt); err != nil {
+			t.Fatal(err)
+		}
+		want = append([]string{post.Id}, want...)
+		time.Sleep(time.Millisecond)
+	}
+
+	var got []string
+	var cursor *pkg.Cursor
+	for pages := 0; ; pages++ {
+		if pages > 3 {
+			t.Fatal("pagination did not end")
+		}
+		ids, next := timelineIDs(t, tr, reader.ID, 2, cursor)
+		got = append(got, ids...)
+		if next == nil {
+			break
+		}
+		var err error
+		cursor, err = pkg.DecodeCursor(next.Encode(), utils.CursorFeed)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("paginated timeline = %v, want %v", got, want)
+	}
+	// timeline redis hanya berisi post dari author yang bukan selebriti
+	if n, _ := rdb.ZCard(ctx, timelineKey(reader.ID)).Result(); n != 2 {
+		t.Errorf("redis timeline has %d posts, want 2", n)
+	}
+}
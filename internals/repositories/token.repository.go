@@ -0,0 +1,223 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/raihaninkam/finalPhase3/pkg"
+	"github.com/redis/go-redis/v9"
+)
+
+type TokenRepository struct {
+	db  *pgxpool.Pool
+	rdb *redis.Client
+}
+
+func NewTokenRepository(db *pgxpool.Pool, rdb *redis.Client) *TokenRepository {
+	return &TokenRepository{
+		db:  db,
+		rdb: rdb,
+	}
+}
+
+// CreateRefreshToken membuat refresh token pertama (family baru) untuk sesi login
+func (tr *TokenRepository) CreateRefreshToken(ctx context.Context, userID, sessionID string) (string, error) {
+	return tr.insertRefreshToken(ctx, tr.db, userID, sessionID, "")
+}
+
+// RotateRefreshToken menukar refresh token lama dengan yang baru pada family dan sesi yang sama.
+// Jika token yang sudah pernah dipakai dikirim lagi (reuse), seluruh family beserta sesinya dicabut.
+// Mengembalikan refresh token baru, user id dan session id
+func (tr *TokenRepository) RotateRefreshToken(ctx context.Context, token string) (string, string, string, error) {
+	tx, err := tr.db.Begin(ctx)
+	if err != nil {
+		log.Println("Failed to begin transaction:", err.Error())
+		return "", "", "", err
+	}
+	defer tx.Rollback(ctx)
+
+	sql := `SELECT rt.id, rt.user_id, rt.family_id, COALESCE(rt.session_id::text, ''), rt.expires_at, rt.used_at, rt.revoked_at
+	        FROM refresh_tokens rt
+	        WHERE rt.token_hash = $1
+	        FOR UPDATE`
+
+	var (
+		id, userID, familyID, sessionID string
+		expiresAt                       time.Time
+		usedAt, revokedAt               *time.Time
+	)
+	if err := tx.QueryRow(ctx, sql, pkg.HashToken(token)).Scan(&id, &userID, &familyID, &sessionID, &expiresAt, &usedAt, &revokedAt); err != nil {
+		if err == pgx.ErrNoRows {
+			return "", "", "", errors.New("refresh token not found")
+		}
+		log.Println("Failed to get refresh token.\nCause: ", err.Error())
+		return "", "", "", err
+	}
+
+	if revokedAt != nil {
+		return "", "", "", errors.New("refresh token revoked")
+	}
+
+	// token sudah pernah ditukar -> kemungkinan dicuri, cabut seluruh family dan sesinya
+	if usedAt != nil {
+		if _, err := tx.Exec(ctx, `UPDATE refresh_tokens SET revoked_at = now() WHERE family_id = $1 AND revoked_at IS NULL`, familyID); err != nil {
+			log.Println("Failed to revoke refresh token family.\nCause: ", err.Error())
+			return "", "", "", err
+		}
+		if sessionID != "" {
+			if _, err := tx.Exec(ctx, `UPDATE sessions SET revoked_at = now() WHERE id = $1 AND revoked_at IS NULL`, sessionID); err != nil {
+				log.Println("Failed to revoke session.\nCause: ", err.Error())
+				return "", "", "", err
+			}
+		}
+		// access token sesi ini juga harus langsung ditolak. Ditandai sebelum commit agar jika redis gagal,
+		// pencabutan di-rollback dan reuse berikutnya mengulang seluruh proses ini
+		if sessionID != "" {
+			if err := markSessionsRevoked(ctx, tr.rdb, sessionID); err != nil {
+				return "", "", "", err
+			}
+		}
+		if err := tx.Commit(ctx); err != nil {
+			log.Println("Failed to commit transaction:", err.Error())
+			return "", "", "", err
+		}
+		log.Printf("Refresh token reuse detected for user %s, family %s revoked", userID, familyID)
+		return "", "", "", errors.New("refresh token reused")
+	}
+
+	if time.Now().After(expiresAt) {
+		return "", "", "", errors.New("refresh token expired")
+	}
+
+	if _, err := tx.Exec(ctx, `UPDATE refresh_tokens SET used_at = now() WHERE id = $1`, id); err != nil {
+		log.Println("Failed to mark refresh token as used.\nCause: ", err.Error())
+		return "", "", "", err
+	}
+	if sessionID != "" {
+		if _, err := tx.Exec(ctx, `UPDATE sessions SET last_seen_at = now() WHERE id = $1`, sessionID); err != nil {
+			log.Println("Failed to update session.\nCause: ", err.Error())
+			return "", "", "", err
+		}
+	}
+
+	newToken, err := tr.insertRefreshToken(ctx, tx, userID, sessionID, familyID)
+	if err != nil {
+		return "", "", "", err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		log.Println("Failed to commit transaction:", err.Error())
+		return "", "", "", err
+	}
+
+	return newToken, userID, sessionID, nil
+}
+
+// RevokeUserRefreshTokens mencabut semua refresh token milik user kecuali milik sesi exceptSessionID
+// (kosongkan untuk mencabut semuanya, misalnya logout semua device)
+func (tr *TokenRepository) RevokeUserRefreshTokens(ctx context.Context, userID, exceptSessionID string) error {
+	sql := `UPDATE refresh_tokens SET revoked_at = now()
+	        WHERE user_id = $1 AND revoked_at IS NULL
+	        AND (session_id IS NULL OR session_id::text <> $2)`
+
+	if _, err := tr.db.Exec(ctx, sql, userID, exceptSessionID); err != nil {
+		log.Println("Failed to revoke refresh tokens.\nCause: ", err.Error())
+		return err
+	}
+	return nil
+}
+
+// CreatePasswordResetToken membuat token reset password sekali pakai.
+// Token reset lama yang belum dipakai ikut dinonaktifkan
+func (tr *TokenRepository) CreatePasswordResetToken(ctx context.Context, userID string) (string, error) {
+	token, err := pkg.GenRandomToken(32)
+	if err != nil {
+		return "", err
+	}
+
+	tx, err := tr.db.Begin(ctx)
+	if err != nil {
+		log.Println("Failed to begin transaction:", err.Error())
+		return "", err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `UPDATE password_resets SET used_at = now() WHERE user_id = $1 AND used_at IS NULL`, userID); err != nil {
+		log.Println("Failed to invalidate password reset tokens.\nCause: ", err.Error())
+		return "", err
+	}
+
+	sql := `INSERT INTO password_resets (user_id, token_hash, expires_at) VALUES ($1, $2, $3)`
+	if _, err := tx.Exec(ctx, sql, userID, pkg.HashToken(token), time.Now().Add(pkg.PasswordResetTokenTTL)); err != nil {
+		log.Println("Failed to create password reset token.\nCause: ", err.Error())
+		return "", err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		log.Println("Failed to commit transaction:", err.Error())
+		return "", err
+	}
+	return token, nil
+}
+
+// ResetPassword memakai token reset password dan mengganti password user dalam satu transaksi.
+// Mengembalikan id user pemilik token
+func (tr *TokenRepository) ResetPassword(ctx context.Context, token, hashedPassword string) (string, error) {
+	tx, err := tr.db.Begin(ctx)
+	if err != nil {
+		log.Println("Failed to begin transaction:", err.Error())
+		return "", err
+	}
+	defer tx.Rollback(ctx)
+
+	sql := `UPDATE password_resets SET used_at = now()
+	        WHERE token_hash = $1 AND used_at IS NULL AND expires_at > now()
+	        RETURNING user_id`
+
+	var userID string
+	if err := tx.QueryRow(ctx, sql, pkg.HashToken(token)).Scan(&userID); err != nil {
+		if err == pgx.ErrNoRows {
+			return "", errors.New("reset token invalid")
+		}
+		log.Println("Failed to use password reset token.\nCause: ", err.Error())
+		return "", err
+	}
+
+	if _, err := tx.Exec(ctx, `UPDATE users SET password_hash = $1, updated_at = now() WHERE id = $2`, hashedPassword, userID); err != nil {
+		log.Println("Failed to update password.\nCause: ", err.Error())
+		return "", err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		log.Println("Failed to commit transaction:", err.Error())
+		return "", err
+	}
+	return userID, nil
+}
+
+type rowQuerier interface {
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+// insertRefreshToken menyimpan hash refresh token baru. Jika familyID kosong maka dibuat family baru
+func (tr *TokenRepository) insertRefreshToken(ctx context.Context, q rowQuerier, userID, sessionID, familyID string) (string, error) {
+	token, err := pkg.GenRandomToken(32)
+	if err != nil {
+		return "", err
+	}
+
+	sql := `INSERT INTO refresh_tokens (user_id, session_id, family_id, token_hash, expires_at)
+	        VALUES ($1, NULLIF($2, '')::uuid, COALESCE(NULLIF($3, '')::uuid, gen_random_uuid()), $4, $5)
+	        RETURNING id`
+
+	var id string
+	if err := q.QueryRow(ctx, sql, userID, sessionID, familyID, pkg.HashToken(token), time.Now().Add(pkg.RefreshTokenTTL)).Scan(&id); err != nil {
+		log.Println("Failed to create refresh token.\nCause: ", err.Error())
+		return "", err
+	}
+	return token, nil
+}
@@ -0,0 +1,51 @@
+package repositories
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/raihaninkam/finalPhase3/internals/models"
+	"github.com/raihaninkam/finalPhase3/internals/utils"
+	"github.com/redis/go-redis/v9"
+)
+
+func TestRotateRefreshTokenReuse(t *testing.T) {
+	db, rdb := newTestStores(t)
+	ctx := context.Background()
+	user := createTestUser(t, db, rdb, "budi")
+
+	session := &models.Session{UserId: user.ID}
+	if err := NewSessionRepository(db, rdb).CreateSession(ctx, session); err != nil {
+		t.Fatal(err)
+	}
+	tr := NewTokenRepository(db, rdb)
+	first, err := tr.CreateRefreshToken(ctx, user.ID, session.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rotated, userID, sessionID, err := tr.RotateRefreshToken(ctx, first)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if userID != user.ID || sessionID != session.Id {
+		t.Errorf("RotateRefreshToken() = %s, %s, want %s, %s", userID, sessionID, user.ID, session.Id)
+	}
+
+	// tanda sesi dicabut tidak bisa disimpan di redis -> refresh gagal dengan error internal dan pencabutan di-rollback
+	broken := redis.NewClient(&redis.Options{Addr: rdb.Options().Addr})
+	broken.Close()
+	if _, _, _, err := NewTokenRepository(db, broken).RotateRefreshToken(ctx, first); err == nil || strings.Contains(err.Error(), "refresh token") {
+		t.Errorf("reuse with redis down error = %v, want a redis error", err)
+	}
+
+	if _, _, _, err := tr.RotateRefreshToken(ctx, first); err == nil || err.Error() != "refresh token reused" {
+		t.Errorf("RotateRefreshToken() on a reused token error = %v, want refresh token reused", err)
+	}
+	if _, _, _, err := tr.RotateRefreshToken(ctx, rotated); err == nil || err.Error() != "refresh token revoked" {
+		t.Errorf("RotateRefreshToken() on the rotated token error = %v, want refresh token revoked", err)
+	}
+	if rdb.Exists(ctx, utils.RedisKey("session-revoked", session.Id)).Val() != 1 {
+		t.Error("session is not marked revoked on redis")
+	}
+}
@@ -0,0 +1,65 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/raihaninkam/finalPhase3/internals/models"
+	"github.com/raihaninkam/finalPhase3/internals/utils"
+	"github.com/redis/go-redis/v9"
+)
+
+type UserRepository struct {
+	db  *pgxpool.Pool
+	rdb *redis.Client
+}
+
+func NewUserRepository(db *pgxpool.Pool, rdb *redis.Client) *UserRepository {
+	return &UserRepository{
+		db:  db,
+		rdb: rdb,
+	}
+}
+
+// GetProfile mengambil profil publik berdasarkan username atau id user, beserta jumlah follower / following
+// dan apakah viewer mengikuti user tersebut
+func (ur *UserRepository) GetProfile(ctx context.Context, usernameOrID, viewerID string) (*models.PublicProfile, error) {
+	column := "u.username"
+	if utils.IsUUID(usernameOrID) {
+		column = "u.id"
+	}
+
+	sql := `
+		SELECT u.id, u.username, u.name, u.avatar_url, u.bio, u.created_at,
+			u.follower_count, u.following_count, u.is_private,
+			EXISTS(SELECT 1 FROM follows WHERE follower_id = $2 AND following_id = u.id),
+			EXISTS(SELECT 1 FROM follow_requests WHERE requester_id = $2 AND target_id = u.id)
+		FROM users u
+		WHERE ` + column + ` = $1
+	`
+
+	var profile models.PublicProfile
+	if err := ur.db.QueryRow(ctx, sql, usernameOrID, viewerID).Scan(
+		&profile.Id,
+		&profile.Username,
+		&profile.Name,
+		&profile.Avatar,
+		&profile.Bio,
+		&profile.CreatedAt,
+		&profile.FollowersCount,
+		&profile.FollowingCount,
+		&profile.IsPrivate,
+		&profile.IsFollowing,
+		&profile.IsRequested,
+	); err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, errors.New("user not found")
+		}
+		log.Println("Failed to get profile.\nCause: ", err.Error())
+		return nil, err
+	}
+	return &profile, nil
+}
@@ -0,0 +1,22 @@
+package routers
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/raihaninkam/finalPhase3/internals/handlers"
+	middleware "github.com/raihaninkam/finalPhase3/internals/middlewares"
+	"github.com/raihaninkam/finalPhase3/internals/models"
+	"github.com/raihaninkam/finalPhase3/internals/repositories"
+	"github.com/redis/go-redis/v9"
+)
+
+func InitAdminRouter(router *gin.Engine, db *pgxpool.Pool, rdb *redis.Client) {
+	adminRouter := router.Group("/admin", middleware.VerifyToken(rdb))
+	adminRepository := repositories.NewAdminRepository(db, rdb)
+	loginAttemptRepository := repositories.NewLoginAttemptRepository(rdb)
+	adminHandler := handlers.NewAdminHandler(adminRepository, loginAttemptRepository)
+
+	adminRouter.GET("/users", middleware.RequireRole(models.RoleAdmin, models.RoleModerator), adminHandler.ListUsers)
+	adminRouter.POST("/users/:id/unlock", middleware.RequireRole(models.RoleAdmin, models.RoleModerator), adminHandler.UnlockUser)
+	adminRouter.PATCH("/users/:id/role", middleware.RequireRole(models.RoleAdmin), adminHandler.UpdateRole)
+}
@@ -0,0 +1,57 @@
+package routers
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/raihaninkam/finalPhase3/internals/models"
+	"github.com/raihaninkam/finalPhase3/internals/repositories"
+	"github.com/raihaninkam/finalPhase3/pkg"
+)
+
+func TestDemotedAdminLosesAdminAccess(t *testing.T) {
+	db, rdb := newTestStores(t)
+	ctx := context.Background()
+
+	hc := pkg.NewHashConfig()
+	hc.UseRecommended()
+	hash, err := hc.GenHash("Secret123!")
+	if err != nil {
+		t.Fatal(err)
+	}
+	adr := repositories.NewAdminRepository(db, rdb)
+	admin := createTestUser(t, db, rdb, "budi", hash)
+	demoted := createTestUser(t, db, rdb, "rina", hash)
+	for _, user := range []*models.User{admin, demoted} {
+		if _, err := adr.UpdateRole(ctx, user.ID, models.RoleAdmin); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	router := InitRouter(db, rdb, nil)
+	adminToken := login(t, router, admin.Email, "Secret123!")
+	oldToken := login(t, router, demoted.Email, "Secret123!")
+	if rec := doJSON(router, http.MethodGet, "/admin/users", oldToken, ""); rec.Code != http.StatusOK {
+		t.Fatalf("GET /admin/users as admin = %d, want %d", rec.Code, http.StatusOK)
+	}
+
+	rec := doJSON(router, http.MethodPatch, "/admin/users/"+demoted.ID+"/role", adminToken, `{"role":"user"}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("PATCH role = %d %s, want %d", rec.Code, rec.Body.String(), http.StatusOK)
+	}
+
+	// token lama masih membawa role admin, tapi sesinya sudah dicabut
+	if rec := doJSON(router, http.MethodGet, "/admin/users", oldToken, ""); rec.Code != http.StatusUnauthorized {
+		t.Errorf("GET /admin/users with the old token = %d, want %d", rec.Code, http.StatusUnauthorized)
+	}
+	// token baru membawa role user
+	newToken := login(t, router, demoted.Email, "Secret123!")
+	if rec := doJSON(router, http.MethodGet, "/admin/users", newToken, ""); rec.Code != http.StatusForbidden {
+		t.Errorf("GET /admin/users after demotion = %d, want %d", rec.Code, http.StatusForbidden)
+	}
+	// admin yang mengubah role tidak ikut kehilangan sesinya
+	if rec := doJSON(router, http.MethodGet, "/admin/users", adminToken, ""); rec.Code != http.StatusOK {
+		t.Errorf("GET /admin/users as the remaining admin = %d, want %d", rec.Code, http.StatusOK)
+	}
+}
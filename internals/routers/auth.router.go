@@ -6,17 +6,41 @@ import (
 	"github.com/raihaninkam/finalPhase3/internals/handlers"
 	middleware "github.com/raihaninkam/finalPhase3/internals/middlewares"
 	"github.com/raihaninkam/finalPhase3/internals/repositories"
+	"github.com/raihaninkam/finalPhase3/pkg"
 	"github.com/redis/go-redis/v9"
 )
 
-func InitAuthRouter(router *gin.Engine, db *pgxpool.Pool, rdb *redis.Client) {
+func InitAuthRouter(router *gin.Engine, db *pgxpool.Pool, rdb *redis.Client, mailer pkg.Mailer) {
 	authRouter := router.Group("/auth")
 	authRepository := repositories.NewAuthRepository(db, rdb)
-	authHandler := handlers.NewAuthHandler(authRepository)
+	tokenRepository := repositories.NewTokenRepository(db, rdb)
+	sessionRepository := repositories.NewSessionRepository(db, rdb)
+	loginAttemptRepository := repositories.NewLoginAttemptRepository(rdb)
+	mfaRepository := repositories.NewMfaRepository(db, rdb)
+	authHandler := handlers.NewAuthHandler(authRepository, tokenRepository, sessionRepository, loginAttemptRepository, mfaRepository, mailer)
 
 	authRouter.POST("/register", authHandler.Register)
 	authRouter.POST("/login", authHandler.Login)
+	authRouter.POST("/refresh", authHandler.Refresh)
+	authRouter.POST("/forgot-password", authHandler.ForgotPassword)
+	authRouter.POST("/reset-password", authHandler.ResetPassword)
+	authRouter.POST("/verify-email", authHandler.VerifyEmail)
+	authRouter.POST("/verify-email/resend", authHandler.ResendVerification)
+	authRouter.POST("/unlock", authHandler.UnlockAccount)
+	authRouter.POST("/mfa/verify", authHandler.VerifyMfa)
+
+	authRouter.POST("/logout", middleware.VerifyToken(rdb), authHandler.Logout)
+	authRouter.POST("/logout-all", middleware.VerifyToken(rdb), authHandler.LogoutAll)
+
+	authRouter.POST("/mfa/enroll", middleware.VerifyToken(rdb), authHandler.EnrollMfa)
+	authRouter.POST("/mfa/confirm", middleware.VerifyToken(rdb), authHandler.ConfirmMfa)
+	authRouter.DELETE("/mfa", middleware.VerifyToken(rdb), authHandler.DisableMfa)
+
+	authRouter.GET("/sessions", middleware.VerifyToken(rdb), authHandler.GetSessions)
+	authRouter.DELETE("/sessions/:id", middleware.VerifyToken(rdb), authHandler.RevokeSession)
 
 	authRouter.PATCH("/profile", middleware.VerifyToken(rdb), authHandler.UpdateProfile)
+	authRouter.PATCH("/password", middleware.VerifyToken(rdb), authHandler.ChangePassword)
+	authRouter.DELETE("/account", middleware.VerifyToken(rdb), authHandler.DeleteAccount)
 
 }
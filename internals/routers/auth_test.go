@@ -0,0 +1,149 @@
+package routers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/raihaninkam/finalPhase3/internals/repositories"
+	"github.com/raihaninkam/finalPhase3/pkg"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestLoginRehashesBcryptPassword(t *testing.T) {
+	db, rdb := newTestStores(t)
+	ctx := context.Background()
+
+	// user hasil import data lama yang password-nya masih bcrypt
+	hash, err := bcrypt.GenerateFromPassword([]byte("Secret123!"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	user := createTestUser(t, db, rdb, "budi", string(hash))
+
+	router := InitRouter(db, rdb, nil)
+	login(t, router, user.Email, "Secret123!")
+
+	stored, err := repositories.NewAuthRepository(db, rdb).GetEmail(ctx, user.Email)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(stored.Password, "$argon2id$") {
+		t.Fatalf("stored hash = %s, want argon2id", stored.Password)
+	}
+	hc := pkg.NewHashConfig()
+	hc.UseRecommended()
+	if hc.NeedsRehash(stored.Password) {
+		t.Errorf("stored hash %s does not use the recommended parameters", stored.Password)
+	}
+
+	// password yang sama tetap bisa dipakai login dengan hash baru
+	login(t, router, user.Email, "Secret123!")
+}
+
+func TestRefreshTokenReuseRevokesSession(t *testing.T) {
+	db, rdb := newTestStores(t)
+
+	hc := pkg.NewHashConfig()
+	hc.UseRecommended()
+	hash, err := hc.GenHash("Secret123!")
+	if err != nil {
+		t.Fatal(err)
+	}
+	user := createTestUser(t, db, rdb, "budi", hash)
+	router := InitRouter(db, rdb, nil)
+
+	type tokens struct {
+		Token        string `json:"token"`
+		RefreshToken string `json:"refresh_token"`
+	}
+	refresh := func(refreshToken string) (int, tokens) {
+		rec := doJSON(router, http.MethodPost, "/auth/refresh", "", fmt.Sprintf(`{"refresh_token":%q}`, refreshToken))
+		var body tokens
+		json.Unmarshal(rec.Body.Bytes(), &body)
+		return rec.Code, body
+	}
+
+	rec := doJSON(router, http.MethodPost, "/auth/login", "", fmt.Sprintf(`{"email":%q,"password":"Secret123!"}`, user.Email))
+	var first tokens
+	if err := json.Unmarshal(rec.Body.Bytes(), &first); err != nil || rec.Code != http.StatusOK {
+		t.Fatalf("login = %d %s", rec.Code, rec.Body.String())
+	}
+
+	code, rotated := refresh(first.RefreshToken)
+	if code != http.StatusOK {
+		t.Fatalf("refresh = %d, want %d", code, http.StatusOK)
+	}
+	if rec := doJSON(router, http.MethodGet, "/auth/sessions", rotated.Token, ""); rec.Code != http.StatusOK {
+		t.Fatalf("GET /auth/sessions with the rotated token = %d, want %d", rec.Code, http.StatusOK)
+	}
+
+	// refresh token lama dipakai lagi -> seluruh family dan sesinya dicabut
+	if code, _ := refresh(first.RefreshToken); code != http.StatusUnauthorized {
+		t.Errorf("reused refresh = %d, want %d", code, http.StatusUnauthorized)
+	}
+	if code, _ := refresh(rotated.RefreshToken); code != http.StatusUnauthorized {
+		t.Errorf("refresh with the rotated token after reuse = %d, want %d", code, http.StatusUnauthorized)
+	}
+	for name, token := range map[string]string{"login": first.Token, "rotated": rotated.Token} {
+		if rec := doJSON(router, http.MethodGet, "/auth/sessions", token, ""); rec.Code != http.StatusUnauthorized {
+			t.Errorf("GET /auth/sessions with the %s access token = %d, want %d", name, rec.Code, http.StatusUnauthorized)
+		}
+	}
+}
+
+func TestChangePasswordLockout(t *testing.T) {
+	db, rdb := newTestStores(t)
+
+	hc := pkg.NewHashConfig()
+	hc.UseRecommended()
+	hash, err := hc.GenHash("Secret123!")
+	if err != nil {
+		t.Fatal(err)
+	}
+	user := createTestUser(t, db, rdb, "budi", hash)
+	router := InitRouter(db, rdb, pkg.NewLogMailer(""))
+	token := login(t, router, user.Email, "Secret123!")
+
+	changePassword := func(current string) int {
+		body := fmt.Sprintf(`{"current_password":%q,"new_password":"NewSecret456!"}`, current)
+		return doJSON(router, http.MethodPatch, "/auth/password", token, body).Code
+	}
+
+	// access token yang dicuri tidak bisa dipakai untuk menebak password tanpa batas
+	for i := range 5 {
+		if code := changePassword("wrong-password"); code != http.StatusBadRequest {
+			t.Fatalf("attempt %d = %d, want %d", i+1, code, http.StatusBadRequest)
+		}
+	}
+	if code := changePassword("Secret123!"); code != http.StatusLocked {
+		t.Errorf("change password with a locked account = %d, want %d", code, http.StatusLocked)
+	}
+	// kunci yang sama berlaku untuk login
+	rec := doJSON(router, http.MethodPost, "/auth/login", "", fmt.Sprintf(`{"email":%q,"password":"Secret123!"}`, user.Email))
+	if rec.Code != http.StatusLocked {
+		t.Errorf("login with a locked account = %d, want %d", rec.Code, http.StatusLocked)
+	}
+
+	// setelah kunci dibuka, password yang benar mereset counter gagal
+	if err := repositories.NewLoginAttemptRepository(rdb).ResetAccount(context.Background(), user.Email); err != nil {
+		t.Fatal(err)
+	}
+	for range 4 {
+		changePassword("wrong-password")
+	}
+	if code := changePassword("Secret123!"); code != http.StatusOK {
+		t.Fatalf("change password = %d, want %d", code, http.StatusOK)
+	}
+	for i := range 4 {
+		if code := changePassword("wrong-password"); code != http.StatusBadRequest {
+			t.Fatalf("attempt %d after a successful change = %d, want %d", i+1, code, http.StatusBadRequest)
+		}
+	}
+	if code := changePassword("NewSecret456!"); code != http.StatusOK {
+		t.Errorf("change password after 4 failures = %d, want %d", code, http.StatusOK)
+	}
+}
@@ -0,0 +1,27 @@
+package routers
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/raihaninkam/finalPhase3/internals/handlers"
+	middleware "github.com/raihaninkam/finalPhase3/internals/middlewares"
+	"github.com/raihaninkam/finalPhase3/internals/repositories"
+	"github.com/redis/go-redis/v9"
+)
+
+func InitBlockRouter(router *gin.Engine, db *pgxpool.Pool, rdb *redis.Client) {
+	blockRouter := router.Group("", middleware.VerifyToken(rdb))
+	blockRepository := repositories.NewBlockRepository(db, rdb)
+	timelineRepository := repositories.NewTimelineRepository(db, rdb)
+	blockHandler := handlers.NewBlockHandler(blockRepository, timelineRepository)
+
+	// block
+	blockRouter.GET("/blocks", blockHandler.GetBlocks)
+	blockRouter.POST("/block/:id", blockHandler.Block)
+	blockRouter.DELETE("/block/:id", blockHandler.Unblock)
+
+	// mute
+	blockRouter.GET("/mutes", blockHandler.GetMutes)
+	blockRouter.POST("/mute/:id", blockHandler.Mute)
+	blockRouter.DELETE("/mute/:id", blockHandler.Unmute)
+}
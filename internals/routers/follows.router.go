@@ -12,9 +12,13 @@ import (
 func InitFollowsRouter(router *gin.Engine, db *pgxpool.Pool, rdb *redis.Client) {
 	followRouter := router.Group("")
 	followRepository := repositories.NewFollowsRepository(db, rdb)
-	followHandler := handlers.NewFollowHandler(followRepository)
+	timelineRepository := repositories.NewTimelineRepository(db, rdb)
+	followHandler := handlers.NewFollowHandler(followRepository, timelineRepository)
 
 	followRouter.GET("/following", middleware.VerifyToken(rdb), followHandler.GetFollowing)
 	followRouter.POST("/follow/:id", middleware.VerifyToken(rdb), followHandler.Follow)
+	followRouter.DELETE("/follow/:id", middleware.VerifyToken(rdb), followHandler.Unfollow)
+	followRouter.GET("/users/:username/followers", middleware.VerifyToken(rdb), followHandler.GetUserFollowers)
+	followRouter.GET("/users/:username/following", middleware.VerifyToken(rdb), followHandler.GetUserFollowing)
 
 }
@@ -0,0 +1,10 @@
+package routers
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/raihaninkam/finalPhase3/internals/handlers"
+)
+
+func InitJWKSRouter(router *gin.Engine) {
+	router.GET("/.well-known/jwks.json", handlers.JWKS)
+}
@@ -0,0 +1,128 @@
+package routers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/raihaninkam/finalPhase3/internals/configs"
+	"github.com/raihaninkam/finalPhase3/internals/models"
+	"github.com/raihaninkam/finalPhase3/internals/repositories"
+	"github.com/redis/go-redis/v9"
+)
+
+// testSchema adalah schema postgres khusus test package ini, agar tidak bentrok dengan test package lain
+const testSchema = "routers_test"
+
+func TestMain(m *testing.M) {
+	gin.SetMode(gin.TestMode)
+	if os.Getenv("JWT_SECRET") == "" {
+		os.Setenv("JWT_SECRET", "test-jwt-secret")
+	}
+	os.Exit(m.Run())
+}
+
+// newTestStores menyiapkan database yang baru dimigrasi dan redis yang kosong untuk satu test.
+// Test di-skip jika TEST_DATABASE_URL atau TEST_REDIS_URL tidak diisi
+func newTestStores(t *testing.T) (*pgxpool.Pool, *redis.Client) {
+	t.Helper()
+	dbURL := os.Getenv("TEST_DATABASE_URL")
+	if dbURL == "" || os.Getenv("TEST_REDIS_URL") == "" {
+		t.Skip("TEST_DATABASE_URL and TEST_REDIS_URL are not set")
+	}
+	ctx := context.Background()
+
+	config, err := pgxpool.ParseConfig(dbURL)
+	if err != nil {
+		t.Fatalf("invalid TEST_DATABASE_URL: %v", err)
+	}
+	config.ConnConfig.RuntimeParams["search_path"] = testSchema
+	db, err := pgxpool.NewWithConfig(ctx, config)
+	if err != nil {
+		t.Fatalf("failed to connect to test database: %v", err)
+	}
+	t.Cleanup(db.Close)
+
+	if _, err := db.Exec(ctx, `DROP SCHEMA IF EXISTS `+testSchema+` CASCADE; CREATE SCHEMA `+testSchema); err != nil {
+		t.Fatalf("failed to reset test schema: %v", err)
+	}
+	if _, err := configs.MigrateUp(ctx, db); err != nil {
+		t.Fatalf("failed to migrate test database: %v", err)
+	}
+	return db, newTestRedis(t)
+}
+
+// newTestRedis menyiapkan redis yang kosong untuk satu test, di-skip jika TEST_REDIS_URL tidak diisi.
+// TEST_REDIS_URL harus menunjuk ke database redis khusus test karena isinya di-FLUSHDB
+func newTestRedis(t *testing.T) *redis.Client {
+	t.Helper()
+	redisURL := os.Getenv("TEST_REDIS_URL")
+	if redisURL == "" {
+		t.Skip("TEST_REDIS_URL is not set")
+	}
+	opt, err := redis.ParseURL(redisURL)
+	if err != nil {
+		t.Fatalf("invalid TEST_REDIS_URL: %v", err)
+	}
+	rdb := redis.NewClient(opt)
+	t.Cleanup(func() { rdb.Close() })
+	if err := rdb.FlushDB(context.Background()).Err(); err != nil {
+		t.Fatalf("failed to flush test redis: %v", err)
+	}
+	return rdb
+}
+
+// createTestUser menyimpan user dengan hash password yang sudah jadi (argon2id atau bcrypt data lama)
+func createTestUser(t *testing.T, db *pgxpool.Pool, rdb *redis.Client, username, passwordHash string) *models.User {
+	t.Helper()
+	user := &models.User{
+		Username: username,
+		Email:    fmt.Sprintf("%s@example.com", username),
+		Password: passwordHash,
+		Name:     &username,
+	}
+	if err := repositories.NewAuthRepository(db, rdb).CreateAccount(context.Background(), user); err != nil {
+		t.Fatalf("failed to create user %s: %v", username, err)
+	}
+	return user
+}
+
+// doJSON mengirim request dengan body JSON (boleh kosong) dan access token (boleh kosong)
+func doJSON(router http.Handler, method, path, token, body string) *httptest.ResponseRecorder {
+	var reader io.Reader
+	if body != "" {
+		reader = strings.NewReader(body)
+	}
+	req := httptest.NewRequest(method, path, reader)
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec
+}
+
+// login masuk lewat POST /auth/login dan mengembalikan access token
+func login(t *testing.T, router http.Handler, email, password string) string {
+	t.Helper()
+	rec := doJSON(router, http.MethodPost, "/auth/login", "", fmt.Sprintf(`{"email":%q,"password":%q}`, email, password))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("login %s = %d %s, want %d", email, rec.Code, rec.Body.String(), http.StatusOK)
+	}
+	var body struct {
+		Token string `json:"token"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	return body.Token
+}
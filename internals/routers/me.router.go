@@ -0,0 +1,28 @@
+package routers
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/raihaninkam/finalPhase3/internals/handlers"
+	middleware "github.com/raihaninkam/finalPhase3/internals/middlewares"
+	"github.com/raihaninkam/finalPhase3/internals/repositories"
+	"github.com/redis/go-redis/v9"
+)
+
+func InitMeRouter(router *gin.Engine, db *pgxpool.Pool, rdb *redis.Client) {
+	meRouter := router.Group("/me", middleware.VerifyToken(rdb))
+	exportRepository := repositories.NewExportRepository(db, rdb)
+	exportHandler := handlers.NewExportHandler(exportRepository)
+
+	meRouter.POST("/export", exportHandler.RequestExport)
+	meRouter.GET("/export/:id", exportHandler.GetExport)
+
+	// privacy & follow request
+	followRepository := repositories.NewFollowsRepository(db, rdb)
+	timelineRepository := repositories.NewTimelineRepository(db, rdb)
+	followHandler := handlers.NewFollowHandler(followRepository, timelineRepository)
+	meRouter.PATCH("/privacy", followHandler.UpdatePrivacy)
+	meRouter.GET("/follow-requests", followHandler.GetFollowRequests)
+	meRouter.POST("/follow-requests/:id/approve", followHandler.ApproveFollowRequest)
+	meRouter.DELETE("/follow-requests/:id", followHandler.RejectFollowRequest)
+}
@@ -0,0 +1,20 @@
+package routers
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/raihaninkam/finalPhase3/internals/handlers"
+	middleware "github.com/raihaninkam/finalPhase3/internals/middlewares"
+	"github.com/raihaninkam/finalPhase3/internals/repositories"
+	"github.com/redis/go-redis/v9"
+)
+
+func InitNotificationRouter(router *gin.Engine, db *pgxpool.Pool, rdb *redis.Client) {
+	notificationRouter := router.Group("/notifications", middleware.VerifyToken(rdb))
+	notificationRepository := repositories.NewNotificationRepository(db, rdb)
+	notificationHandler := handlers.NewNotificationHandler(notificationRepository)
+
+	notificationRouter.GET("", notificationHandler.GetNotifications)
+	notificationRouter.GET("/unread-count", notificationHandler.GetUnreadCount)
+	notificationRouter.PATCH("/read", notificationHandler.MarkAsRead)
+}
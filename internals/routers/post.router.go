@@ -12,11 +12,20 @@ import (
 func InitPostRouter(router *gin.Engine, db *pgxpool.Pool, rdb *redis.Client) {
 	postRouter := router.Group("")
 	postRepository := repositories.NewPostRepository(db, rdb)
-	postHandler := handlers.NewPostHandler(postRepository)
+	timelineRepository := repositories.NewTimelineRepository(db, rdb)
+	postHandler := handlers.NewPostHandler(postRepository, timelineRepository)
 
 	// posting
-	postRouter.POST("/post", middleware.VerifyToken(rdb), postHandler.CreatePost)
+	postRouter.POST("/post", middleware.VerifyToken(rdb), middleware.RequireVerifiedEmail(db), postHandler.CreatePost)
 	postRouter.GET("/post", middleware.VerifyToken(rdb), postHandler.GetFeed)
+	postRouter.GET("/post/:id", middleware.VerifyToken(rdb), postHandler.GetPostDetail)
+	postRouter.PATCH("/post/:id", middleware.VerifyToken(rdb), postHandler.UpdatePost)
+	postRouter.DELETE("/post/:id", middleware.VerifyToken(rdb), postHandler.DeletePost)
+	postRouter.GET("/post/:id/history", middleware.VerifyToken(rdb), postHandler.GetPostHistory)
+
+	// bookmark
+	postRouter.POST("/post/:id/bookmark", middleware.VerifyToken(rdb), postHandler.BookmarkPost)
+	postRouter.DELETE("/post/:id/bookmark", middleware.VerifyToken(rdb), postHandler.UnbookmarkPost)
 
 	// like
 	likeRepository := repositories.NewLikeRepository(db, rdb)
@@ -28,11 +37,11 @@ func InitPostRouter(router *gin.Engine, db *pgxpool.Pool, rdb *redis.Client) {
 	commentRepository := repositories.NewCommentRepository(db, rdb)
 	commentHandler := handlers.NewCommentHandler(commentRepository)
 	postRouter.GET("/post/:id/comment", middleware.VerifyToken(rdb), commentHandler.GetPostComments)
-	postRouter.POST("/post/:id/comment", middleware.VerifyToken(rdb), commentHandler.CreateComment)
+	postRouter.POST("/post/:id/comment", middleware.VerifyToken(rdb), middleware.RequireVerifiedEmail(db), commentHandler.CreateComment)
 	postRouter.DELETE("/comment/:id", middleware.VerifyToken(rdb), commentHandler.DeleteComment)
 
 	// popular
-	popularHandler := handlers.NewPostHandler(postRepository)
+	popularHandler := handlers.NewPostHandler(postRepository, timelineRepository)
 	postRouter.GET("/post/popular", middleware.VerifyToken(rdb), popularHandler.GetPopularPosts)
 
 }
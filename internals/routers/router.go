@@ -1,27 +1,50 @@
 package routers
 
 import (
+	"log"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
 	"github.com/jackc/pgx/v5/pgxpool"
 	docs "github.com/raihaninkam/finalPhase3/docs"
+	"github.com/raihaninkam/finalPhase3/internals/configs"
+	"github.com/raihaninkam/finalPhase3/pkg"
 	"github.com/redis/go-redis/v9"
 	swaggerfiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
 )
 
-func InitRouter(db *pgxpool.Pool, rdb *redis.Client) *gin.Engine {
+func InitRouter(db *pgxpool.Pool, rdb *redis.Client, mailer pkg.Mailer) *gin.Engine {
 	router := gin.Default()
 
+	// ClientIP dipakai untuk batas gagal login per IP, jadi X-Forwarded-For hanya dibaca dari proxy yang dikonfigurasi
+	if err := router.SetTrustedProxies(configs.TrustedProxies()); err != nil {
+		log.Println("Invalid TRUSTED_PROXIES, no proxy is trusted.\nCause: ", err.Error())
+		router.SetTrustedProxies(nil)
+	}
+
 	// router.Use(middlewares.CORSMiddleware)
 
-	InitAuthRouter(router, db, rdb)
+	InitAuthRouter(router, db, rdb, mailer)
 
 	InitPostRouter(router, db, rdb)
 
 	InitFollowsRouter(router, db, rdb)
 
+	InitBlockRouter(router, db, rdb)
+
+	InitSuggestionRouter(router, db, rdb)
+
+	InitNotificationRouter(router, db, rdb)
+
+	InitUserRouter(router, db, rdb)
+
+	InitMeRouter(router, db, rdb)
+
+	InitAdminRouter(router, db, rdb)
+
+	InitJWKSRouter(router)
+
 	docs.SwaggerInfo.BasePath = "/"
 	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerfiles.Handler))
 
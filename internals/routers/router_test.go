@@ -0,0 +1,52 @@
+package routers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/raihaninkam/finalPhase3/internals/repositories"
+)
+
+// loginFrom mengirim POST /auth/login dari remoteAddr dengan header X-Forwarded-For
+func loginFrom(router http.Handler, remoteAddr, forwardedFor string) int {
+	req := httptest.NewRequest(http.MethodPost, "/auth/login", strings.NewReader(`{"email":"budi@example.com","password":"Secret123!"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Forwarded-For", forwardedFor)
+	req.RemoteAddr = remoteAddr
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec.Code
+}
+
+func TestLoginIPLockIgnoresSpoofedForwardedFor(t *testing.T) {
+	rdb := newTestRedis(t)
+	ctx := context.Background()
+
+	// IP 192.0.2.1 sudah mencapai batas gagal login
+	la := repositories.NewLoginAttemptRepository(rdb)
+	for i := range 20 {
+		if _, err := la.RegisterFailure(ctx, fmt.Sprintf("user%d@example.com", i), "192.0.2.1"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// tanpa proxy yang dipercaya, X-Forwarded-For palsu tidak menghasilkan IP (dan counter) baru
+	t.Setenv("TRUSTED_PROXIES", "")
+	router := InitRouter(nil, rdb, nil)
+	for _, spoofed := range []string{"203.0.113.1", "203.0.113.2"} {
+		if code := loginFrom(router, "192.0.2.1:40000", spoofed); code != http.StatusTooManyRequests {
+			t.Errorf("login with X-Forwarded-For %s = %d, want %d", spoofed, code, http.StatusTooManyRequests)
+		}
+	}
+
+	// di belakang proxy yang dipercaya, IP client diambil dari X-Forwarded-For
+	t.Setenv("TRUSTED_PROXIES", "10.0.0.0/8")
+	router = InitRouter(nil, rdb, nil)
+	if code := loginFrom(router, "10.0.0.1:40000", "192.0.2.1"); code != http.StatusTooManyRequests {
+		t.Errorf("login through trusted proxy = %d, want %d", code, http.StatusTooManyRequests)
+	}
+}
@@ -0,0 +1,18 @@
+package routers
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/raihaninkam/finalPhase3/internals/handlers"
+	middleware "github.com/raihaninkam/finalPhase3/internals/middlewares"
+	"github.com/raihaninkam/finalPhase3/internals/repositories"
+	"github.com/redis/go-redis/v9"
+)
+
+func InitSuggestionRouter(router *gin.Engine, db *pgxpool.Pool, rdb *redis.Client) {
+	suggestionRouter := router.Group("/suggestions", middleware.VerifyToken(rdb))
+	suggestionRepository := repositories.NewSuggestionRepository(db, rdb)
+	suggestionHandler := handlers.NewSuggestionHandler(suggestionRepository)
+
+	suggestionRouter.GET("/users", suggestionHandler.GetUserSuggestions)
+}
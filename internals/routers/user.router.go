@@ -0,0 +1,18 @@
+package routers
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/raihaninkam/finalPhase3/internals/handlers"
+	middleware "github.com/raihaninkam/finalPhase3/internals/middlewares"
+	"github.com/raihaninkam/finalPhase3/internals/repositories"
+	"github.com/redis/go-redis/v9"
+)
+
+func InitUserRouter(router *gin.Engine, db *pgxpool.Pool, rdb *redis.Client) {
+	userRouter := router.Group("/users", middleware.VerifyToken(rdb))
+	userRepository := repositories.NewUserRepository(db, rdb)
+	userHandler := handlers.NewUserHandler(userRepository)
+
+	userRouter.GET("/:username", userHandler.GetProfile)
+}
@@ -0,0 +1,15 @@
+package utils
+
+import (
+	"os"
+	"strings"
+)
+
+// AppURL menyusun link ke aplikasi (frontend) dari env APP_URL, dipakai untuk link di email
+func AppURL(path string) string {
+	baseURL := os.Getenv("APP_URL")
+	if baseURL == "" {
+		baseURL = "http://localhost:3009"
+	}
+	return strings.TrimRight(baseURL, "/") + path
+}
@@ -8,15 +8,33 @@ import (
 )
 
 func GetUserFromCtx(ctx *gin.Context) (string, error) {
+	userClaims, err := GetClaimsFromCtx(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	return userClaims.UserId, nil
+}
+
+func GetClaimsFromCtx(ctx *gin.Context) (*pkg.Claims, error) {
 	claims, ok := ctx.Get("claims")
 	if !ok {
-		return "", errors.New("claims not found in context, token might be missing")
+		return nil, errors.New("claims not found in context, token might be missing")
 	}
 
 	userClaims, ok := claims.(*pkg.Claims)
 	if !ok {
-		return "", errors.New("invalid claims format")
+		return nil, errors.New("invalid claims format")
 	}
 
-	return userClaims.UserId, nil
+	return userClaims, nil
+}
+
+// GetTokenFromCtx mengambil raw token (tanpa "Bearer ") yang disimpan oleh middleware VerifyToken
+func GetTokenFromCtx(ctx *gin.Context) (string, error) {
+	token := ctx.GetString("token")
+	if token == "" {
+		return "", errors.New("token not found in context")
+	}
+	return token, nil
 }
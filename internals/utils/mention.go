@@ -0,0 +1,29 @@
+package utils
+
+import (
+	"regexp"
+	"strings"
+)
+
+// maxMentions membatasi jumlah user yang bisa di-mention dalam satu post / komentar
+const maxMentions = 10
+
+var mentionRegex = regexp.MustCompile(`(?:^|[^a-z0-9_@])@([a-z0-9_]{3,30})\b`)
+
+// ExtractMentions mengambil username unik yang di-mention (@username) di dalam teks
+func ExtractMentions(text string) []string {
+	seen := map[string]bool{}
+	var usernames []string
+	for _, match := range mentionRegex.FindAllStringSubmatch(strings.ToLower(text), -1) {
+		username := match[1]
+		if seen[username] {
+			continue
+		}
+		seen[username] = true
+		usernames = append(usernames, username)
+		if len(usernames) == maxMentions {
+			break
+		}
+	}
+	return usernames
+}
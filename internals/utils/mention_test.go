@@ -0,0 +1,39 @@
+package utils
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestExtractMentions(t *testing.T) {
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{"hello @budi", []string{"budi"}},
+		{"@Budi and @ani_2, @budi again", []string{"budi", "ani_2"}},
+		{"(@cici) @dodi.", []string{"cici", "dodi"}},
+		{"mail budi@example.com", nil},
+		{"@@budi", nil},
+		{"@ab is too short", nil},
+		{"@" + strings.Repeat("a", 31) + " is too long", nil},
+		{"no mentions here", nil},
+	}
+	for _, tt := range tests {
+		if got := ExtractMentions(tt.text); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ExtractMentions(%q) = %v, want %v", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestExtractMentionsLimit(t *testing.T) {
+	var text []string
+	for i := range maxMentions + 5 {
+		text = append(text, fmt.Sprintf("@user%d", i))
+	}
+	if got := ExtractMentions(strings.Join(text, " ")); len(got) != maxMentions {
+		t.Errorf("ExtractMentions() returned %d usernames, want %d", len(got), maxMentions)
+	}
+}
@@ -0,0 +1,57 @@
+package utils
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/raihaninkam/finalPhase3/internals/models"
+	"github.com/raihaninkam/finalPhase3/pkg"
+)
+
+const (
+	DefaultPageLimit = 20
+	MaxPageLimit     = 100
+)
+
+// Jenis cursor per listing, cursor dari satu listing ditolak di listing lain
+const (
+	CursorFeed           = "feed"
+	CursorComments       = "comments"
+	CursorFollowing      = "following"
+	CursorFollowers      = "followers"
+	CursorFollowRequests = "follow-requests"
+	CursorBlocks         = "blocks"
+	CursorMutes          = "mutes"
+	CursorNotifications  = "notifications"
+	CursorPopular        = "popular"
+)
+
+// GetPaginationParams membaca query ?limit= dan ?cursor=. Limit di luar 1..MaxPageLimit
+// memakai default / dibatasi, cursor yang tidak valid mengembalikan pkg.ErrInvalidCursor
+func GetPaginationParams(ctx *gin.Context, kind string) (int, *pkg.Cursor, error) {
+	limit := DefaultPageLimit
+	if l, err := strconv.Atoi(ctx.Query("limit")); err == nil && l > 0 {
+		limit = min(l, MaxPageLimit)
+	}
+
+	raw := ctx.Query("cursor")
+	if raw == "" {
+		return limit, nil, nil
+	}
+	cursor, err := pkg.DecodeCursor(raw, kind)
+	if err != nil {
+		return limit, nil, err
+	}
+	return limit, cursor, nil
+}
+
+// NewPagination menyusun envelope pagination dari cursor halaman berikutnya (nil jika habis)
+func NewPagination(limit int, next *pkg.Cursor) models.Pagination {
+	pagination := models.Pagination{Limit: limit}
+	if next != nil {
+		encoded := next.Encode()
+		pagination.NextCursor = &encoded
+		pagination.HasMore = true
+	}
+	return pagination
+}
@@ -0,0 +1,18 @@
+package utils
+
+import (
+	"os"
+	"strings"
+)
+
+const defaultRedisKeyPrefix = "social-media"
+
+// RedisKey menyusun key redis dengan namespace dari env RDB_KEY_PREFIX,
+// contoh: RedisKey("blacklist", token) -> "social-media:blacklist:<token>"
+func RedisKey(parts ...string) string {
+	prefix := os.Getenv("RDB_KEY_PREFIX")
+	if prefix == "" {
+		prefix = defaultRedisKeyPrefix
+	}
+	return prefix + ":" + strings.Join(parts, ":")
+}
@@ -3,9 +3,12 @@ package utils
 import (
 	"errors"
 	"fmt"
+	"log"
 	"mime/multipart"
+	"os"
 	"path/filepath"
 	"regexp"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -32,3 +35,15 @@ func FileUpload(c *gin.Context, file *multipart.FileHeader, prefix string) (stri
 
 	return filename, nil
 }
+
+// RemoveUploadedFile menghapus file hasil FileUpload berdasarkan url-nya ("/public/<file>").
+// Url lain (misalnya gambar eksternal) diabaikan
+func RemoveUploadedFile(url string) {
+	if !strings.HasPrefix(url, "/public/") {
+		return
+	}
+	location := filepath.Join("public", filepath.Base(url))
+	if err := os.Remove(location); err != nil && !os.IsNotExist(err) {
+		log.Println("Failed to remove uploaded file.\nCause: ", err.Error())
+	}
+}
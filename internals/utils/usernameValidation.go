@@ -0,0 +1,35 @@
+package utils
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+)
+
+var usernameRegex = regexp.MustCompile(`^[a-z0-9_]{3,30}$`)
+
+// reservedUsernames tidak boleh dipakai user karena bentrok dengan route / bisa menyesatkan user lain
+var reservedUsernames = map[string]bool{
+	"admin": true, "administrator": true, "moderator": true, "root": true, "system": true,
+	"support": true, "help": true, "security": true, "official": true, "staff": true,
+	"api": true, "auth": true, "login": true, "logout": true, "register": true,
+	"me": true, "settings": true, "users": true, "user": true, "post": true, "posts": true,
+	"follow": true, "following": true, "followers": true, "swagger": true, "public": true,
+	"null": true, "undefined": true,
+}
+
+// NormalizeUsername menyamakan format username (huruf kecil, tanpa spasi di awal/akhir)
+func NormalizeUsername(username string) string {
+	return strings.ToLower(strings.TrimSpace(username))
+}
+
+// UsernameValidation memastikan username sudah dinormalisasi, sesuai format dan bukan nama yang dicadangkan
+func UsernameValidation(username string) error {
+	if !usernameRegex.MatchString(username) {
+		return errors.New("username must be 3-30 characters of lowercase letters, digits or underscore")
+	}
+	if reservedUsernames[username] {
+		return errors.New("username is reserved")
+	}
+	return nil
+}
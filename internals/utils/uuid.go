@@ -0,0 +1,10 @@
+package utils
+
+import "regexp"
+
+var uuidRegex = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// IsUUID mengecek apakah string merupakan UUID yang valid (format id di database)
+func IsUUID(id string) bool {
+	return uuidRegex.MatchString(id)
+}
@@ -2,7 +2,6 @@ package utils
 
 import (
 	"errors"
-	"log"
 	"regexp"
 
 	"github.com/raihaninkam/finalPhase3/internals/models"
@@ -16,15 +15,18 @@ func RegisterValidation(body models.AuthRequest) error {
 		return errors.New("email format is wrong")
 	}
 
+	return PasswordValidation(body.Password)
+}
+
+// PasswordValidation memastikan password memenuhi aturan yang sama dengan saat register
+func PasswordValidation(password string) error {
 	// cek format password
 	// harus : huruf, angka, simbol, 8 karakter
-	log.Println(body.Password)
-	islengEight := len(body.Password) >= 8
-	isNotHvSymbl := regexp.MustCompile(`[!@#$%^&*/><]`).MatchString(body.Password)
-	isNotHvChar := regexp.MustCompile(`[a-zA-Z]`).MatchString(body.Password)
-	isNotHvDigit := regexp.MustCompile(`\d`).MatchString(body.Password)
+	islengEight := len(password) >= 8
+	isNotHvSymbl := regexp.MustCompile(`[!@#$%^&*/><]`).MatchString(password)
+	isNotHvChar := regexp.MustCompile(`[a-zA-Z]`).MatchString(password)
+	isNotHvDigit := regexp.MustCompile(`\d`).MatchString(password)
 
-	log.Println(isNotHvSymbl, isNotHvChar, isNotHvDigit, islengEight)
 	if !isNotHvChar || !isNotHvSymbl || !isNotHvDigit || !islengEight {
 		return errors.New("password must contain : character, digit, symbol, minimum 8 characters")
 	}
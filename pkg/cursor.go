@@ -0,0 +1,98 @@
+package pkg
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrInvalidCursor dikembalikan jika cursor rusak, tanda tangannya tidak cocok atau bukan milik endpoint tsb
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Cursor adalah posisi keyset (created_at + id) untuk pagination. Score dipakai oleh
+// listing yang diurutkan berdasarkan skor (misalnya post populer), beserta AsOf yaitu waktu
+// skor dihitung agar halaman berikutnya memakai skor dari waktu yang sama.
+// Kind mengikat cursor ke satu jenis listing agar tidak bisa dipakai di endpoint lain
+type Cursor struct {
+	Kind      string    `json:"k"`
+	Score     float64   `json:"s,omitempty"`
+	AsOf      time.Time `json:"a,omitzero"`
+	CreatedAt time.Time `json:"t"`
+	ID        string    `json:"id"`
+}
+
+func NewCursor(kind string, createdAt time.Time, id string) *Cursor {
+	return &Cursor{Kind: kind, CreatedAt: createdAt, ID: id}
+}
+
+var (
+	cursorOnce   sync.Once
+	cursorSecret []byte
+	cursorErr    error
+)
+
+// LoadCursorSecret memuat secret tanda tangan cursor: CURSOR_SECRET, atau JWT_SECRET jika kosong.
+// Dipanggil saat startup, deployment tanpa keduanya (misalnya hanya memakai JWT_KEYS_DIR) ditolak
+// karena cursor dengan secret kosong bisa dipalsukan
+func LoadCursorSecret() error {
+	cursorOnce.Do(func() {
+		secret := os.Getenv("CURSOR_SECRET")
+		if secret == "" {
+			secret = os.Getenv("JWT_SECRET")
+		}
+		if secret == "" {
+			cursorErr = errors.New("no cursor secret found, set CURSOR_SECRET")
+			return
+		}
+		cursorSecret = []byte(secret)
+	})
+	return cursorErr
+}
+
+func signCursor(payload string) (string, error) {
+	if err := LoadCursorSecret(); err != nil {
+		return "", err
+	}
+	mac := hmac.New(sha256.New, cursorSecret)
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil)), nil
+}
+
+// Encode menghasilkan cursor opaque "<payload>.<signature>" (base64 url-safe).
+// Panic jika secret cursor tidak ada, LoadCursorSecret sudah dicek saat startup
+func (c *Cursor) Encode() string {
+	b, _ := json.Marshal(c)
+	payload := base64.RawURLEncoding.EncodeToString(b)
+	signature, err := signCursor(payload)
+	if err != nil {
+		panic(err)
+	}
+	return payload + "." + signature
+}
+
+// DecodeCursor memverifikasi tanda tangan cursor dan memastikan kind-nya sesuai
+func DecodeCursor(cursor, kind string) (*Cursor, error) {
+	payload, signature, ok := strings.Cut(cursor, ".")
+	if !ok {
+		return nil, ErrInvalidCursor
+	}
+	expected, err := signCursor(payload)
+	if err != nil || !hmac.Equal([]byte(signature), []byte(expected)) {
+		return nil, ErrInvalidCursor
+	}
+	b, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var c Cursor
+	if err := json.Unmarshal(b, &c); err != nil || c.Kind != kind || c.ID == "" {
+		return nil, ErrInvalidCursor
+	}
+	return &c, nil
+}
@@ -0,0 +1,98 @@
+package pkg
+
+import (
+	"encoding/base64"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// useCursorSecret mengganti secret cursor selama satu test, LoadCursorSecret hanya membaca env sekali
+func useCursorSecret(t *testing.T, secret string) {
+	t.Helper()
+	t.Setenv("CURSOR_SECRET", secret)
+	t.Setenv("JWT_SECRET", "")
+	cursorOnce, cursorSecret, cursorErr = sync.Once{}, nil, nil
+	t.Cleanup(func() { cursorOnce, cursorSecret, cursorErr = sync.Once{}, nil, nil })
+}
+
+func TestCursorRoundTrip(t *testing.T) {
+	useCursorSecret(t, "test-secret")
+
+	at := time.Date(2025, 1, 2, 3, 4, 5, 123456000, time.UTC)
+	encoded := NewCursor("feed", at, "post-1").Encode()
+
+	cursor, err := DecodeCursor(encoded, "feed")
+	if err != nil {
+		t.Fatalf("DecodeCursor() error: %v", err)
+	}
+	if !cursor.CreatedAt.Equal(at) || cursor.ID != "post-1" || cursor.Kind != "feed" {
+		t.Errorf("DecodeCursor() = %+v", cursor)
+	}
+
+	// cursor tidak boleh dipakai di listing lain
+	if _, err := DecodeCursor(encoded, "comments"); err != ErrInvalidCursor {
+		t.Errorf("DecodeCursor() with other kind = %v, want ErrInvalidCursor", err)
+	}
+
+	// cursor listing berdasarkan skor membawa skor dan waktu skor dihitung
+	popular := NewCursor("popular", at, "post-1")
+	popular.Score, popular.AsOf = 2.5, at.Add(time.Minute)
+	cursor, err = DecodeCursor(popular.Encode(), "popular")
+	if err != nil {
+		t.Fatalf("DecodeCursor() error: %v", err)
+	}
+	if cursor.Score != 2.5 || !cursor.AsOf.Equal(popular.AsOf) {
+		t.Errorf("DecodeCursor() = %+v, want score 2.5 and as_of %v", cursor, popular.AsOf)
+	}
+	payload, _, _ := strings.Cut(encoded, ".")
+	if raw, _ := base64.RawURLEncoding.DecodeString(payload); strings.Contains(string(raw), `"a"`) {
+		t.Errorf("feed cursor payload = %s, want no as_of", raw)
+	}
+}
+
+func TestCursorTampered(t *testing.T) {
+	useCursorSecret(t, "test-secret")
+
+	encoded := NewCursor("feed", time.Now(), "post-1").Encode()
+	payload, signature, _ := strings.Cut(encoded, ".")
+	forged := NewCursor("feed", time.Now(), "post-2").Encode()
+	forgedPayload, _, _ := strings.Cut(forged, ".")
+
+	for _, cursor := range []string{
+		"",
+		"not-a-cursor",
+		payload,
+		forgedPayload + "." + signature,
+		payload + "." + signature + "x",
+	} {
+		if _, err := DecodeCursor(cursor, "feed"); err != ErrInvalidCursor {
+			t.Errorf("DecodeCursor(%q) = %v, want ErrInvalidCursor", cursor, err)
+		}
+	}
+
+	// cursor yang ditandatangani dengan secret lain ditolak
+	useCursorSecret(t, "other-secret")
+	if _, err := DecodeCursor(encoded, "feed"); err != ErrInvalidCursor {
+		t.Errorf("DecodeCursor() with rotated secret = %v, want ErrInvalidCursor", err)
+	}
+}
+
+func TestCursorWithoutSecret(t *testing.T) {
+	useCursorSecret(t, "")
+
+	if err := LoadCursorSecret(); err == nil {
+		t.Fatal("LoadCursorSecret() without CURSOR_SECRET and JWT_SECRET should fail")
+	}
+	if _, err := DecodeCursor("payload.signature", "feed"); err != ErrInvalidCursor {
+		t.Errorf("DecodeCursor() without secret = %v, want ErrInvalidCursor", err)
+	}
+
+	// fallback ke JWT_SECRET
+	useCursorSecret(t, "")
+	t.Setenv("JWT_SECRET", "jwt-secret")
+	if err := LoadCursorSecret(); err != nil {
+		t.Errorf("LoadCursorSecret() with JWT_SECRET: %v", err)
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"strings"
 
 	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
 )
 
 type HashConfig struct {
@@ -63,49 +64,110 @@ func (h *HashConfig) genSalt() ([]byte, error) {
 }
 
 func (h *HashConfig) CompareHashAndPassword(password, hashedPassword string) (bool, error) {
+	// hash bcrypt dari data lama (import / seed), diverifikasi agar user tetap bisa login lalu di-migrasi ke argon2id
+	if isBcryptHash(hashedPassword) {
+		err := bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(password))
+		if err == bcrypt.ErrMismatchedHashAndPassword {
+			return false, nil
+		}
+		if err != nil {
+			return false, err
+		}
+		return true, nil
+	}
+
+	salt, hash, err := h.parseHash(hashedPassword)
+	if err != nil {
+		return false, err
+	}
+
+	// Comparison
+	// Generate Hash dari password
+	hashPwd := argon2.IDKey([]byte(password), salt, h.Time, h.Memory, h.Thread, h.KeyLen)
+	// komparasi hasil hash dengan waktu tidak konstan
+	// if slices.Compare(hash, hashPwd) != 0 {
+	// 	return false, nil
+	// }
+	// komparasi hasil hash dengan waktu konstan (lebih aman dari timing attack di hash)
+	if subtle.ConstantTimeCompare(hash, hashPwd) == 0 {
+		return false, nil
+	}
+	return true, nil
+}
+
+// dummyHash adalah hash argon2id dengan parameter rekomendasi, dipakai saat email tidak terdaftar
+const dummyHash = "$argon2id$v=19$m=65536,t=2,p=1$zLeLVOOsnSMfkgzIDgfP5g$BPPDz413gCzWzyf4mkxa/IxJ5M/vQrZ9e/BTQr/KEiI"
+
+// CompareDummyHash menjalankan komparasi argon2 yang hasilnya dibuang, agar waktu respon login untuk
+// email yang tidak terdaftar sama dengan email terdaftar (tidak membocorkan email mana yang terdaftar)
+func CompareDummyHash(password string) {
+	_, _ = NewHashConfig().CompareHashAndPassword(password, dummyHash)
+}
+
+// NeedsRehash mengecek apakah hash tersimpan memakai parameter yang lebih lemah dari konfigurasi h
+// (atau masih bcrypt), sehingga perlu di-hash ulang saat user berhasil login
+func (h *HashConfig) NeedsRehash(hashedPassword string) bool {
+	if isBcryptHash(hashedPassword) {
+		return true
+	}
+
+	stored := NewHashConfig()
+	if _, _, err := stored.parseHash(hashedPassword); err != nil {
+		return true
+	}
+	return stored.Memory < h.Memory ||
+		stored.Time < h.Time ||
+		stored.Thread < h.Thread ||
+		stored.KeyLen < h.KeyLen ||
+		stored.SaltLen < h.SaltLen
+}
+
+// parseHash mengisi konfigurasi h dari hash argon2id dan mengembalikan salt serta hash-nya
+func (h *HashConfig) parseHash(hashedPassword string) ([]byte, []byte, error) {
 	result := strings.Split(hashedPassword, "$")
-	fmt.Println(len(result), result)
 	// cek panjang hasil split, kalau bukan 6 maka format hash invalid
 	if len(result) != 6 {
-		return false, errors.New("invalid hash format")
+		return nil, nil, errors.New("invalid hash format")
 	}
 	// cek kriptografi yang digunakan
 	if result[1] != "argon2id" {
-		return false, errors.New("invalid crypto method")
+		return nil, nil, errors.New("invalid crypto method")
 	}
 	// cek versi nya
 	var version int
 	fmt.Sscanf(result[2], "v=%d", &version)
 	if version != argon2.Version {
-		return false, errors.New("invalid argon2id version")
+		return nil, nil, errors.New("invalid argon2id version")
 	}
 	// ambil konfigurasi memory, time dan thread
 	if _, err := fmt.Sscanf(result[3], "m=%d,t=%d,p=%d", &h.Memory, &h.Time, &h.Thread); err != nil {
-		return false, errors.New("invalid format")
+		return nil, nil, errors.New("invalid format")
+	}
+	// argon2 panic jika time atau thread 0
+	if h.Time < 1 || h.Thread < 1 {
+		return nil, nil, errors.New("invalid argon2id parameters")
 	}
 	// ambil nilai salt
 	salt, err := base64.RawStdEncoding.DecodeString(result[4])
 	if err != nil {
-		return false, err
+		return nil, nil, err
 	}
 	h.SaltLen = uint32(len(salt))
 	// ambil nilai hash
 	hash, err := base64.RawStdEncoding.DecodeString(result[5])
 	if err != nil {
-		return false, err
+		return nil, nil, err
+	}
+	// hash kosong akan cocok dengan password apa pun
+	if len(salt) == 0 || len(hash) == 0 {
+		return nil, nil, errors.New("invalid hash format")
 	}
 	h.KeyLen = uint32(len(hash))
+	return salt, hash, nil
+}
 
-	// Comparison
-	// Generate Hash dari password
-	hashPwd := argon2.IDKey([]byte(password), salt, h.Time, h.Memory, h.Thread, h.KeyLen)
-	// komparasi hasil hash dengan waktu tidak konstan
-	// if slices.Compare(hash, hashPwd) != 0 {
-	// 	return false, nil
-	// }
-	// komparasi hasil hash dengan waktu konstan (lebih aman dari timing attack di hash)
-	if subtle.ConstantTimeCompare(hash, hashPwd) == 0 {
-		return false, nil
-	}
-	return true, nil
+func isBcryptHash(hashedPassword string) bool {
+	return strings.HasPrefix(hashedPassword, "$2a$") ||
+		strings.HasPrefix(hashedPassword, "$2b$") ||
+		strings.HasPrefix(hashedPassword, "$2y$")
 }
@@ -0,0 +1,121 @@
+package pkg
+
+import (
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+const hashTestPassword = "Secret123!"
+
+func recommendedHashConfig() *HashConfig {
+	hc := NewHashConfig()
+	hc.UseRecommended()
+	return hc
+}
+
+func TestCompareBcryptHash(t *testing.T) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(hashTestPassword), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for password, want := range map[string]bool{hashTestPassword: true, "wrong-password": false} {
+		ok, err := NewHashConfig().CompareHashAndPassword(password, string(hash))
+		if err != nil || ok != want {
+			t.Errorf("CompareHashAndPassword(%q) = %t, %v, want %t", password, ok, err, want)
+		}
+	}
+	if !recommendedHashConfig().NeedsRehash(string(hash)) {
+		t.Error("NeedsRehash(bcrypt) = false, want true")
+	}
+}
+
+func TestCompareArgon2idHash(t *testing.T) {
+	hash, err := recommendedHashConfig().GenHash(hashTestPassword)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(hash, "$argon2id$v=19$m=65536,t=2,p=1$") {
+		t.Errorf("GenHash() = %s, want argon2id with the recommended parameters", hash)
+	}
+
+	for password, want := range map[string]bool{hashTestPassword: true, "wrong-password": false} {
+		ok, err := NewHashConfig().CompareHashAndPassword(password, hash)
+		if err != nil || ok != want {
+			t.Errorf("CompareHashAndPassword(%q) = %t, %v, want %t", password, ok, err, want)
+		}
+	}
+	if recommendedHashConfig().NeedsRehash(hash) {
+		t.Error("NeedsRehash(current parameters) = true, want false")
+	}
+}
+
+func TestNeedsRehashWeakerArgon2id(t *testing.T) {
+	tests := []struct {
+		name                      string
+		memory, time, key, saltLn uint32
+		thread                    uint8
+	}{
+		{"memory", 32 * 1024, 2, 32, 16, 1},
+		{"time", 64 * 1024, 1, 32, 16, 1},
+		{"key length", 64 * 1024, 2, 16, 16, 1},
+		{"salt length", 64 * 1024, 2, 32, 8, 1},
+	}
+	for _, tt := range tests {
+		weak := NewHashConfig()
+		weak.SetConfig(tt.memory, tt.time, tt.key, tt.saltLn, tt.thread)
+		hash, err := weak.GenHash(hashTestPassword)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// hash lama tetap bisa diverifikasi dengan parameter yang tersimpan di hash
+		if ok, err := NewHashConfig().CompareHashAndPassword(hashTestPassword, hash); err != nil || !ok {
+			t.Errorf("%s: CompareHashAndPassword() = %t, %v, want true", tt.name, ok, err)
+		}
+		if !recommendedHashConfig().NeedsRehash(hash) {
+			t.Errorf("%s: NeedsRehash(weaker parameters) = false, want true", tt.name)
+		}
+	}
+}
+
+func TestCompareMalformedHash(t *testing.T) {
+	hashes := map[string]string{
+		"empty":          "",
+		"plain text":     hashTestPassword,
+		"missing part":   "$argon2id$v=19$m=65536,t=2,p=1$zLeLVOOsnSMfkgzIDgfP5g",
+		"other method":   "$argon2i$v=19$m=65536,t=2,p=1$zLeLVOOsnSMfkgzIDgfP5g$BPPDz413gCzWzyf4mkxa/IxJ5M/vQrZ9e/BTQr/KEiI",
+		"other version":  "$argon2id$v=16$m=65536,t=2,p=1$zLeLVOOsnSMfkgzIDgfP5g$BPPDz413gCzWzyf4mkxa/IxJ5M/vQrZ9e/BTQr/KEiI",
+		"bad params":     "$argon2id$v=19$m=x,t=2,p=1$zLeLVOOsnSMfkgzIDgfP5g$BPPDz413gCzWzyf4mkxa/IxJ5M/vQrZ9e/BTQr/KEiI",
+		"zero time":      "$argon2id$v=19$m=65536,t=0,p=1$zLeLVOOsnSMfkgzIDgfP5g$BPPDz413gCzWzyf4mkxa/IxJ5M/vQrZ9e/BTQr/KEiI",
+		"zero thread":    "$argon2id$v=19$m=65536,t=2,p=0$zLeLVOOsnSMfkgzIDgfP5g$BPPDz413gCzWzyf4mkxa/IxJ5M/vQrZ9e/BTQr/KEiI",
+		"bad salt":       "$argon2id$v=19$m=65536,t=2,p=1$!!!$BPPDz413gCzWzyf4mkxa/IxJ5M/vQrZ9e/BTQr/KEiI",
+		"bad hash":       "$argon2id$v=19$m=65536,t=2,p=1$zLeLVOOsnSMfkgzIDgfP5g$!!!",
+		"empty salt":     "$argon2id$v=19$m=65536,t=2,p=1$$BPPDz413gCzWzyf4mkxa/IxJ5M/vQrZ9e/BTQr/KEiI",
+		"empty hash":     "$argon2id$v=19$m=65536,t=2,p=1$zLeLVOOsnSMfkgzIDgfP5g$",
+		"bcrypt garbage": "$2a$10$tooshort",
+	}
+	for name, hash := range hashes {
+		ok, err := NewHashConfig().CompareHashAndPassword(hashTestPassword, hash)
+		if err == nil || ok {
+			t.Errorf("%s: CompareHashAndPassword() = %t, %v, want an error", name, ok, err)
+		}
+		// hash yang rusak diganti saat login berikutnya
+		if !recommendedHashConfig().NeedsRehash(hash) {
+			t.Errorf("%s: NeedsRehash() = false, want true", name)
+		}
+	}
+}
+
+func TestCompareDummyHash(t *testing.T) {
+	// dummyHash harus bisa di-parse, kalau tidak CompareDummyHash selesai jauh lebih cepat dari login biasa
+	ok, err := NewHashConfig().CompareHashAndPassword(hashTestPassword, dummyHash)
+	if err != nil || ok {
+		t.Errorf("CompareHashAndPassword(dummyHash) = %t, %v, want false without error", ok, err)
+	}
+	if recommendedHashConfig().NeedsRehash(dummyHash) {
+		t.Error("dummyHash does not use the recommended parameters")
+	}
+	CompareDummyHash(hashTestPassword)
+}
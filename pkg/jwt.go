@@ -8,38 +8,113 @@ import (
 	"github.com/golang-jwt/jwt/v5"
 )
 
+// AccessTokenTTL adalah masa berlaku access token JWT
+const AccessTokenTTL = time.Minute * 30
+
+// AccessTokenAudience adalah audience wajib access token. Action token ditandatangani dengan key yang sama
+// tetapi memakai audience purpose-nya sendiri, sehingga tidak bisa dipakai sebagai access token (dan sebaliknya).
+// Service lain yang memverifikasi token lewat JWKS juga harus mengecek aud ini
+const AccessTokenAudience = "access"
+
 type Claims struct {
 	UserId string `json:"user_id"`
 	Role   string `json:"role"`
+	// IssuedAtMs adalah waktu token dibuat dalam milidetik. iat hanya berpresisi detik sehingga
+	// tidak bisa membedakan token yang dibuat di detik yang sama sebelum dan sesudah logout semua device.
+	// Token tanpa claim ini (dibuat sebelum claim ditambahkan) bernilai 0 dan dianggap sudah dicabut
+	IssuedAtMs int64 `json:"iat_ms,omitempty"`
 	jwt.RegisteredClaims
 }
 
-func NewJWTClaims(userid string, role string) *Claims {
+// NewJWTClaims membuat claims access token. sessionID disimpan sebagai jti
+// sehingga token bisa dicabut per device
+func NewJWTClaims(userid string, role string, sessionID string) *Claims {
+	now := time.Now()
 	return &Claims{
-		UserId: userid,
-		Role:   role,
+		UserId:     userid,
+		Role:       role,
+		IssuedAtMs: now.UnixMilli(),
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute * 30)),
+			ID:        sessionID,
+			Audience:  jwt.ClaimStrings{AccessTokenAudience},
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(AccessTokenTTL)),
 			Issuer:    os.Getenv("JWT_ISSUER"),
 		},
 	}
 }
 
-func (c *Claims) GenToken() (string, error) {
-	jwtSecret := os.Getenv("JWT_SECRET")
-	if jwtSecret == "" {
-		return "", errors.New("no secret found")
+// RemainingTTL mengembalikan sisa waktu sebelum token expired
+func (c *Claims) RemainingTTL() time.Duration {
+	if c.ExpiresAt == nil {
+		return 0
 	}
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, c)
-	return token.SignedString([]byte(jwtSecret))
+	return time.Until(c.ExpiresAt.Time)
+}
+
+func (c *Claims) GenToken() (string, error) {
+	return signToken(c)
 }
 
 func (c *Claims) VerifyToken(token string) error {
-	jwtSecret := os.Getenv("JWT_SECRET")
-	if jwtSecret == "" {
-		return errors.New("no secret found")
+	err := parseToken(token, c, jwt.WithAudience(AccessTokenAudience))
+	// token tanpa aud bukan access token
+	if errors.Is(err, jwt.ErrTokenRequiredClaimMissing) {
+		return jwt.ErrTokenInvalidAudience
+	}
+	return err
+}
+
+// ActionClaims adalah token bertanda tangan berumur pendek untuk satu keperluan (purpose),
+// misalnya link verifikasi email. Purpose disimpan sebagai audience agar tidak bisa dipakai sebagai access token,
+// karena itu purpose tidak boleh sama dengan AccessTokenAudience
+type ActionClaims struct {
+	UserId string `json:"user_id"`
+	Email  string `json:"email,omitempty"`
+	jwt.RegisteredClaims
+}
+
+func NewActionClaims(userid, email, purpose string, ttl time.Duration) *ActionClaims {
+	now := time.Now()
+	return &ActionClaims{
+		UserId: userid,
+		Email:  email,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Audience:  jwt.ClaimStrings{purpose},
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+			Issuer:    os.Getenv("JWT_ISSUER"),
+		},
+	}
+}
+
+func (c *ActionClaims) GenToken() (string, error) {
+	return signToken(c)
+}
+
+func (c *ActionClaims) VerifyToken(token, purpose string) error {
+	return parseToken(token, c, jwt.WithAudience(purpose))
+}
+
+func signToken(claims jwt.Claims) (string, error) {
+	if err := LoadSigningKeys(); err != nil {
+		return "", err
+	}
+	// tanpa JWT_KEYS_DIR, tetap memakai HS256 dengan JWT_SECRET
+	if keys.active == nil {
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+		return token.SignedString(keys.hmacSecret)
+	}
+	token := jwt.NewWithClaims(keys.active.method, claims)
+	token.Header["kid"] = keys.active.kid
+	return token.SignedString(keys.active.private)
+}
+
+func parseToken(token string, claims jwt.Claims, opts ...jwt.ParserOption) error {
+	if err := LoadSigningKeys(); err != nil {
+		return err
 	}
-	parsedToken, err := jwt.ParseWithClaims(token, c, func(t *jwt.Token) (any, error) { return []byte(jwtSecret), nil })
+	parsedToken, err := jwt.ParseWithClaims(token, claims, keys.verifyKey, opts...)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,278 @@
+package pkg
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// defaultKeyGrace adalah lama key yang sudah dipensiunkan masih diterima untuk verifikasi,
+// cukup lebih lama dari masa berlaku access token
+const defaultKeyGrace = time.Hour
+
+// legacyHMACKid adalah nama di JWT_RETIRED_KIDS untuk JWT_SECRET (token HS256 lama tanpa kid)
+const legacyHMACKid = "hs256"
+
+type signingKey struct {
+	kid       string
+	method    jwt.SigningMethod
+	private   crypto.Signer
+	public    crypto.PublicKey
+	retiredAt *time.Time
+}
+
+type keyRing struct {
+	active     *signingKey
+	keys       map[string]*signingKey
+	grace      time.Duration
+	hmacSecret []byte
+	// hmacRetiredAt diisi jika JWT_SECRET dipakai bersama JWT_KEYS_DIR: token HS256 lama hanya diterima
+	// sampai waktu ini ditambah grace
+	hmacRetiredAt *time.Time
+}
+
+var (
+	keysOnce sync.Once
+	keys     *keyRing
+	keysErr  error
+)
+
+// LoadSigningKeys memuat key JWT sekali saja. Dipanggil saat startup agar konfigurasi yang salah langsung ketahuan.
+//
+// Konfigurasi dari env:
+//   - JWT_KEYS_DIR: folder berisi private key "<kid>.pem" (RSA / Ed25519) atau public key "<kid>.pub.pem"
+//   - JWT_ACTIVE_KID: kid dari private key yang dipakai untuk sign token baru
+//   - JWT_RETIRED_KIDS: daftar "kid=RFC3339" key yang dipensiunkan, masih diterima selama JWT_KEY_GRACE setelahnya
+//   - JWT_SECRET: jika JWT_KEYS_DIR kosong, secret ini dipakai untuk sign dan verifikasi (mode HS256 seperti sebelumnya).
+//     Jika JWT_KEYS_DIR diisi, token HS256 lama (tanpa kid) hanya diterima jika JWT_RETIRED_KIDS memuat
+//     "hs256=RFC3339", dengan batas waktu yang sama seperti key lain yang dipensiunkan
+func LoadSigningKeys() error {
+	keysOnce.Do(func() {
+		keys, keysErr = loadKeyRing()
+	})
+	return keysErr
+}
+
+func loadKeyRing() (*keyRing, error) {
+	ring := &keyRing{
+		keys:  map[string]*signingKey{},
+		grace: defaultKeyGrace,
+	}
+	if secret := os.Getenv("JWT_SECRET"); secret != "" {
+		ring.hmacSecret = []byte(secret)
+	}
+	if grace := os.Getenv("JWT_KEY_GRACE"); grace != "" {
+		d, err := time.ParseDuration(grace)
+		if err != nil {
+			return nil, fmt.Errorf("invalid JWT_KEY_GRACE: %w", err)
+		}
+		ring.grace = d
+	}
+
+	dir := os.Getenv("JWT_KEYS_DIR")
+	if dir == "" {
+		if ring.hmacSecret == nil {
+			return nil, errors.New("no secret found")
+		}
+		return ring, nil
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.pem"))
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range files {
+		key, err := loadKeyFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load jwt key %s: %w", file, err)
+		}
+		// "<kid>.pem" dan "<kid>.pub.pem" adalah key yang sama, public key tidak boleh menimpa private key
+		if existing, ok := ring.keys[key.kid]; ok {
+			key, err = mergeKeys(existing, key)
+			if err != nil {
+				return nil, fmt.Errorf("failed to load jwt key %s: %w", file, err)
+			}
+		}
+		ring.keys[key.kid] = key
+	}
+
+	retired := os.Getenv("JWT_RETIRED_KIDS")
+	for _, item := range strings.Split(retired, ",") {
+		if strings.TrimSpace(item) == "" {
+			continue
+		}
+		kid, at, ok := strings.Cut(strings.TrimSpace(item), "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid JWT_RETIRED_KIDS entry %q, expected kid=RFC3339", item)
+		}
+		retiredAt, err := time.Parse(time.RFC3339, at)
+		if err != nil {
+			return nil, fmt.Errorf("invalid retired time for kid %s: %w", kid, err)
+		}
+		if kid == legacyHMACKid {
+			if ring.hmacSecret == nil {
+				return nil, errors.New("JWT_RETIRED_KIDS contains hs256 but JWT_SECRET is empty")
+			}
+			ring.hmacRetiredAt = &retiredAt
+			continue
+		}
+		if key, ok := ring.keys[kid]; ok {
+			key.retiredAt = &retiredAt
+		}
+	}
+	// tanpa batas waktu, token HS256 lama tidak diterima lagi setelah pindah ke key ring
+	if ring.hmacRetiredAt == nil {
+		ring.hmacSecret = nil
+	}
+
+	activeKid := os.Getenv("JWT_ACTIVE_KID")
+	active, ok := ring.keys[activeKid]
+	if !ok || active.private == nil {
+		return nil, fmt.Errorf("active jwt key %q not found or has no private key", activeKid)
+	}
+	if active.retiredAt != nil {
+		return nil, fmt.Errorf("active jwt key %q is retired", activeKid)
+	}
+	ring.active = active
+	return ring, nil
+}
+
+func loadKeyFile(file string) (*signingKey, error) {
+	raw, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(raw)
+	if block == nil {
+		return nil, errors.New("invalid pem")
+	}
+
+	name := filepath.Base(file)
+	kid := strings.TrimSuffix(strings.TrimSuffix(name, ".pem"), ".pub")
+
+	var parsed any
+	switch block.Type {
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		parsed, err = x509.ParsePKIXPublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("unsupported pem type %q", block.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	key := &signingKey{kid: kid}
+	switch k := parsed.(type) {
+	case *rsa.PrivateKey:
+		key.method, key.private, key.public = jwt.SigningMethodRS256, k, &k.PublicKey
+	case *rsa.PublicKey:
+		key.method, key.public = jwt.SigningMethodRS256, k
+	case ed25519.PrivateKey:
+		key.method, key.private, key.public = jwt.SigningMethodEdDSA, k, k.Public()
+	case ed25519.PublicKey:
+		key.method, key.public = jwt.SigningMethodEdDSA, k
+	default:
+		return nil, fmt.Errorf("unsupported key type %T", parsed)
+	}
+	return key, nil
+}
+
+// mergeKeys menggabungkan private key dan public key dengan kid yang sama. Keduanya harus pasangan yang sama
+func mergeKeys(a, b *signingKey) (*signingKey, error) {
+	if a.method.Alg() != b.method.Alg() {
+		return nil, fmt.Errorf("key type mismatch for kid %s", a.kid)
+	}
+	if a.private != nil && b.private != nil {
+		return nil, fmt.Errorf("duplicate private key for kid %s", a.kid)
+	}
+	pub, ok := a.public.(interface{ Equal(crypto.PublicKey) bool })
+	if !ok || !pub.Equal(b.public) {
+		return nil, fmt.Errorf("public key does not match private key for kid %s", a.kid)
+	}
+	if b.private != nil {
+		return b, nil
+	}
+	return a, nil
+}
+
+// verifyKey memilih key untuk memverifikasi token berdasarkan header kid,
+// dan memastikan algoritma token sesuai dengan jenis key (mencegah algorithm confusion)
+func (r *keyRing) verifyKey(t *jwt.Token) (any, error) {
+	kid, _ := t.Header["kid"].(string)
+	if kid == "" {
+		if r.hmacSecret == nil || t.Method != jwt.SigningMethodHS256 {
+			return nil, jwt.ErrTokenUnverifiable
+		}
+		if r.hmacRetiredAt != nil && time.Now().After(r.hmacRetiredAt.Add(r.grace)) {
+			return nil, jwt.ErrTokenUnverifiable
+		}
+		return r.hmacSecret, nil
+	}
+
+	key, ok := r.keys[kid]
+	if !ok || t.Method.Alg() != key.method.Alg() {
+		return nil, jwt.ErrTokenUnverifiable
+	}
+	if key.retiredAt != nil && time.Now().After(key.retiredAt.Add(r.grace)) {
+		return nil, jwt.ErrTokenUnverifiable
+	}
+	return key.public, nil
+}
+
+// JWK adalah public key dalam format JSON Web Key (RFC 7517)
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+// JWKS mengembalikan semua public key yang masih berlaku untuk verifikasi token oleh service lain
+func JWKS() ([]JWK, error) {
+	if err := LoadSigningKeys(); err != nil {
+		return nil, err
+	}
+
+	jwks := []JWK{}
+	for _, key := range keys.keys {
+		if key.retiredAt != nil && time.Now().After(key.retiredAt.Add(keys.grace)) {
+			continue
+		}
+		jwk := JWK{Kid: key.kid, Use: "sig", Alg: key.method.Alg()}
+		switch pub := key.public.(type) {
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.Kty = "OKP"
+			jwk.Crv = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(pub)
+		}
+		jwks = append(jwks, jwk)
+	}
+	sort.Slice(jwks, func(i, j int) bool { return jwks[i].Kid < jwks[j].Kid })
+	return jwks, nil
+}
@@ -0,0 +1,257 @@
+package pkg
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// setKeyEnv mengosongkan semua env JWT lalu mengisi yang diberikan
+func setKeyEnv(t *testing.T, env map[string]string) {
+	t.Helper()
+	for _, name := range []string{"JWT_SECRET", "JWT_KEYS_DIR", "JWT_ACTIVE_KID", "JWT_RETIRED_KIDS", "JWT_KEY_GRACE"} {
+		t.Setenv(name, env[name])
+	}
+}
+
+// writeEd25519Key menulis "<kid>.pem" dan "<kid>.pub.pem" ke dir. pub dipakai sebagai public key jika tidak nil
+func writeEd25519Key(t *testing.T, dir, kid string, pub ed25519.PublicKey) ed25519.PrivateKey {
+	t.Helper()
+	keyPub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pub == nil {
+		pub = keyPub
+	}
+	privDER, err := x509.MarshalPKCS8PrivateKey(priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pubDER, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writePEM(t, filepath.Join(dir, kid+".pem"), "PRIVATE KEY", privDER)
+	writePEM(t, filepath.Join(dir, kid+".pub.pem"), "PUBLIC KEY", pubDER)
+	return priv
+}
+
+func writePEM(t *testing.T, file, blockType string, der []byte) {
+	t.Helper()
+	if err := os.WriteFile(file, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func signWith(t *testing.T, method jwt.SigningMethod, kid string, key any) string {
+	t.Helper()
+	token := jwt.NewWithClaims(method, jwt.RegisteredClaims{ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute))})
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed
+}
+
+func verifyWith(ring *keyRing, token string) error {
+	_, err := jwt.Parse(token, ring.verifyKey)
+	return err
+}
+
+func TestLoadKeyRingHMAC(t *testing.T) {
+	setKeyEnv(t, map[string]string{"JWT_SECRET": "secret"})
+	ring, err := loadKeyRing()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ring.active != nil {
+		t.Error("HS256 mode should not have an active key")
+	}
+	if err := verifyWith(ring, signWith(t, jwt.SigningMethodHS256, "", []byte("secret"))); err != nil {
+		t.Errorf("HS256 token rejected: %v", err)
+	}
+	if err := verifyWith(ring, signWith(t, jwt.SigningMethodHS256, "", []byte("other"))); err == nil {
+		t.Error("HS256 token with wrong secret accepted")
+	}
+
+	setKeyEnv(t, nil)
+	if _, err := loadKeyRing(); err == nil {
+		t.Error("loadKeyRing() without any key should fail")
+	}
+}
+
+func TestLoadKeyRingEdDSA(t *testing.T) {
+	dir := t.TempDir()
+	priv := writeEd25519Key(t, dir, "k1", nil)
+	setKeyEnv(t, map[string]string{"JWT_KEYS_DIR": dir, "JWT_ACTIVE_KID": "k1"})
+
+	ring, err := loadKeyRing()
+	if err != nil {
+		t.Fatalf("loadKeyRing() error: %v", err)
+	}
+	if ring.active == nil || ring.active.kid != "k1" {
+		t.Fatalf("active key = %+v, want k1", ring.active)
+	}
+	if err := verifyWith(ring, signWith(t, jwt.SigningMethodEdDSA, "k1", priv)); err != nil {
+		t.Errorf("token signed by active key rejected: %v", err)
+	}
+	if err := verifyWith(ring, signWith(t, jwt.SigningMethodEdDSA, "unknown", priv)); err == nil {
+		t.Error("token with unknown kid accepted")
+	}
+	// algorithm confusion: kid EdDSA dengan token HS256 ditolak
+	if err := verifyWith(ring, signWith(t, jwt.SigningMethodHS256, "k1", []byte("secret"))); err == nil {
+		t.Error("HS256 token with EdDSA kid accepted")
+	}
+
+	setKeyEnv(t, map[string]string{"JWT_KEYS_DIR": dir, "JWT_ACTIVE_KID": "missing"})
+	if _, err := loadKeyRing(); err == nil {
+		t.Error("loadKeyRing() with unknown JWT_ACTIVE_KID should fail")
+	}
+}
+
+func TestLoadKeyRingMergesPublicKey(t *testing.T) {
+	dir := t.TempDir()
+	priv := writeEd25519Key(t, dir, "k1", nil)
+	setKeyEnv(t, map[string]string{"JWT_KEYS_DIR": dir, "JWT_ACTIVE_KID": "k1"})
+
+	// public key "<kid>.pub.pem" tidak boleh menimpa private key dengan kid yang sama
+	ring, err := loadKeyRing()
+	if err != nil {
+		t.Fatalf("loadKeyRing() error: %v", err)
+	}
+	if ring.active == nil || ring.active.private == nil {
+		t.Fatal("active key has no private key")
+	}
+	if err := verifyWith(ring, signWith(t, jwt.SigningMethodEdDSA, "k1", priv)); err != nil {
+		t.Errorf("token signed by active key rejected: %v", err)
+	}
+	// algorithm confusion: kid EdDSA dengan token HS256 ditolak
+	if err := verifyWith(ring, signWith(t, jwt.SigningMethodHS256, "k1", []byte("secret"))); err == nil {
+		t.Error("HS256 token with EdDSA kid accepted")
+	}
+}
+
+func TestLoadKeyRingRejectsMismatchedPublicKey(t *testing.T) {
+	otherPub, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	writeEd25519Key(t, dir, "k1", otherPub)
+	setKeyEnv(t, map[string]string{"JWT_KEYS_DIR": dir, "JWT_ACTIVE_KID": "k1"})
+
+	if _, err := loadKeyRing(); err == nil || !strings.Contains(err.Error(), "does not match") {
+		t.Errorf("loadKeyRing() = %v, want public key mismatch", err)
+	}
+}
+
+func TestLoadKeyRingLegacyHMAC(t *testing.T) {
+	dir := t.TempDir()
+	writeEd25519Key(t, dir, "k1", nil)
+	legacy := signWith(t, jwt.SigningMethodHS256, "", []byte("secret"))
+
+	// tanpa entry hs256, token HS256 lama langsung ditolak setelah pindah ke key ring
+	setKeyEnv(t, map[string]string{"JWT_SECRET": "secret", "JWT_KEYS_DIR": dir, "JWT_ACTIVE_KID": "k1"})
+	ring, err := loadKeyRing()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := verifyWith(ring, legacy); err == nil {
+		t.Error("legacy HS256 token accepted without a hs256 retirement entry")
+	}
+
+	// masih dalam masa grace
+	setKeyEnv(t, map[string]string{
+		"JWT_SECRET":       "secret",
+		"JWT_KEYS_DIR":     dir,
+		"JWT_ACTIVE_KID":   "k1",
+		"JWT_RETIRED_KIDS": "hs256=" + time.Now().Format(time.RFC3339),
+	})
+	ring, err = loadKeyRing()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := verifyWith(ring, legacy); err != nil {
+		t.Errorf("legacy HS256 token rejected during grace: %v", err)
+	}
+
+	// masa grace sudah lewat
+	setKeyEnv(t, map[string]string{
+		"JWT_SECRET":       "secret",
+		"JWT_KEYS_DIR":     dir,
+		"JWT_ACTIVE_KID":   "k1",
+		"JWT_RETIRED_KIDS": "hs256=" + time.Now().Add(-2*time.Hour).Format(time.RFC3339),
+	})
+	ring, err = loadKeyRing()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := verifyWith(ring, legacy); err == nil {
+		t.Error("legacy HS256 token accepted after grace")
+	}
+
+	// entry hs256 tanpa JWT_SECRET adalah konfigurasi yang salah
+	setKeyEnv(t, map[string]string{
+		"JWT_KEYS_DIR":     dir,
+		"JWT_ACTIVE_KID":   "k1",
+		"JWT_RETIRED_KIDS": "hs256=" + time.Now().Format(time.RFC3339),
+	})
+	if _, err := loadKeyRing(); err == nil {
+		t.Error("loadKeyRing() with hs256 entry but no JWT_SECRET should fail")
+	}
+}
+
+func TestLoadKeyRingRetiredKey(t *testing.T) {
+	dir := t.TempDir()
+	oldPriv := writeEd25519Key(t, dir, "old", nil)
+	writeEd25519Key(t, dir, "new", nil)
+	oldToken := signWith(t, jwt.SigningMethodEdDSA, "old", oldPriv)
+
+	setKeyEnv(t, map[string]string{
+		"JWT_KEYS_DIR":     dir,
+		"JWT_ACTIVE_KID":   "new",
+		"JWT_RETIRED_KIDS": "old=" + time.Now().Format(time.RFC3339),
+	})
+	ring, err := loadKeyRing()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := verifyWith(ring, oldToken); err != nil {
+		t.Errorf("token of retired key rejected during grace: %v", err)
+	}
+
+	setKeyEnv(t, map[string]string{
+		"JWT_KEYS_DIR":     dir,
+		"JWT_ACTIVE_KID":   "new",
+		"JWT_RETIRED_KIDS": "old=" + time.Now().Add(-2*time.Hour).Format(time.RFC3339),
+	})
+	ring, err = loadKeyRing()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := verifyWith(ring, oldToken); err == nil {
+		t.Error("token of retired key accepted after grace")
+	}
+
+	// key yang dipensiunkan tidak boleh menjadi key aktif
+	setKeyEnv(t, map[string]string{
+		"JWT_KEYS_DIR":     dir,
+		"JWT_ACTIVE_KID":   "old",
+		"JWT_RETIRED_KIDS": "old=" + time.Now().Format(time.RFC3339),
+	})
+	if _, err := loadKeyRing(); err == nil {
+		t.Error("loadKeyRing() with a retired active key should fail")
+	}
+}
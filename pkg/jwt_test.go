@@ -0,0 +1,84 @@
+package pkg
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func TestAccessTokenIssuedAtMs(t *testing.T) {
+	t.Setenv("JWT_SECRET", "secret")
+	t.Setenv("JWT_ISSUER", "test")
+
+	claims := NewJWTClaims("user-1", "user", "session-1")
+	token, err := claims.GenToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var parsed Claims
+	if err := parsed.VerifyToken(token); err != nil {
+		t.Fatalf("VerifyToken() error: %v", err)
+	}
+	if parsed.IssuedAtMs != claims.IssuedAtMs || parsed.IssuedAtMs/1000 != parsed.IssuedAt.Unix() {
+		t.Errorf("iat_ms = %d, iat = %d, want %d", parsed.IssuedAtMs, parsed.IssuedAt.Unix(), claims.IssuedAtMs)
+	}
+
+	// iat dan exp standar tetap berupa detik bulat
+	payload, err := base64.RawURLEncoding.DecodeString(strings.Split(token, ".")[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(payload, &raw); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"iat", "exp"} {
+		if strings.Contains(string(raw[name]), ".") {
+			t.Errorf("%s = %s, want whole seconds", name, raw[name])
+		}
+	}
+}
+
+func TestAccessTokenAudience(t *testing.T) {
+	t.Setenv("JWT_SECRET", "secret")
+	t.Setenv("JWT_ISSUER", "test")
+
+	access, err := NewJWTClaims("user-1", "user", "session-1").GenToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+	action, err := NewActionClaims("user-1", "", "mfa-pending", time.Minute).GenToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// token lama tanpa aud
+	legacy, err := signToken(&Claims{UserId: "user-1", Role: "user", RegisteredClaims: jwt.RegisteredClaims{
+		Issuer:    "test",
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var claims Claims
+	if err := claims.VerifyToken(access); err != nil {
+		t.Errorf("access token: VerifyToken() error: %v", err)
+	}
+	for name, token := range map[string]string{"action token": action, "token without aud": legacy} {
+		var claims Claims
+		if err := claims.VerifyToken(token); !errors.Is(err, jwt.ErrTokenInvalidAudience) {
+			t.Errorf("%s: VerifyToken() error = %v, want %v", name, err, jwt.ErrTokenInvalidAudience)
+		}
+	}
+
+	var actionClaims ActionClaims
+	if err := actionClaims.VerifyToken(access, "mfa-pending"); !errors.Is(err, jwt.ErrTokenInvalidAudience) {
+		t.Errorf("access token as action token: error = %v, want %v", err, jwt.ErrTokenInvalidAudience)
+	}
+}
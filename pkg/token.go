@@ -0,0 +1,31 @@
+package pkg
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"time"
+)
+
+// RefreshTokenTTL adalah masa berlaku refresh token
+const RefreshTokenTTL = time.Hour * 24 * 30
+
+// PasswordResetTokenTTL adalah masa berlaku token reset password
+const PasswordResetTokenTTL = time.Hour
+
+// GenRandomToken membuat token opaque acak sepanjang size byte (encoding base64 url-safe)
+func GenRandomToken(size int) (string, error) {
+	b := make([]byte, size)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// HashToken menghasilkan hash sha256 dari token opaque untuk disimpan di database.
+// Token acak sudah memiliki entropi tinggi sehingga tidak perlu argon2
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
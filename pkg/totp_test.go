@@ -0,0 +1,101 @@
+package pkg
+
+import (
+	"encoding/base32"
+	"strings"
+	"testing"
+	"time"
+)
+
+// secret SHA1 dari RFC 6238 appendix B ("12345678901234567890")
+var rfc6238Secret = base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+
+func TestValidateTOTPRFC6238(t *testing.T) {
+	// kode 8 digit di RFC dipotong menjadi 6 digit terakhir
+	tests := []struct {
+		unix int64
+		code string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+	for _, tt := range tests {
+		step, ok := ValidateTOTP(rfc6238Secret, tt.code, time.Unix(tt.unix, 0))
+		if !ok {
+			t.Errorf("ValidateTOTP(%s) at %d = false, want true", tt.code, tt.unix)
+			continue
+		}
+		if step != tt.unix/totpPeriod {
+			t.Errorf("ValidateTOTP(%s) at %d step = %d, want %d", tt.code, tt.unix, step, tt.unix/totpPeriod)
+		}
+	}
+}
+
+func TestValidateTOTPSkew(t *testing.T) {
+	// kode "287082" berlaku pada step 1 (detik 30-59)
+	tests := []struct {
+		unix int64
+		want bool
+	}{
+		{0, true},   // 1 step sebelum
+		{59, true},  // step yang sama
+		{60, true},  // 1 step sesudah
+		{89, true},  // akhir 1 step sesudah
+		{90, false}, // 2 step sesudah
+	}
+	for _, tt := range tests {
+		step, ok := ValidateTOTP(rfc6238Secret, "287082", time.Unix(tt.unix, 0))
+		if ok != tt.want {
+			t.Errorf("ValidateTOTP at %d = %t, want %t", tt.unix, ok, tt.want)
+		}
+		if ok && step != 1 {
+			t.Errorf("ValidateTOTP at %d step = %d, want 1", tt.unix, step)
+		}
+	}
+}
+
+func TestValidateTOTPRejectsInvalidInput(t *testing.T) {
+	at := time.Unix(59, 0)
+	tests := []struct {
+		name   string
+		secret string
+		code   string
+	}{
+		{"too short", rfc6238Secret, "28708"},
+		{"too long", rfc6238Secret, "2870820"},
+		{"8 digit rfc code", rfc6238Secret, "94287082"},
+		{"empty", rfc6238Secret, ""},
+		{"non numeric", rfc6238Secret, "28708a"},
+		{"letters", rfc6238Secret, "abcdef"},
+		{"invalid base32 secret", "not-base32!", "287082"},
+		{"padded base32 secret", rfc6238Secret + "====", "287082"},
+	}
+	for _, tt := range tests {
+		if _, ok := ValidateTOTP(tt.secret, tt.code, at); ok {
+			t.Errorf("%s: ValidateTOTP(%q, %q) = true, want false", tt.name, tt.secret, tt.code)
+		}
+	}
+}
+
+func TestGenTOTPSecret(t *testing.T) {
+	secret, err := GenTOTPSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := totpEncoding.DecodeString(secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	code := totpCode(key, now.Unix()/totpPeriod)
+	// secret yang diketik manual dari aplikasi authenticator bisa berupa huruf kecil
+	for _, s := range []string{secret, strings.ToLower(secret)} {
+		if _, ok := ValidateTOTP(s, code, now); !ok {
+			t.Errorf("ValidateTOTP(%q) = false, want true", s)
+		}
+	}
+}